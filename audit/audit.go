@@ -0,0 +1,110 @@
+// Package audit records who performed which operation on a tenant and how it ended.
+package audit
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/fabric8io/fabric8-init-tenant/auth"
+	"github.com/goadesign/goa/middleware"
+	uuid "github.com/satori/go.uuid"
+)
+
+// Represents the outcome of an operation. Operations running in the background are recorded as
+// accepted when requested, and recorded again once they succeeded or failed.
+const (
+	OutcomeAccepted  = "accepted"
+	OutcomeSucceeded = "succeeded"
+	OutcomeFailed    = "failed"
+)
+
+// Details hold operation specific values, e.g. the namespace type of a reset
+type Details map[string]string
+
+// Value - Implementation of valuer for database/sql
+func (d Details) Value() (driver.Value, error) {
+	if d == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(d)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// Scan - Implement the database/sql scanner interface
+func (d *Details) Scan(value interface{}) error {
+	if value == nil {
+		*d = nil
+		return nil
+	}
+	var b []byte
+	switch v := value.(type) {
+	case []byte:
+		b = v
+	case string:
+		b = []byte(v)
+	default:
+		return errors.New("failed to scan Details")
+	}
+	return json.Unmarshal(b, d)
+}
+
+// Record is an entry of the audit trail. Records are only ever added, never changed.
+type Record struct {
+	ID        uuid.UUID `sql:"type:uuid" gorm:"primary_key"`
+	CreatedAt time.Time
+	// ActorID and ActorEmail identify the caller who requested the operation, empty for operations
+	// the service started on its own
+	ActorID    uuid.UUID `sql:"type:uuid"`
+	ActorEmail string
+	// ActingAs is the OpenShift user the operation was performed as
+	ActingAs      string
+	Operation     string
+	TenantID      uuid.UUID `sql:"type:uuid"`
+	TargetVersion string
+	Outcome       string
+	Error         string
+	RequestID     string
+	Details       Details `sql:"type:jsonb"`
+}
+
+// TableName overrides the table name settings in Gorm to force a specific table name
+// in the database.
+func (m Record) TableName() string {
+	return "audit_records"
+}
+
+// NewRecord returns an accepted record of the operation on the tenant, performed by the caller
+// and as part of the request found in the context
+func NewRecord(ctx context.Context, operation string, tenantID uuid.UUID) *Record {
+	r := &Record{
+		Operation: operation,
+		TenantID:  tenantID,
+		Outcome:   OutcomeAccepted,
+		RequestID: middleware.ContextRequestID(ctx),
+	}
+	if identity := auth.ContextIdentity(ctx); identity != nil {
+		r.ActorID = identity.ID
+		r.ActorEmail = identity.Email
+	}
+	return r
+}
+
+// Completed returns a new record of the operation that succeeded or failed depending on the given error
+func (m Record) Completed(err error) *Record {
+	r := m
+	r.ID = uuid.Nil
+	r.CreatedAt = time.Time{}
+	r.Outcome = OutcomeSucceeded
+	r.Error = ""
+	if err != nil {
+		r.Outcome = OutcomeFailed
+		r.Error = err.Error()
+	}
+	return &r
+}
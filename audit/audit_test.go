@@ -0,0 +1,72 @@
+package audit_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/almighty/almighty-core/resource"
+	"github.com/fabric8io/fabric8-init-tenant/audit"
+	"github.com/fabric8io/fabric8-init-tenant/auth"
+	"github.com/goadesign/goa/middleware"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// requestContext returns the context of a request with the given ID handled by the caller
+func requestContext(t *testing.T, requestID string, identity *auth.Identity) context.Context {
+	var ctx context.Context
+	handler := middleware.RequestID()(func(c context.Context, rw http.ResponseWriter, req *http.Request) error {
+		ctx = c
+		return nil
+	})
+	req, err := http.NewRequest("POST", "/api/tenant", nil)
+	require.NoError(t, err)
+	req.Header.Set(middleware.RequestIDHeader, requestID)
+	require.NoError(t, handler(auth.WithIdentity(context.Background(), identity), httptest.NewRecorder(), req))
+	return ctx
+}
+
+func TestNewRecord(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+
+	identity := &auth.Identity{ID: uuid.NewV4(), Email: "admin@example.com"}
+	tenantID := uuid.NewV4()
+
+	r := audit.NewRecord(requestContext(t, "request-1", identity), "reset", tenantID)
+	assert.Equal(t, identity.ID, r.ActorID)
+	assert.Equal(t, "admin@example.com", r.ActorEmail)
+	assert.Equal(t, "reset", r.Operation)
+	assert.Equal(t, tenantID, r.TenantID)
+	assert.Equal(t, audit.OutcomeAccepted, r.Outcome)
+	assert.Equal(t, "request-1", r.RequestID)
+}
+
+func TestNewRecordWithoutCaller(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+
+	r := audit.NewRecord(context.Background(), "migrate", uuid.NewV4())
+	assert.Equal(t, uuid.Nil, r.ActorID)
+	assert.Equal(t, "", r.RequestID)
+}
+
+func TestCompleted(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+
+	accepted := audit.NewRecord(context.Background(), "update", uuid.NewV4())
+	accepted.ID = uuid.NewV4()
+	accepted.TargetVersion = "1.0.92"
+
+	succeeded := accepted.Completed(nil)
+	assert.Equal(t, uuid.Nil, succeeded.ID)
+	assert.Equal(t, audit.OutcomeSucceeded, succeeded.Outcome)
+	assert.Equal(t, "1.0.92", succeeded.TargetVersion)
+	assert.Equal(t, audit.OutcomeAccepted, accepted.Outcome)
+
+	failed := accepted.Completed(fmt.Errorf("cluster unavailable"))
+	assert.Equal(t, audit.OutcomeFailed, failed.Outcome)
+	assert.Equal(t, "cluster unavailable", failed.Error)
+}
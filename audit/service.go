@@ -0,0 +1,92 @@
+package audit
+
+import (
+	"time"
+
+	"github.com/fabric8io/fabric8-init-tenant/webhook"
+	"github.com/jinzhu/gorm"
+	uuid "github.com/satori/go.uuid"
+)
+
+// DefaultLimit is the number of records returned when the filter sets no limit
+const DefaultLimit = 100
+
+// Filter selects audit records, unset fields match all records
+type Filter struct {
+	TenantID *uuid.UUID
+	ActorID  *uuid.UUID
+	Since    *time.Time
+	Until    *time.Time
+	Limit    int
+}
+
+type Service interface {
+	AddRecord(record *Record, events ...webhook.Event) error
+	FindRecords(filter Filter) ([]*Record, error)
+}
+
+func NewDBService(db *gorm.DB) Service {
+	return &DBService{db: db}
+}
+
+type DBService struct {
+	db *gorm.DB
+}
+
+// AddRecord appends the record to the audit trail, and publishes the events reporting the outcome of
+// the operation in the same transaction
+func (s DBService) AddRecord(record *Record, events ...webhook.Event) error {
+	if record.ID == uuid.Nil {
+		record.ID = uuid.NewV4()
+	}
+	tx := s.db.Begin()
+	if err := tx.Create(record).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	for _, e := range events {
+		if err := webhook.Publish(tx, e); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit().Error
+}
+
+// FindRecords returns the records matching the filter, the newest first
+func (s DBService) FindRecords(filter Filter) ([]*Record, error) {
+	query := s.db.Table(Record{}.TableName())
+	if filter.TenantID != nil {
+		query = query.Where("tenant_id = ?", *filter.TenantID)
+	}
+	if filter.ActorID != nil {
+		query = query.Where("actor_id = ?", *filter.ActorID)
+	}
+	if filter.Since != nil {
+		query = query.Where("created_at >= ?", *filter.Since)
+	}
+	if filter.Until != nil {
+		query = query.Where("created_at < ?", *filter.Until)
+	}
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+	var r []*Record
+	err := query.Order("created_at desc").Limit(limit).Find(&r).Error
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+type NilService struct {
+}
+
+func (s NilService) AddRecord(record *Record, events ...webhook.Event) error {
+	return nil
+}
+
+func (s NilService) FindRecords(filter Filter) ([]*Record, error) {
+	return nil, nil
+}
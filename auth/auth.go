@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"context"
+	"crypto/rsa"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/goadesign/goa"
+	goajwt "github.com/goadesign/goa/middleware/security/jwt"
+)
+
+// KeyFunc returns the public key a token signed with the given key id is verified with
+type KeyFunc func(kid string) (*rsa.PublicKey, error)
+
+// New returns a middleware validating the JWT of the request as defined by the scheme. Unlike the goa
+// JWT middleware, which tries a fixed list of keys, the signing key is selected by the kid token header
+// so keys can be rotated at runtime. The claims are checked by the validator.
+// The token is stored in the request context for goajwt.ContextJWT, the identity read from the named
+// claims for ContextIdentity.
+func New(keys KeyFunc, validator Validator, names ClaimNames, scheme *goa.JWTSecurity) goa.Middleware {
+	// the claims are validated by the validator, with leeway and distinct errors
+	parser := &jwt.Parser{SkipClaimsValidation: true}
+	return func(nextHandler goa.Handler) goa.Handler {
+		return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			raw, err := extractToken(req, scheme)
+			if err != nil {
+				return err
+			}
+			token, err := parser.Parse(raw, func(token *jwt.Token) (interface{}, error) {
+				if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
+					return nil, fmt.Errorf("unexpected signing method %v", token.Header["alg"])
+				}
+				kid, _ := token.Header["kid"].(string)
+				return keys(kid)
+			})
+			if err != nil {
+				return goajwt.ErrJWTError(fmt.Sprintf("JWT validation failed: %v", err))
+			}
+			claims, ok := token.Claims.(jwt.MapClaims)
+			if !ok {
+				return goajwt.ErrJWTError("unexpected claims")
+			}
+			err = validator.Validate(claims, time.Now())
+			if err != nil {
+				return err
+			}
+			identity, err := NewIdentity(token, names)
+			if err != nil {
+				return goajwt.ErrJWTError(err.Error())
+			}
+			ctx = WithIdentity(goajwt.WithJWT(ctx, token), identity)
+			return nextHandler(ctx, rw, req)
+		}
+	}
+}
+
+func extractToken(req *http.Request, scheme *goa.JWTSecurity) (string, error) {
+	if scheme.In == goa.LocQuery {
+		raw := req.URL.Query().Get(scheme.Name)
+		if raw == "" {
+			return "", goajwt.ErrJWTError(fmt.Sprintf("missing parameter %q", scheme.Name))
+		}
+		return raw, nil
+	}
+	val := req.Header.Get(scheme.Name)
+	if val == "" {
+		return "", goajwt.ErrJWTError(fmt.Sprintf("missing header %q", scheme.Name))
+	}
+	if !strings.HasPrefix(strings.ToLower(val), "bearer ") {
+		return "", goajwt.ErrJWTError(fmt.Sprintf("invalid or malformed %q header, expected 'Bearer JWT-token...'", scheme.Name))
+	}
+	return strings.TrimSpace(val[len("bearer "):]), nil
+}
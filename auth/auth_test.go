@@ -0,0 +1,86 @@
+package auth_test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/almighty/almighty-core/resource"
+	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/fabric8io/fabric8-init-tenant/auth"
+	"github.com/fabric8io/fabric8-init-tenant/idp"
+	"github.com/goadesign/goa"
+	goajwt "github.com/goadesign/goa/middleware/security/jwt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const subjectID = "2b9ac9d6-d6e9-4a2c-8a52-2e2bd1e10f9c"
+
+func sign(t *testing.T, key *rsa.PrivateKey, kid string) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{"sub": subjectID})
+	token.Header["kid"] = kid
+	raw, err := token.SignedString(key)
+	require.NoError(t, err)
+	return raw
+}
+
+func TestMiddleware(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+
+	current, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+	rotated, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+
+	keys := func(kid string) (*rsa.PublicKey, error) {
+		if kid == "current" {
+			return &current.PublicKey, nil
+		}
+		return nil, idp.ErrUnknownKey{Kid: kid}
+	}
+	scheme := &goa.JWTSecurity{In: goa.LocHeader, Name: "Authorization"}
+
+	var subject interface{}
+	handler := auth.New(keys, auth.Validator{}, auth.DefaultClaimNames, scheme)(func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+		subject = goajwt.ContextJWT(ctx).Claims.(jwt.MapClaims)["sub"]
+		assert.Equal(t, subjectID, auth.ContextIdentity(ctx).ID.String())
+		return nil
+	})
+	call := func(header string) error {
+		subject = nil
+		req := httptest.NewRequest("GET", "/api/tenant", nil)
+		if header != "" {
+			req.Header.Set("Authorization", header)
+		}
+		return handler(context.Background(), httptest.NewRecorder(), req)
+	}
+
+	t.Run("key selected by kid", func(t *testing.T) {
+		require.NoError(t, call("Bearer "+sign(t, current, "current")))
+		assert.Equal(t, subjectID, subject)
+	})
+
+	t.Run("unknown kid", func(t *testing.T) {
+		err := call("Bearer " + sign(t, rotated, "rotated"))
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "unknown signing key")
+		assert.Nil(t, subject)
+	})
+
+	t.Run("wrong key for kid", func(t *testing.T) {
+		assert.Error(t, call("Bearer "+sign(t, rotated, "current")))
+		assert.Nil(t, subject)
+	})
+
+	t.Run("missing header", func(t *testing.T) {
+		assert.Error(t, call(""))
+	})
+
+	t.Run("not a bearer token", func(t *testing.T) {
+		assert.Error(t, call("Basic dXNlcjpwYXNz"))
+	})
+}
@@ -0,0 +1,98 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/almighty/almighty-core/errors"
+	jwt "github.com/dgrijalva/jwt-go"
+	uuid "github.com/satori/go.uuid"
+)
+
+// ClaimNames are the token claims the identity of the caller is read from
+type ClaimNames struct {
+	Subject  string
+	Email    string
+	Username string
+	Name     string
+}
+
+// DefaultClaimNames are the claims of the Keycloak access tokens
+var DefaultClaimNames = ClaimNames{
+	Subject:  "sub",
+	Email:    "email",
+	Username: "preferred_username",
+	Name:     "name",
+}
+
+// Identity is the caller of a request, the subject is the ID of the tenant
+type Identity struct {
+	ID       uuid.UUID
+	Email    string
+	Username string
+	Name     string
+	// TokenID is the jti claim identifying the token the identity is read from
+	TokenID string
+	Token   *jwt.Token
+}
+
+// NewIdentity reads the identity from the token claims. The subject must be a UUID other than the
+// nil UUID, the other claims are optional but must be strings if present.
+func NewIdentity(token *jwt.Token, names ClaimNames) (*Identity, error) {
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, errors.NewUnauthorizedError("unexpected token claims")
+	}
+	sub, err := stringClaim(claims, names.Subject)
+	if err != nil {
+		return nil, err
+	}
+	id, err := uuid.FromString(sub)
+	if err != nil || uuid.Equal(id, uuid.Nil) {
+		return nil, errors.NewUnauthorizedError(fmt.Sprintf("claim %q is not a valid subject ID", names.Subject))
+	}
+	identity := &Identity{ID: id, Token: token}
+	optional := []struct {
+		name  string
+		value *string
+	}{
+		{names.Email, &identity.Email},
+		{names.Username, &identity.Username},
+		{names.Name, &identity.Name},
+		{"jti", &identity.TokenID},
+	}
+	for _, claim := range optional {
+		if _, found := claims[claim.name]; claim.name == "" || !found {
+			continue
+		}
+		if *claim.value, err = stringClaim(claims, claim.name); err != nil {
+			return nil, err
+		}
+	}
+	return identity, nil
+}
+
+func stringClaim(claims jwt.MapClaims, name string) (string, error) {
+	value, found := claims[name]
+	if !found {
+		return "", errors.NewUnauthorizedError(fmt.Sprintf("missing claim %q", name))
+	}
+	s, ok := value.(string)
+	if !ok {
+		return "", errors.NewUnauthorizedError(fmt.Sprintf("claim %q is not a string", name))
+	}
+	return s, nil
+}
+
+type identityKey struct{}
+
+// WithIdentity returns a copy of the context holding the identity of the caller
+func WithIdentity(ctx context.Context, identity *Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, identity)
+}
+
+// ContextIdentity returns the identity of the caller, or nil if the request is not authenticated
+func ContextIdentity(ctx context.Context) *Identity {
+	identity, _ := ctx.Value(identityKey{}).(*Identity)
+	return identity
+}
@@ -0,0 +1,77 @@
+package auth_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/almighty/almighty-core/resource"
+	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/fabric8io/fabric8-init-tenant/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewIdentity(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+
+	identity := func(claims jwt.MapClaims, names auth.ClaimNames) (*auth.Identity, error) {
+		return auth.NewIdentity(jwt.NewWithClaims(jwt.SigningMethodRS256, claims), names)
+	}
+
+	t.Run("all claims", func(t *testing.T) {
+		id, err := identity(jwt.MapClaims{
+			"sub":                subjectID,
+			"email":              "john.doe@example.com",
+			"preferred_username": "jdoe",
+			"name":               "John Doe",
+		}, auth.DefaultClaimNames)
+		require.NoError(t, err)
+		assert.Equal(t, subjectID, id.ID.String())
+		assert.Equal(t, "john.doe@example.com", id.Email)
+		assert.Equal(t, "jdoe", id.Username)
+		assert.Equal(t, "John Doe", id.Name)
+	})
+
+	t.Run("optional claims missing", func(t *testing.T) {
+		id, err := identity(jwt.MapClaims{"sub": subjectID}, auth.DefaultClaimNames)
+		require.NoError(t, err)
+		assert.Equal(t, "", id.Email)
+	})
+
+	t.Run("configured claim names", func(t *testing.T) {
+		names := auth.ClaimNames{Subject: "user_id", Email: "mail"}
+		id, err := identity(jwt.MapClaims{"user_id": subjectID, "mail": "jdoe@example.com"}, names)
+		require.NoError(t, err)
+		assert.Equal(t, subjectID, id.ID.String())
+		assert.Equal(t, "jdoe@example.com", id.Email)
+	})
+
+	t.Run("email not a string", func(t *testing.T) {
+		_, err := identity(jwt.MapClaims{"sub": subjectID, "email": 42.0}, auth.DefaultClaimNames)
+		assert.Error(t, err)
+	})
+
+	t.Run("missing subject", func(t *testing.T) {
+		_, err := identity(jwt.MapClaims{"email": "jdoe@example.com"}, auth.DefaultClaimNames)
+		assert.Error(t, err)
+	})
+
+	t.Run("malformed subject", func(t *testing.T) {
+		_, err := identity(jwt.MapClaims{"sub": "jdoe"}, auth.DefaultClaimNames)
+		assert.Error(t, err)
+	})
+
+	t.Run("nil subject", func(t *testing.T) {
+		_, err := identity(jwt.MapClaims{"sub": "00000000-0000-0000-0000-000000000000"}, auth.DefaultClaimNames)
+		assert.Error(t, err)
+	})
+}
+
+func TestContextIdentity(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+
+	ctx := context.Background()
+	assert.Nil(t, auth.ContextIdentity(ctx))
+	identity := &auth.Identity{Email: "jdoe@example.com"}
+	assert.Equal(t, identity, auth.ContextIdentity(auth.WithIdentity(ctx, identity)))
+}
@@ -0,0 +1,95 @@
+package auth
+
+import (
+	"fmt"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/fabric8io/fabric8-init-tenant/jsonapi"
+	"github.com/goadesign/goa"
+	goajwt "github.com/goadesign/goa/middleware/security/jwt"
+)
+
+var (
+	// ErrTokenExpired is returned for tokens past their exp claim
+	ErrTokenExpired = goa.NewErrorClass(jsonapi.ErrorCodeTokenExpired, 401)
+	// ErrInvalidAudience is returned for tokens issued to a client that is not allowed
+	ErrInvalidAudience = goa.NewErrorClass(jsonapi.ErrorCodeInvalidAudience, 401)
+)
+
+// Validator validates the claims of a token with a verified signature
+type Validator struct {
+	// Issuer the iss claim must match, not checked if empty
+	Issuer string
+	// Audiences the aud or azp claim must contain one of, not checked if empty
+	Audiences []string
+	// Leeway tolerated for the clock skew between the issuer and this service
+	Leeway time.Duration
+	// RequiredClaims must be present and not empty
+	RequiredClaims []string
+}
+
+// Validate returns an error unless the token claims are valid at the given time
+func (v Validator) Validate(claims jwt.MapClaims, now time.Time) error {
+	for _, name := range v.RequiredClaims {
+		if value, found := claims[name]; !found || value == nil || value == "" {
+			return goajwt.ErrJWTError(fmt.Sprintf("missing claim %q", name))
+		}
+	}
+	if exp, found := timeClaim(claims, "exp"); found && now.After(exp.Add(v.Leeway)) {
+		return ErrTokenExpired(fmt.Sprintf("token expired at %v", exp.UTC().Format(time.RFC3339)))
+	}
+	if nbf, found := timeClaim(claims, "nbf"); found && now.Add(v.Leeway).Before(nbf) {
+		return goajwt.ErrJWTError(fmt.Sprintf("token not valid before %v", nbf.UTC().Format(time.RFC3339)))
+	}
+	if iat, found := timeClaim(claims, "iat"); found && now.Add(v.Leeway).Before(iat) {
+		return goajwt.ErrJWTError("token issued in the future")
+	}
+	if v.Issuer != "" {
+		if iss, _ := claims["iss"].(string); iss != v.Issuer {
+			return goajwt.ErrJWTError(fmt.Sprintf("unexpected issuer %q", iss))
+		}
+	}
+	if len(v.Audiences) > 0 && !v.allowedAudience(claims) {
+		return ErrInvalidAudience(fmt.Sprintf("token not issued for %v", v.Audiences))
+	}
+	return nil
+}
+
+func (v Validator) allowedAudience(claims jwt.MapClaims) bool {
+	var audiences []string
+	switch aud := claims["aud"].(type) {
+	case string:
+		audiences = append(audiences, aud)
+	case []interface{}:
+		for _, a := range aud {
+			if s, ok := a.(string); ok {
+				audiences = append(audiences, s)
+			}
+		}
+	}
+	if azp, ok := claims["azp"].(string); ok {
+		audiences = append(audiences, azp)
+	}
+	for _, allowed := range v.Audiences {
+		for _, aud := range audiences {
+			if aud == allowed {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// timeClaim returns the NumericDate claim with the given name
+func timeClaim(claims jwt.MapClaims, name string) (time.Time, bool) {
+	switch value := claims[name].(type) {
+	case float64:
+		return time.Unix(int64(value), 0), true
+	case int64:
+		return time.Unix(value, 0), true
+	case int:
+		return time.Unix(int64(value), 0), true
+	}
+	return time.Time{}, false
+}
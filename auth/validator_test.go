@@ -0,0 +1,95 @@
+package auth_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/almighty/almighty-core/resource"
+	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/fabric8io/fabric8-init-tenant/auth"
+	"github.com/fabric8io/fabric8-init-tenant/jsonapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+
+	now := time.Date(2017, 6, 1, 12, 0, 0, 0, time.UTC)
+	validator := auth.Validator{
+		Issuer:         "https://sso.openshift.io/auth/realms/fabric8",
+		Audiences:      []string{"fabric8-online-platform"},
+		Leeway:         30 * time.Second,
+		RequiredClaims: []string{"sub", "exp"},
+	}
+	valid := func() jwt.MapClaims {
+		return jwt.MapClaims{
+			"sub": "a-user",
+			"iss": "https://sso.openshift.io/auth/realms/fabric8",
+			"aud": "fabric8-online-platform",
+			"exp": float64(now.Add(time.Minute).Unix()),
+			"iat": float64(now.Add(-time.Minute).Unix()),
+		}
+	}
+
+	errorCode := func(t *testing.T, err error) (string, int) {
+		require.Error(t, err)
+		jerr, status := jsonapi.ErrorToJSONAPIError(err)
+		return *jerr.Code, status
+	}
+
+	t.Run("valid", func(t *testing.T) {
+		assert.NoError(t, validator.Validate(valid(), now))
+	})
+
+	t.Run("expired within leeway", func(t *testing.T) {
+		claims := valid()
+		claims["exp"] = float64(now.Add(-10 * time.Second).Unix())
+		assert.NoError(t, validator.Validate(claims, now))
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		claims := valid()
+		claims["exp"] = float64(now.Add(-time.Minute).Unix())
+		code, status := errorCode(t, validator.Validate(claims, now))
+		assert.Equal(t, jsonapi.ErrorCodeTokenExpired, code)
+		assert.Equal(t, http.StatusUnauthorized, status)
+	})
+
+	t.Run("issued in the future", func(t *testing.T) {
+		claims := valid()
+		claims["iat"] = float64(now.Add(time.Minute).Unix())
+		code, _ := errorCode(t, validator.Validate(claims, now))
+		assert.Equal(t, jsonapi.ErrorCodeJWTSecurityError, code)
+	})
+
+	t.Run("wrong issuer", func(t *testing.T) {
+		claims := valid()
+		claims["iss"] = "https://sso.example.com/auth/realms/fabric8"
+		code, _ := errorCode(t, validator.Validate(claims, now))
+		assert.Equal(t, jsonapi.ErrorCodeJWTSecurityError, code)
+	})
+
+	t.Run("wrong audience", func(t *testing.T) {
+		claims := valid()
+		claims["aud"] = []interface{}{"other-client"}
+		code, status := errorCode(t, validator.Validate(claims, now))
+		assert.Equal(t, jsonapi.ErrorCodeInvalidAudience, code)
+		assert.Equal(t, http.StatusUnauthorized, status)
+	})
+
+	t.Run("authorized party", func(t *testing.T) {
+		claims := valid()
+		claims["aud"] = "account"
+		claims["azp"] = "fabric8-online-platform"
+		assert.NoError(t, validator.Validate(claims, now))
+	})
+
+	t.Run("missing claim", func(t *testing.T) {
+		claims := valid()
+		delete(claims, "sub")
+		code, _ := errorCode(t, validator.Validate(claims, now))
+		assert.Equal(t, jsonapi.ErrorCodeJWTSecurityError, code)
+	})
+}
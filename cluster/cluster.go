@@ -0,0 +1,92 @@
+package cluster
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/fabric8io/fabric8-init-tenant/transport"
+	uuid "github.com/satori/go.uuid"
+)
+
+// Labels describe a cluster, e.g. region=us-east-2
+type Labels map[string]string
+
+// Value - Implementation of valuer for database/sql
+func (l Labels) Value() (driver.Value, error) {
+	if l == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(l)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// Scan - Implement the database/sql scanner interface
+func (l *Labels) Scan(value interface{}) error {
+	if value == nil {
+		*l = nil
+		return nil
+	}
+	var b []byte
+	switch v := value.(type) {
+	case []byte:
+		b = v
+	case string:
+		b = []byte(v)
+	default:
+		return errors.New("failed to scan Labels")
+	}
+	return json.Unmarshal(b, l)
+}
+
+// Cluster is an OpenShift cluster tenants can be placed on
+type Cluster struct {
+	ID        uuid.UUID `sql:"type:uuid" gorm:"primary_key"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	Name      string
+	APIURL    string `gorm:"column:api_url"`
+	// Token is the service token used to provision tenants on the cluster. It is stored in
+	// plaintext, access to the clusters table must be restricted accordingly.
+	Token string
+	// CABundle, ClientCert and ClientKey hold PEM encoded certificates and keys. Like the
+	// token, the client key is stored in plaintext.
+	CABundle              string `gorm:"column:ca_bundle"`
+	ClientCert            string
+	ClientKey             string
+	ServerName            string
+	ProxyURL              string `gorm:"column:proxy_url"`
+	InsecureSkipTLSVerify bool   `gorm:"column:insecure_skip_tls_verify"`
+	// Capacity is the maximum number of tenants placed on the cluster, 0 means unlimited
+	Capacity int
+	// Weight is the share of tenants placed on the cluster by the weighted placement policy
+	Weight int
+	Labels Labels `sql:"type:jsonb"`
+}
+
+// TableName overrides the table name settings in Gorm to force a specific table name
+// in the database.
+func (m Cluster) TableName() string {
+	return "clusters"
+}
+
+// TransportConfig returns the TLS and proxy settings used to connect to the cluster
+func (m Cluster) TransportConfig() transport.Config {
+	return transport.Config{
+		CABundle:           m.CABundle,
+		ClientCert:         m.ClientCert,
+		ClientKey:          m.ClientKey,
+		ServerName:         m.ServerName,
+		ProxyURL:           m.ProxyURL,
+		InsecureSkipVerify: m.InsecureSkipTLSVerify,
+	}
+}
+
+// HasCapacity returns if another tenant can be placed on the cluster currently hosting the given number of tenants
+func (m Cluster) HasCapacity(tenants int) bool {
+	return m.Capacity <= 0 || tenants < m.Capacity
+}
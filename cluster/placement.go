@@ -0,0 +1,214 @@
+package cluster
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// Represents the placement policies
+const (
+	PolicyLeastTenants  = "least-tenants"
+	PolicyWeighted      = "weighted"
+	PolicyLabelMatching = "label-matching"
+)
+
+// Request describes the tenant being placed
+type Request struct {
+	TenantID uuid.UUID
+	Username string
+	// Attributes of the user matched against the cluster labels, e.g. region or plan
+	Attributes map[string]string
+}
+
+// Candidate is a cluster with free capacity and the number of tenants it currently hosts
+type Candidate struct {
+	Cluster *Cluster
+	Tenants int
+}
+
+// Decision is the cluster a tenant is placed on and why
+type Decision struct {
+	Cluster *Cluster
+	Reason  string
+}
+
+// Policy decides which of the candidates a tenant is placed on. A Policy returns nil if it
+// does not apply to the request.
+type Policy interface {
+	Place(req Request, candidates []Candidate) *Decision
+}
+
+// Override pins a tenant to a cluster, taking precedence over the placement policy
+type Override struct {
+	TenantID  uuid.UUID `sql:"type:uuid" gorm:"primary_key"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	MasterURL string
+}
+
+// TableName overrides the table name settings in Gorm to force a specific table name
+// in the database.
+func (m Override) TableName() string {
+	return "placement_overrides"
+}
+
+// NewPolicy creates the named policy. Users listed in fixed are placed on the cluster with the
+// mapped API URL, label-matching matches the given label keys before placing on the least used cluster.
+func NewPolicy(name string, labels []string, fixed map[string]string) (Policy, error) {
+	var policy Policy
+	switch name {
+	case "", PolicyLeastTenants:
+		policy = LeastTenants{}
+	case PolicyWeighted:
+		policy = Weighted{}
+	case PolicyLabelMatching:
+		policy = First(LabelMatching{Keys: labels, Policy: LeastTenants{}}, LeastTenants{})
+	default:
+		return nil, fmt.Errorf("unknown placement policy %v", name)
+	}
+	if len(fixed) > 0 {
+		policy = First(Fixed{Users: fixed}, policy)
+	}
+	return policy, nil
+}
+
+// LeastTenants places tenants on the cluster hosting the fewest tenants
+type LeastTenants struct{}
+
+// Place implements Policy
+func (LeastTenants) Place(req Request, candidates []Candidate) *Decision {
+	var selected *Candidate
+	for i, c := range candidates {
+		if selected == nil || c.Tenants < selected.Tenants {
+			selected = &candidates[i]
+		}
+	}
+	if selected == nil {
+		return nil
+	}
+	return &Decision{
+		Cluster: selected.Cluster,
+		Reason:  fmt.Sprintf("%v: %v tenants", PolicyLeastTenants, selected.Tenants),
+	}
+}
+
+// Weighted places tenants on the cluster with the fewest tenants relative to its weight, so clusters
+// fill up in proportion to their weights. A weight below 1 counts as 1.
+type Weighted struct{}
+
+// Place implements Policy
+func (Weighted) Place(req Request, candidates []Candidate) *Decision {
+	var selected *Candidate
+	var selectedLoad float64
+	for i, c := range candidates {
+		load := float64(c.Tenants) / float64(weight(c.Cluster))
+		if selected == nil || load < selectedLoad {
+			selected = &candidates[i]
+			selectedLoad = load
+		}
+	}
+	if selected == nil {
+		return nil
+	}
+	return &Decision{
+		Cluster: selected.Cluster,
+		Reason:  fmt.Sprintf("%v: %v tenants, weight %v", PolicyWeighted, selected.Tenants, weight(selected.Cluster)),
+	}
+}
+
+func weight(c *Cluster) int {
+	if c.Weight < 1 {
+		return 1
+	}
+	return c.Weight
+}
+
+// LabelMatching limits the candidates to the clusters whose labels match the request attributes
+// for all given keys and lets Policy decide among them. Keys missing from the request are ignored.
+type LabelMatching struct {
+	Keys   []string
+	Policy Policy
+}
+
+// Place implements Policy
+func (p LabelMatching) Place(req Request, candidates []Candidate) *Decision {
+	var matched []string
+	for _, key := range p.Keys {
+		if value, found := req.Attributes[key]; found && value != "" {
+			matched = append(matched, key+"="+value)
+		}
+	}
+	if len(matched) == 0 {
+		return nil
+	}
+	sort.Strings(matched)
+
+	var matching []Candidate
+	for _, c := range candidates {
+		if matches(c.Cluster, req.Attributes, p.Keys) {
+			matching = append(matching, c)
+		}
+	}
+	decision := p.Policy.Place(req, matching)
+	if decision == nil {
+		return nil
+	}
+	decision.Reason = fmt.Sprintf("%v [%v], %v", PolicyLabelMatching, strings.Join(matched, ","), decision.Reason)
+	return decision
+}
+
+func matches(c *Cluster, attributes map[string]string, keys []string) bool {
+	for _, key := range keys {
+		value, found := attributes[key]
+		if !found || value == "" {
+			continue
+		}
+		if c.Labels[key] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// Fixed places the listed users, by username or tenant ID, on the cluster with the mapped API URL
+type Fixed struct {
+	Users map[string]string
+}
+
+// Place implements Policy
+func (p Fixed) Place(req Request, candidates []Candidate) *Decision {
+	apiURL, found := p.Users[req.Username]
+	if !found {
+		apiURL, found = p.Users[req.TenantID.String()]
+	}
+	if !found {
+		return nil
+	}
+	for _, c := range candidates {
+		if c.Cluster.APIURL == apiURL {
+			return &Decision{Cluster: c.Cluster, Reason: "fixed cluster for allow-listed user"}
+		}
+	}
+	return nil
+}
+
+// First returns the decision of the first policy that applies to the request
+func First(policies ...Policy) Policy {
+	return first(policies)
+}
+
+type first []Policy
+
+// Place implements Policy
+func (p first) Place(req Request, candidates []Candidate) *Decision {
+	for _, policy := range p {
+		if decision := policy.Place(req, candidates); decision != nil {
+			return decision
+		}
+	}
+	return nil
+}
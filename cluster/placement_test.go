@@ -0,0 +1,99 @@
+package cluster_test
+
+import (
+	"testing"
+
+	"github.com/almighty/almighty-core/resource"
+	"github.com/fabric8io/fabric8-init-tenant/cluster"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	east = &cluster.Cluster{APIURL: "https://east", Weight: 3, Labels: cluster.Labels{"region": "us-east"}}
+	west = &cluster.Cluster{APIURL: "https://west", Labels: cluster.Labels{"region": "us-west"}}
+)
+
+func candidates(eastTenants, westTenants int) []cluster.Candidate {
+	return []cluster.Candidate{
+		{Cluster: east, Tenants: eastTenants},
+		{Cluster: west, Tenants: westTenants},
+	}
+}
+
+func TestLeastTenants(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+
+	d := cluster.LeastTenants{}.Place(cluster.Request{}, candidates(5, 4))
+	require.NotNil(t, d)
+	assert.Equal(t, west, d.Cluster)
+	assert.Equal(t, "least-tenants: 4 tenants", d.Reason)
+
+	assert.Nil(t, cluster.LeastTenants{}.Place(cluster.Request{}, nil))
+}
+
+func TestWeighted(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+
+	d := cluster.Weighted{}.Place(cluster.Request{}, candidates(5, 2))
+	require.NotNil(t, d)
+	assert.Equal(t, east, d.Cluster)
+	assert.Equal(t, "weighted: 5 tenants, weight 3", d.Reason)
+
+	d = cluster.Weighted{}.Place(cluster.Request{}, candidates(7, 2))
+	require.NotNil(t, d)
+	assert.Equal(t, west, d.Cluster)
+}
+
+func TestLabelMatching(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+
+	policy, err := cluster.NewPolicy(cluster.PolicyLabelMatching, []string{"region", "plan"}, nil)
+	require.NoError(t, err)
+
+	t.Run("matching cluster", func(t *testing.T) {
+		d := policy.Place(cluster.Request{Attributes: map[string]string{"region": "us-east", "plan": "free"}}, candidates(5, 1))
+		require.NotNil(t, d)
+		assert.Equal(t, east, d.Cluster)
+		assert.Equal(t, "label-matching [plan=free,region=us-east], least-tenants: 5 tenants", d.Reason)
+	})
+
+	t.Run("no matching cluster", func(t *testing.T) {
+		d := policy.Place(cluster.Request{Attributes: map[string]string{"region": "eu"}}, candidates(5, 1))
+		require.NotNil(t, d)
+		assert.Equal(t, west, d.Cluster)
+		assert.Equal(t, "least-tenants: 1 tenants", d.Reason)
+	})
+}
+
+func TestFixed(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+
+	tenantID := uuid.NewV4()
+	policy, err := cluster.NewPolicy(cluster.PolicyLeastTenants, nil, map[string]string{
+		"john@example.com": "https://east",
+		tenantID.String():  "https://east",
+	})
+	require.NoError(t, err)
+
+	d := policy.Place(cluster.Request{Username: "john@example.com"}, candidates(5, 1))
+	require.NotNil(t, d)
+	assert.Equal(t, east, d.Cluster)
+	assert.Equal(t, "fixed cluster for allow-listed user", d.Reason)
+
+	d = policy.Place(cluster.Request{TenantID: tenantID}, candidates(5, 1))
+	require.NotNil(t, d)
+	assert.Equal(t, east, d.Cluster)
+
+	d = policy.Place(cluster.Request{Username: "jane@example.com"}, candidates(5, 1))
+	require.NotNil(t, d)
+	assert.Equal(t, west, d.Cluster)
+}
+
+func TestUnknownPolicy(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+
+	_, err := cluster.NewPolicy("random", nil, nil)
+	assert.Error(t, err)
+}
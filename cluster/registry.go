@@ -0,0 +1,171 @@
+package cluster
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/almighty/almighty-core/errors"
+	"github.com/fabric8io/fabric8-init-tenant/openshift"
+	"github.com/fabric8io/fabric8-init-tenant/transport"
+	uuid "github.com/satori/go.uuid"
+)
+
+// ErrNoCapacity is returned when no registered cluster can host another tenant
+var ErrNoCapacity = fmt.Errorf("no cluster with free capacity")
+
+// Registry resolves the OpenShift configuration of the registered clusters and selects
+// the cluster new tenants are placed on
+type Registry struct {
+	service Service
+	base    openshift.Config
+	policy  Policy
+
+	mu      sync.Mutex
+	configs map[string]resolvedConfig
+}
+
+type resolvedConfig struct {
+	updatedAt time.Time
+	config    openshift.Config
+}
+
+// NewRegistry creates a Registry for the clusters known by the service. The base configuration
+// provides the settings shared by all clusters and is used as is for tenants without a recorded cluster.
+// New tenants are placed using the policy, or on the cluster hosting the fewest tenants if nil.
+func NewRegistry(service Service, base openshift.Config, policy Policy) *Registry {
+	if policy == nil {
+		policy = LeastTenants{}
+	}
+	return &Registry{
+		service: service,
+		base:    base,
+		policy:  policy,
+		configs: map[string]resolvedConfig{},
+	}
+}
+
+// Clusters returns all registered clusters
+func (r *Registry) Clusters() ([]*Cluster, error) {
+	return r.service.GetClusters()
+}
+
+// Seed registers the given clusters, updating the ones already registered with the same API URL
+func (r *Registry) Seed(clusters []*Cluster) error {
+	for _, c := range clusters {
+		existing, err := r.service.GetCluster(c.APIURL)
+		if err != nil {
+			return err
+		}
+		if existing != nil {
+			c.ID = existing.ID
+			c.CreatedAt = existing.CreatedAt
+		}
+		if err := r.service.UpdateCluster(c); err != nil {
+			return fmt.Errorf("unable to register cluster %v: %v", c.APIURL, err)
+		}
+	}
+	return nil
+}
+
+// Place decides which cluster a new tenant is placed on. An admin override takes precedence over
+// the placement policy, which only considers clusters with free capacity.
+func (r *Registry) Place(req Request) (*Decision, error) {
+	override, err := r.service.GetOverride(req.TenantID)
+	if err != nil {
+		return nil, err
+	}
+	if override != nil {
+		c, err := r.service.GetCluster(override.MasterURL)
+		if err != nil {
+			return nil, err
+		}
+		if c == nil {
+			return nil, fmt.Errorf("unknown cluster %v", override.MasterURL)
+		}
+		return &Decision{Cluster: c, Reason: "admin override"}, nil
+	}
+
+	clusters, err := r.service.GetClusters()
+	if err != nil {
+		return nil, err
+	}
+	counts, err := r.service.CountTenants()
+	if err != nil {
+		return nil, err
+	}
+	var candidates []Candidate
+	for _, c := range clusters {
+		if c.HasCapacity(counts[c.APIURL]) {
+			candidates = append(candidates, Candidate{Cluster: c, Tenants: counts[c.APIURL]})
+		}
+	}
+	decision := r.policy.Place(req, candidates)
+	if decision == nil {
+		return nil, ErrNoCapacity
+	}
+	return decision, nil
+}
+
+// Override pins the tenant to the registered cluster with the given API URL
+func (r *Registry) Override(tenantID uuid.UUID, apiURL string) error {
+	c, err := r.service.GetCluster(apiURL)
+	if err != nil {
+		return err
+	}
+	if c == nil {
+		return errors.NewNotFoundError("clusters", apiURL)
+	}
+	override, err := r.service.GetOverride(tenantID)
+	if err != nil {
+		return err
+	}
+	if override == nil {
+		override = &Override{TenantID: tenantID}
+	}
+	override.MasterURL = apiURL
+	return r.service.UpdateOverride(override)
+}
+
+// Config returns the OpenShift configuration for the cluster with the given API URL. An empty
+// API URL refers to the cluster of the base configuration.
+func (r *Registry) Config(apiURL string) (openshift.Config, error) {
+	if apiURL == "" {
+		apiURL = r.base.MasterURL
+	}
+	c, err := r.service.GetCluster(apiURL)
+	if err != nil {
+		return openshift.Config{}, err
+	}
+	if c == nil {
+		if apiURL == r.base.MasterURL {
+			return r.base, nil
+		}
+		return openshift.Config{}, fmt.Errorf("unknown cluster %v", apiURL)
+	}
+
+	r.mu.Lock()
+	resolved, found := r.configs[apiURL]
+	r.mu.Unlock()
+	if found && resolved.updatedAt.Equal(c.UpdatedAt) {
+		return resolved.config, nil
+	}
+
+	// resolve the master user without holding the lock so a slow or unreachable cluster
+	// does not block the lookups for all other clusters
+	config := r.base
+	config.MasterURL = c.APIURL
+	config.Token = c.Token
+	config.HttpTransport, err = transport.New(c.TransportConfig())
+	if err != nil {
+		return openshift.Config{}, fmt.Errorf("invalid TLS configuration for cluster %v: %v", apiURL, err)
+	}
+	config.MasterUser, err = openshift.WhoAmI(config)
+	if err != nil {
+		return openshift.Config{}, fmt.Errorf("unknown master user on cluster %v: %v", apiURL, err)
+	}
+	r.mu.Lock()
+	r.configs[apiURL] = resolvedConfig{updatedAt: c.UpdatedAt, config: config}
+	r.mu.Unlock()
+	return config, nil
+}
@@ -0,0 +1,183 @@
+package cluster_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/almighty/almighty-core/resource"
+	"github.com/fabric8io/fabric8-init-tenant/cluster"
+	"github.com/fabric8io/fabric8-init-tenant/openshift"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type clusters struct {
+	cluster.NilService
+	clusters  []*cluster.Cluster
+	counts    map[string]int
+	overrides map[uuid.UUID]*cluster.Override
+}
+
+func (s *clusters) GetClusters() ([]*cluster.Cluster, error) {
+	return s.clusters, nil
+}
+
+func (s *clusters) GetCluster(apiURL string) (*cluster.Cluster, error) {
+	for _, c := range s.clusters {
+		if c.APIURL == apiURL {
+			return c, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *clusters) UpdateCluster(c *cluster.Cluster) error {
+	c.UpdatedAt = time.Now()
+	for i, existing := range s.clusters {
+		if existing.APIURL == c.APIURL {
+			s.clusters[i] = c
+			return nil
+		}
+	}
+	s.clusters = append(s.clusters, c)
+	return nil
+}
+
+func (s *clusters) CountTenants() (map[string]int, error) {
+	return s.counts, nil
+}
+
+func (s *clusters) GetOverride(tenantID uuid.UUID) (*cluster.Override, error) {
+	return s.overrides[tenantID], nil
+}
+
+func (s *clusters) UpdateOverride(o *cluster.Override) error {
+	if s.overrides == nil {
+		s.overrides = map[uuid.UUID]*cluster.Override{}
+	}
+	s.overrides[o.TenantID] = o
+	return nil
+}
+
+func TestPlace(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+
+	service := &clusters{
+		clusters: []*cluster.Cluster{
+			{APIURL: "https://a", Capacity: 10},
+			{APIURL: "https://b", Capacity: 5},
+			{APIURL: "https://c"},
+		},
+		counts: map[string]int{"https://a": 3, "https://b": 5, "https://c": 4},
+	}
+	r := cluster.NewRegistry(service, openshift.Config{}, nil)
+	req := cluster.Request{TenantID: uuid.NewV4()}
+
+	d, err := r.Place(req)
+	require.NoError(t, err)
+	assert.Equal(t, "https://a", d.Cluster.APIURL)
+
+	service.counts["https://a"] = 10
+	d, err = r.Place(req)
+	require.NoError(t, err)
+	assert.Equal(t, "https://c", d.Cluster.APIURL, "full clusters are skipped")
+
+	require.NoError(t, r.Override(req.TenantID, "https://b"))
+	d, err = r.Place(req)
+	require.NoError(t, err)
+	assert.Equal(t, "https://b", d.Cluster.APIURL)
+	assert.Equal(t, "admin override", d.Reason)
+	assert.Error(t, r.Override(req.TenantID, "https://unknown"))
+
+	service.clusters = service.clusters[:2]
+	_, err = r.Place(cluster.Request{TenantID: uuid.NewV4()})
+	assert.Equal(t, cluster.ErrNoCapacity, err)
+}
+
+func TestSeed(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+
+	service := &clusters{}
+	r := cluster.NewRegistry(service, openshift.Config{}, nil)
+	require.NoError(t, r.Seed([]*cluster.Cluster{{APIURL: "https://a", Token: "1"}}))
+	id := service.clusters[0].ID
+
+	require.NoError(t, r.Seed([]*cluster.Cluster{{APIURL: "https://a", Token: "2"}}))
+	require.Len(t, service.clusters, 1)
+	assert.Equal(t, id, service.clusters[0].ID)
+	assert.Equal(t, "2", service.clusters[0].Token)
+}
+
+func TestConfig(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "metadata:\n  name: %v\n", r.Header.Get("Authorization")[len("Bearer "):])
+	}))
+	defer server.Close()
+
+	service := &clusters{clusters: []*cluster.Cluster{{APIURL: server.URL, Token: "master-b", UpdatedAt: time.Now()}}}
+	base := openshift.Config{MasterURL: "https://default", Token: "master-a", TemplateDir: "templates"}
+	r := cluster.NewRegistry(service, base, nil)
+
+	t.Run("registered cluster", func(t *testing.T) {
+		config, err := r.Config(server.URL)
+		require.NoError(t, err)
+		assert.Equal(t, server.URL, config.MasterURL)
+		assert.Equal(t, "master-b", config.Token)
+		assert.Equal(t, "master-b", config.MasterUser)
+		assert.Equal(t, "templates", config.TemplateDir)
+	})
+
+	t.Run("default cluster", func(t *testing.T) {
+		config, err := r.Config("")
+		require.NoError(t, err)
+		assert.Equal(t, base, config)
+	})
+
+	t.Run("unknown cluster", func(t *testing.T) {
+		_, err := r.Config("https://unknown")
+		assert.Error(t, err)
+	})
+}
+
+func TestConfigDoesNotBlockOnSlowCluster(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+
+	release := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		fmt.Fprintf(w, "metadata:\n  name: slow\n")
+	}))
+	defer slow.Close()
+	defer close(release)
+	fast := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "metadata:\n  name: fast\n")
+	}))
+	defer fast.Close()
+
+	service := &clusters{clusters: []*cluster.Cluster{
+		{APIURL: slow.URL, Token: "slow", UpdatedAt: time.Now()},
+		{APIURL: fast.URL, Token: "fast", UpdatedAt: time.Now()},
+	}}
+	r := cluster.NewRegistry(service, openshift.Config{MasterURL: "https://default"}, nil)
+
+	go r.Config(slow.URL)
+	time.Sleep(50 * time.Millisecond)
+
+	done := make(chan error)
+	go func() {
+		_, err := r.Config(fast.URL)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("resolving the configuration of a cluster waits for another cluster")
+	}
+}
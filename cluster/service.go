@@ -0,0 +1,119 @@
+package cluster
+
+import (
+	"github.com/jinzhu/gorm"
+	uuid "github.com/satori/go.uuid"
+)
+
+type Service interface {
+	GetClusters() ([]*Cluster, error)
+	GetCluster(apiURL string) (*Cluster, error)
+	UpdateCluster(cluster *Cluster) error
+	CountTenants() (map[string]int, error)
+	GetOverride(tenantID uuid.UUID) (*Override, error)
+	UpdateOverride(override *Override) error
+}
+
+func NewDBService(db *gorm.DB) Service {
+	return &DBService{db: db}
+}
+
+type DBService struct {
+	db *gorm.DB
+}
+
+func (s DBService) GetClusters() ([]*Cluster, error) {
+	var c []*Cluster
+	err := s.db.Table(Cluster{}.TableName()).Order("created_at").Find(&c).Error
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// GetCluster returns the cluster with the given API URL or nil if no such cluster is registered
+func (s DBService) GetCluster(apiURL string) (*Cluster, error) {
+	var c Cluster
+	err := s.db.Table(c.TableName()).Where("api_url = ?", apiURL).Find(&c).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (s DBService) UpdateCluster(cluster *Cluster) error {
+	if cluster.ID == uuid.Nil {
+		cluster.ID = uuid.NewV4()
+	}
+	return s.db.Save(cluster).Error
+}
+
+// CountTenants returns the number of tenants placed on each cluster by API URL
+func (s DBService) CountTenants() (map[string]int, error) {
+	rows, err := s.db.Table("tenants").
+		Select("COALESCE(master_url, ''), count(*)").
+		Where("deleted_at IS NULL").
+		Group("master_url").
+		Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	counts := map[string]int{}
+	for rows.Next() {
+		var apiURL string
+		var count int
+		if err := rows.Scan(&apiURL, &count); err != nil {
+			return nil, err
+		}
+		counts[apiURL] += count
+	}
+	return counts, rows.Err()
+}
+
+// GetOverride returns the placement override of the tenant or nil if the tenant has none
+func (s DBService) GetOverride(tenantID uuid.UUID) (*Override, error) {
+	var o Override
+	err := s.db.Table(o.TableName()).Where("tenant_id = ?", tenantID).Find(&o).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &o, nil
+}
+
+func (s DBService) UpdateOverride(override *Override) error {
+	return s.db.Save(override).Error
+}
+
+type NilService struct {
+}
+
+func (s NilService) GetClusters() ([]*Cluster, error) {
+	return nil, nil
+}
+
+func (s NilService) GetCluster(apiURL string) (*Cluster, error) {
+	return nil, nil
+}
+
+func (s NilService) UpdateCluster(cluster *Cluster) error {
+	return nil
+}
+
+func (s NilService) CountTenants() (map[string]int, error) {
+	return nil, nil
+}
+
+func (s NilService) GetOverride(tenantID uuid.UUID) (*Override, error) {
+	return nil, nil
+}
+
+func (s NilService) UpdateOverride(override *Override) error {
+	return nil
+}
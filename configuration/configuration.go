@@ -10,6 +10,8 @@ import (
 
 	"encoding/base64"
 
+	"github.com/fabric8io/fabric8-init-tenant/auth"
+	"github.com/fabric8io/fabric8-init-tenant/transport"
 	"github.com/spf13/viper"
 )
 
@@ -29,16 +31,62 @@ const (
 	varPostgresConnectionMaxOpen       = "postgres.connection.maxopen"
 	varHTTPAddress                     = "http.address"
 	varDeveloperModeEnabled            = "developer.mode.enabled"
+	varIdentityProvider                = "identity.provider"
+	varOIDCIssuerURL                   = "oidc.issuer.url"
+	varOIDCClientID                    = "oidc.client.id"
+	varOIDCClientSecret                = "oidc.client.secret"
+	varOIDCBrokerTokenURL              = "oidc.broker.token.url"
+	varOIDCAudience                    = "oidc.audience"
 	varKeycloakRealm                   = "keycloak.realm"
 	varKeycloakOpenshiftBroker         = "keycloak.openshift.broker"
+	varKeycloakOpenshiftRefreshURL     = "keycloak.openshift.refresh.url"
+	varKeycloakOpenshiftClientID       = "keycloak.openshift.client.id"
+	varKeycloakOpenshiftClientSecret   = "keycloak.openshift.client.secret"
+	varKeycloakOpenshiftTokenMargin    = "keycloak.openshift.token.margin"
 	varKeycloakURL                     = "keycloak.url"
+	varKeycloakTLSCAFile               = "keycloak.tls.ca.file"
+	varKeycloakTLSCertFile             = "keycloak.tls.cert.file"
+	varKeycloakTLSKeyFile              = "keycloak.tls.key.file"
+	varKeycloakTLSServerName           = "keycloak.tls.server.name"
+	varKeycloakTLSInsecureSkipVerify   = "keycloak.tls.insecure.skip.verify"
+	varKeycloakProxyURL                = "keycloak.proxy.url"
+	varKeycloakKeysRefreshInterval     = "keycloak.keys.refresh.interval"
+	varKeycloakKeysRefreshDelay        = "keycloak.keys.refresh.delay"
+	varKeycloakTokenIssuer             = "keycloak.token.issuer"
+	varKeycloakTokenAudiences          = "keycloak.token.audiences"
+	varKeycloakTokenLeeway             = "keycloak.token.leeway"
+	varKeycloakTokenRequiredClaims     = "keycloak.token.required.claims"
+	varKeycloakTokenClaimSubject       = "keycloak.token.claim.subject"
+	varKeycloakTokenClaimEmail         = "keycloak.token.claim.email"
+	varKeycloakTokenClaimUsername      = "keycloak.token.claim.username"
+	varKeycloakTokenClaimName          = "keycloak.token.claim.name"
 	varOpenshiftTenantMasterURL        = "openshift.tenant.masterurl"
 	varOpenshiftServiceToken           = "openshift.service.token"
 	varOpenshiftUseCurrentCluster      = "openshift.use.current.cluster"
+	varOpenshiftImpersonate            = "openshift.impersonate"
+	varOpenshiftClusters               = "openshift.clusters"
+	varOpenshiftTLSCAFile              = "openshift.tls.ca.file"
+	varOpenshiftTLSCertFile            = "openshift.tls.cert.file"
+	varOpenshiftTLSKeyFile             = "openshift.tls.key.file"
+	varOpenshiftTLSServerName          = "openshift.tls.server.name"
+	varOpenshiftProxyURL               = "openshift.proxy.url"
+	varPlacementPolicy                 = "placement.policy"
+	varPlacementLabels                 = "placement.labels"
+	varPlacementFixed                  = "placement.fixed"
 	varTemplateRecommenderExternalName = "template.recommender.external.name"
 	varTemplateRecommenderAPIToken     = "template.recommender.api.token"
 	varTemplateDomain                  = "template.domain"
 	varAPIServerInsecureSkipTLSVerify  = "api.server.insecure.skip.tls.verify"
+	varAdminSubjects                   = "admin.subjects"
+	varAdminRoles                      = "admin.roles"
+	varAdminRolesClient                = "admin.roles.client"
+	varIdlerEnabled                    = "idler.enabled"
+	varIdlerTimeout                    = "idler.timeout"
+	varIdlerInterval                   = "idler.interval"
+	varHealthCheckTimeout              = "health.check.timeout"
+	varHealthCheckInterval             = "health.check.interval"
+	varWebhookDispatchInterval         = "webhook.dispatch.interval"
+	varWebhookMaxAttempts              = "webhook.max.attempts"
 )
 
 // Data encapsulates the Viper configuration object which stores the configuration data in-memory.
@@ -106,9 +154,44 @@ func (c *Data) setConfigDefaults() {
 	//-----
 	// Misc
 	//-----
+	c.v.SetDefault(varIdentityProvider, IdentityProviderKeycloak)
 	c.v.SetDefault(varKeycloakOpenshiftBroker, defaultKeycloakOpenshiftBroker)
+	c.v.SetDefault(varKeycloakOpenshiftTokenMargin, time.Duration(time.Minute))
 	c.v.SetDefault(varOpenshiftUseCurrentCluster, false)
+	c.v.SetDefault(varOpenshiftImpersonate, false)
 	c.v.SetDefault(varAPIServerInsecureSkipTLSVerify, false)
+	c.v.SetDefault(varKeycloakTLSInsecureSkipVerify, false)
+	c.v.SetDefault(varAdminRoles, "tenant-admin")
+	c.v.SetDefault(varKeycloakKeysRefreshInterval, time.Duration(time.Hour))
+	c.v.SetDefault(varKeycloakKeysRefreshDelay, time.Duration(time.Minute))
+	c.v.SetDefault(varKeycloakTokenLeeway, time.Duration(30*time.Second))
+	c.v.SetDefault(varKeycloakTokenRequiredClaims, "sub,exp")
+	c.v.SetDefault(varKeycloakTokenClaimSubject, auth.DefaultClaimNames.Subject)
+	c.v.SetDefault(varKeycloakTokenClaimEmail, auth.DefaultClaimNames.Email)
+	c.v.SetDefault(varKeycloakTokenClaimUsername, auth.DefaultClaimNames.Username)
+	c.v.SetDefault(varKeycloakTokenClaimName, auth.DefaultClaimNames.Name)
+
+	//----------
+	// Placement
+	//----------
+	c.v.SetDefault(varPlacementPolicy, "least-tenants")
+	c.v.SetDefault(varPlacementLabels, "region,plan")
+
+	//------
+	// Idler
+	//------
+	c.v.SetDefault(varIdlerEnabled, false)
+	c.v.SetDefault(varIdlerTimeout, time.Duration(8*time.Hour))
+	c.v.SetDefault(varIdlerInterval, time.Duration(5*time.Minute))
+
+	c.v.SetDefault(varHealthCheckTimeout, time.Duration(5*time.Second))
+	c.v.SetDefault(varHealthCheckInterval, time.Duration(10*time.Second))
+
+	//---------
+	// Webhooks
+	//---------
+	c.v.SetDefault(varWebhookDispatchInterval, time.Duration(5*time.Second))
+	c.v.SetDefault(varWebhookMaxAttempts, 10)
 
 	// Enable development related features, e.g. token generation endpoint
 	c.v.SetDefault(varDeveloperModeEnabled, false)
@@ -196,6 +279,37 @@ func (c *Data) IsDeveloperModeEnabled() bool {
 	return c.v.GetBool(varDeveloperModeEnabled)
 }
 
+// GetIdentityProvider returns the kind of identity provider issuing the incoming tokens, keycloak or oidc
+func (c *Data) GetIdentityProvider() string {
+	return c.v.GetString(varIdentityProvider)
+}
+
+// GetOIDCIssuerURL returns the issuer URL of the OIDC provider, its configuration is discovered from there
+func (c *Data) GetOIDCIssuerURL() string {
+	return c.v.GetString(varOIDCIssuerURL)
+}
+
+// GetOIDCClientID returns the client the linked account tokens are requested as from the OIDC provider
+func (c *Data) GetOIDCClientID() string {
+	return c.v.GetString(varOIDCClientID)
+}
+
+// GetOIDCClientSecret returns the secret of the client the linked account tokens are requested as
+func (c *Data) GetOIDCClientSecret() string {
+	return c.v.GetString(varOIDCClientSecret)
+}
+
+// GetOIDCBrokerTokenURL returns the endpoint of the OIDC provider returning the linked account token of the
+// caller. The token of the caller is exchanged at the token endpoint if not set.
+func (c *Data) GetOIDCBrokerTokenURL() string {
+	return c.v.GetString(varOIDCBrokerTokenURL)
+}
+
+// GetOIDCAudience returns the audience the token of the caller is exchanged for
+func (c *Data) GetOIDCAudience() string {
+	return c.v.GetString(varOIDCAudience)
+}
+
 // GetKeycloakRealm returns the keyclaok realm name
 func (c *Data) GetKeycloakRealm() string {
 	if c.v.IsSet(varKeycloakRealm) {
@@ -212,6 +326,27 @@ func (c *Data) GetKeycloakOpenshiftBroker() string {
 	return c.v.GetString(varKeycloakOpenshiftBroker)
 }
 
+// GetKeycloakOpenshiftRefreshURL returns the OpenShift OAuth token endpoint the broker tokens are refreshed with,
+// expired broker tokens are fetched from Keycloak again if not set
+func (c *Data) GetKeycloakOpenshiftRefreshURL() string {
+	return c.v.GetString(varKeycloakOpenshiftRefreshURL)
+}
+
+// GetKeycloakOpenshiftClientID returns the OpenShift OAuth client the broker tokens were issued to
+func (c *Data) GetKeycloakOpenshiftClientID() string {
+	return c.v.GetString(varKeycloakOpenshiftClientID)
+}
+
+// GetKeycloakOpenshiftClientSecret returns the secret of the OpenShift OAuth client the broker tokens were issued to
+func (c *Data) GetKeycloakOpenshiftClientSecret() string {
+	return c.v.GetString(varKeycloakOpenshiftClientSecret)
+}
+
+// GetKeycloakOpenshiftTokenMargin returns how long before their expiry the cached broker tokens are refreshed
+func (c *Data) GetKeycloakOpenshiftTokenMargin() time.Duration {
+	return c.v.GetDuration(varKeycloakOpenshiftTokenMargin)
+}
+
 // GetKeycloakURL returns Keycloak URL used by default in Dev mode
 func (c *Data) GetKeycloakURL() string {
 	if c.v.IsSet(varKeycloakURL) {
@@ -223,6 +358,55 @@ func (c *Data) GetKeycloakURL() string {
 	return defaultKeycloakURL
 }
 
+// GetKeycloakKeysRefreshInterval returns how often the Keycloak signing keys are fetched
+func (c *Data) GetKeycloakKeysRefreshInterval() time.Duration {
+	return c.v.GetDuration(varKeycloakKeysRefreshInterval)
+}
+
+// GetKeycloakKeysRefreshDelay returns the minimum time between two fetches of the Keycloak signing keys
+// triggered by tokens signed with an unknown key
+func (c *Data) GetKeycloakKeysRefreshDelay() time.Duration {
+	return c.v.GetDuration(varKeycloakKeysRefreshDelay)
+}
+
+// GetKeycloakTokenIssuer returns the iss claim expected in the incoming tokens. Defaults to the issuer URL
+// of the OIDC provider, or the Keycloak realm URL.
+func (c *Data) GetKeycloakTokenIssuer() string {
+	if c.v.IsSet(varKeycloakTokenIssuer) {
+		return c.v.GetString(varKeycloakTokenIssuer)
+	}
+	if c.GetIdentityProvider() == IdentityProviderOIDC {
+		return strings.TrimSuffix(c.GetOIDCIssuerURL(), "/")
+	}
+	return fmt.Sprintf("%v/auth/realms/%v", c.GetKeycloakURL(), c.GetKeycloakRealm())
+}
+
+// GetKeycloakTokenAudiences returns the clients the incoming tokens may be issued for, matched against
+// the aud and azp claims. Configured as a comma separated list, any client is allowed if empty.
+func (c *Data) GetKeycloakTokenAudiences() []string {
+	return splitList(c.v.GetString(varKeycloakTokenAudiences))
+}
+
+// GetKeycloakTokenLeeway returns the clock skew tolerated when checking the time claims of the incoming tokens
+func (c *Data) GetKeycloakTokenLeeway() time.Duration {
+	return c.v.GetDuration(varKeycloakTokenLeeway)
+}
+
+// GetKeycloakTokenRequiredClaims returns the claims the incoming tokens must contain
+func (c *Data) GetKeycloakTokenRequiredClaims() []string {
+	return splitList(c.v.GetString(varKeycloakTokenRequiredClaims))
+}
+
+// GetKeycloakTokenClaimNames returns the claims of the incoming tokens the identity of the caller is read from
+func (c *Data) GetKeycloakTokenClaimNames() auth.ClaimNames {
+	return auth.ClaimNames{
+		Subject:  c.v.GetString(varKeycloakTokenClaimSubject),
+		Email:    c.v.GetString(varKeycloakTokenClaimEmail),
+		Username: c.v.GetString(varKeycloakTokenClaimUsername),
+		Name:     c.v.GetString(varKeycloakTokenClaimName),
+	}
+}
+
 // GetOpenshiftTenantMasterURL returns the URL for the openshift cluster where the tenant services are running
 func (c *Data) GetOpenshiftTenantMasterURL() string {
 	return c.v.GetString(varOpenshiftTenantMasterURL)
@@ -238,11 +422,192 @@ func (c *Data) UseOpenshiftCurrentCluster() bool {
 	return c.v.GetBool(varOpenshiftUseCurrentCluster)
 }
 
+// IsOpenshiftImpersonate returns if the user scoped objects are created with the service token
+// impersonating the tenant user instead of the linked OpenShift token of the user
+func (c *Data) IsOpenshiftImpersonate() bool {
+	return c.v.GetBool(varOpenshiftImpersonate)
+}
+
+// Cluster describes an OpenShift cluster tenants can be placed on
+type Cluster struct {
+	Name                  string            `yaml:"name"`
+	APIURL                string            `yaml:"api-url"`
+	Token                 string            `yaml:"token"`
+	CABundle              string            `yaml:"ca-bundle"`
+	CAFile                string            `yaml:"ca-file"`
+	CertFile              string            `yaml:"cert-file"`
+	KeyFile               string            `yaml:"key-file"`
+	ServerName            string            `yaml:"server-name"`
+	ProxyURL              string            `yaml:"proxy-url"`
+	InsecureSkipTLSVerify bool              `yaml:"insecure-skip-tls-verify"`
+	Capacity              int               `yaml:"capacity"`
+	Weight                int               `yaml:"weight"`
+	Labels                map[string]string `yaml:"labels"`
+}
+
+// TransportConfig returns the TLS and proxy settings of the cluster, reading the configured files.
+// An inline ca-bundle takes precedence over the ca-file.
+func (c Cluster) TransportConfig() (transport.Config, error) {
+	return transportConfig(c.CABundle, c.CAFile, c.CertFile, c.KeyFile, c.ServerName, c.ProxyURL, c.InsecureSkipTLSVerify)
+}
+
+// GetOpenshiftClusters returns the clusters tenants can be placed on, configured as a YAML (or JSON) list.
+// When no clusters are configured tenants are placed on the openshift.tenant.masterurl cluster.
+func (c *Data) GetOpenshiftClusters() ([]Cluster, error) {
+	var clusters []Cluster
+	if !c.v.IsSet(varOpenshiftClusters) {
+		return clusters, nil
+	}
+	err := yaml.Unmarshal([]byte(c.v.GetString(varOpenshiftClusters)), &clusters)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid configuration %v: %v", varOpenshiftClusters, err)
+	}
+	for _, cluster := range clusters {
+		if cluster.APIURL == "" || cluster.Token == "" {
+			return nil, fmt.Errorf("Invalid configuration %v: api-url and token are required", varOpenshiftClusters)
+		}
+	}
+	return clusters, nil
+}
+
+// GetOpenshiftTransportConfig returns the TLS and proxy settings for the openshift.tenant.masterurl cluster
+func (c *Data) GetOpenshiftTransportConfig() (transport.Config, error) {
+	return transportConfig(
+		"",
+		c.v.GetString(varOpenshiftTLSCAFile),
+		c.v.GetString(varOpenshiftTLSCertFile),
+		c.v.GetString(varOpenshiftTLSKeyFile),
+		c.v.GetString(varOpenshiftTLSServerName),
+		c.v.GetString(varOpenshiftProxyURL),
+		c.APIServerInsecureSkipTLSVerify())
+}
+
+// GetKeycloakTransportConfig returns the TLS and proxy settings for Keycloak
+func (c *Data) GetKeycloakTransportConfig() (transport.Config, error) {
+	return transportConfig(
+		"",
+		c.v.GetString(varKeycloakTLSCAFile),
+		c.v.GetString(varKeycloakTLSCertFile),
+		c.v.GetString(varKeycloakTLSKeyFile),
+		c.v.GetString(varKeycloakTLSServerName),
+		c.v.GetString(varKeycloakProxyURL),
+		c.v.GetBool(varKeycloakTLSInsecureSkipVerify))
+}
+
+func transportConfig(caBundle, caFile, certFile, keyFile, serverName, proxyURL string, insecureSkipVerify bool) (transport.Config, error) {
+	config := transport.Config{
+		CABundle:           caBundle,
+		ServerName:         serverName,
+		ProxyURL:           proxyURL,
+		InsecureSkipVerify: insecureSkipVerify,
+	}
+	var err error
+	if config.CABundle == "" {
+		if config.CABundle, err = transport.ReadFile(caFile); err != nil {
+			return config, fmt.Errorf("Unable to read CA file: %v", err)
+		}
+	}
+	if config.ClientCert, err = transport.ReadFile(certFile); err != nil {
+		return config, fmt.Errorf("Unable to read client certificate file: %v", err)
+	}
+	if config.ClientKey, err = transport.ReadFile(keyFile); err != nil {
+		return config, fmt.Errorf("Unable to read client key file: %v", err)
+	}
+	return config, nil
+}
+
+// GetPlacementPolicy returns the policy deciding which cluster new tenants are placed on,
+// one of least-tenants, weighted or label-matching
+func (c *Data) GetPlacementPolicy() string {
+	return c.v.GetString(varPlacementPolicy)
+}
+
+// GetPlacementLabels returns the user attributes (comma separated) matched against the cluster labels
+// by the label-matching placement policy
+func (c *Data) GetPlacementLabels() []string {
+	return splitList(c.v.GetString(varPlacementLabels))
+}
+
+// GetPlacementFixed returns the allow-listed users, by username or tenant ID, placed on a fixed cluster.
+// Configured as a comma separated list of user=api-url pairs.
+func (c *Data) GetPlacementFixed() (map[string]string, error) {
+	fixed := map[string]string{}
+	for _, entry := range splitList(c.v.GetString(varPlacementFixed)) {
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("Invalid configuration %v: %v is not a user=api-url pair", varPlacementFixed, entry)
+		}
+		fixed[parts[0]] = parts[1]
+	}
+	return fixed, nil
+}
+
 // APIServerInsecureSkipTLSVerify returns if the server's certificate should be checked for validity. This will make your HTTPS connections insecure.
 func (c *Data) APIServerInsecureSkipTLSVerify() bool {
 	return c.v.GetBool(varAPIServerInsecureSkipTLSVerify)
 }
 
+// GetAdminSubjects returns the token subjects (comma separated) allowed to perform administrative operations
+func (c *Data) GetAdminSubjects() []string {
+	return splitList(c.v.GetString(varAdminSubjects))
+}
+
+// GetAdminRoles returns the realm or client roles (comma separated) allowed to perform administrative operations
+func (c *Data) GetAdminRoles() []string {
+	return splitList(c.v.GetString(varAdminRoles))
+}
+
+// GetAdminRolesClient returns the Keycloak client whose roles are checked in addition to the realm roles
+func (c *Data) GetAdminRolesClient() string {
+	return c.v.GetString(varAdminRolesClient)
+}
+
+// splitList splits a comma separated list, ignoring empty entries
+func splitList(list string) []string {
+	var entries []string
+	for _, s := range strings.Split(list, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			entries = append(entries, s)
+		}
+	}
+	return entries
+}
+
+// IsIdlerEnabled returns if inactive Jenkins and Che namespaces should be scaled down
+func (c *Data) IsIdlerEnabled() bool {
+	return c.v.GetBool(varIdlerEnabled)
+}
+
+// GetIdlerTimeout returns how long a tenant has to be inactive before its namespaces are idled
+func (c *Data) GetIdlerTimeout() time.Duration {
+	return c.v.GetDuration(varIdlerTimeout)
+}
+
+// GetIdlerInterval returns how often the idler looks for inactive namespaces
+func (c *Data) GetIdlerInterval() time.Duration {
+	return c.v.GetDuration(varIdlerInterval)
+}
+
+// GetWebhookDispatchInterval returns how often pending webhook deliveries are sent
+func (c *Data) GetWebhookDispatchInterval() time.Duration {
+	return c.v.GetDuration(varWebhookDispatchInterval)
+}
+
+// GetWebhookMaxAttempts returns how often a webhook delivery is attempted before it is given up
+func (c *Data) GetWebhookMaxAttempts() int {
+	return c.v.GetInt(varWebhookMaxAttempts)
+}
+
+// GetHealthCheckTimeout returns how long a dependency check may take before the dependency is reported unavailable
+func (c *Data) GetHealthCheckTimeout() time.Duration {
+	return c.v.GetDuration(varHealthCheckTimeout)
+}
+
+// GetHealthCheckInterval returns how often the readiness checks are run in the background
+func (c *Data) GetHealthCheckInterval() time.Duration {
+	return c.v.GetDuration(varHealthCheckInterval)
+}
+
 // GetTemplateValues return a Map of additional variables used to process the templates
 func (c *Data) GetTemplateValues() (map[string]string, error) {
 	if !c.v.IsSet(varTemplateRecommenderExternalName) {
@@ -262,6 +627,14 @@ func (c *Data) GetTemplateValues() (map[string]string, error) {
 	}, nil
 }
 
+// Represents the kinds of identity provider
+const (
+	// IdentityProviderKeycloak uses the Keycloak realm and broker
+	IdentityProviderKeycloak = "keycloak"
+	// IdentityProviderOIDC uses a generic OpenID Connect provider configured through discovery
+	IdentityProviderOIDC = "oidc"
+)
+
 const (
 	// Auth-related defaults
 
@@ -0,0 +1,119 @@
+package controller
+
+import (
+	"context"
+
+	"github.com/almighty/almighty-core/errors"
+	"github.com/almighty/almighty-core/log"
+	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/fabric8io/fabric8-init-tenant/audit"
+	"github.com/fabric8io/fabric8-init-tenant/auth"
+	"github.com/fabric8io/fabric8-init-tenant/jsonapi"
+	"github.com/fabric8io/fabric8-init-tenant/webhook"
+)
+
+// AdminChecker decides if the caller behind a token is allowed to perform administrative operations
+type AdminChecker func(token *jwt.Token) bool
+
+// AdminSubjects returns an AdminChecker that allows the given token subjects
+func AdminSubjects(subjects []string) AdminChecker {
+	allowed := map[string]bool{}
+	for _, s := range subjects {
+		allowed[s] = true
+	}
+	return func(token *jwt.Token) bool {
+		if claims, ok := token.Claims.(jwt.MapClaims); ok {
+			if sub, ok := claims["sub"].(string); ok {
+				return allowed[sub]
+			}
+		}
+		return false
+	}
+}
+
+// AdminRoles returns an AdminChecker that allows tokens granting any of the given realm roles, or
+// resource roles of the given client
+func AdminRoles(roles []string, client string) AdminChecker {
+	allowed := map[string]bool{}
+	for _, r := range roles {
+		allowed[r] = true
+	}
+	return func(token *jwt.Token) bool {
+		claims, ok := token.Claims.(jwt.MapClaims)
+		if !ok {
+			return false
+		}
+		granted := roleNames(claims["realm_access"])
+		if resources, ok := claims["resource_access"].(map[string]interface{}); ok && client != "" {
+			granted = append(granted, roleNames(resources[client])...)
+		}
+		for _, r := range granted {
+			if allowed[r] {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// roleNames returns the roles of a Keycloak access claim, {"roles": ["..."]}
+func roleNames(access interface{}) []string {
+	var names []string
+	if m, ok := access.(map[string]interface{}); ok {
+		if roles, ok := m["roles"].([]interface{}); ok {
+			for _, r := range roles {
+				if name, ok := r.(string); ok {
+					names = append(names, name)
+				}
+			}
+		}
+	}
+	return names
+}
+
+// AnyAdmin returns an AdminChecker that allows the callers allowed by any of the checkers
+func AnyAdmin(checkers ...AdminChecker) AdminChecker {
+	return func(token *jwt.Token) bool {
+		for _, isAdmin := range checkers {
+			if isAdmin(token) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// currentIdentity returns the identity of the caller of the request
+func currentIdentity(ctx context.Context) (*auth.Identity, error) {
+	identity := auth.ContextIdentity(ctx)
+	if identity == nil {
+		return nil, errors.NewUnauthorizedError("Missing JWT token")
+	}
+	return identity, nil
+}
+
+// requireAdmin returns an error unless the caller of the request is an admin
+func requireAdmin(ctx context.Context, isAdmin AdminChecker) error {
+	identity, err := currentIdentity(ctx)
+	if err != nil {
+		return err
+	}
+	if !isAdmin(identity.Token) {
+		return jsonapi.NewForbiddenError("admin permissions required")
+	}
+	return nil
+}
+
+// recordAudit appends the record to the audit trail along with the webhook events reporting the outcome.
+// A record that can not be stored is logged instead, the operation itself does not fail because of it.
+func recordAudit(ctx context.Context, auditor audit.Service, record *audit.Record, events ...webhook.Event) {
+	if err := auditor.AddRecord(record, events...); err != nil {
+		log.Error(ctx, map[string]interface{}{
+			"err":       err,
+			"operation": record.Operation,
+			"tenant_id": record.TenantID,
+			"actor_id":  record.ActorID,
+			"outcome":   record.Outcome,
+		}, "unable to record audit trail")
+	}
+}
@@ -0,0 +1,51 @@
+package controller_test
+
+import (
+	"testing"
+
+	"github.com/almighty/almighty-core/resource"
+	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/fabric8io/fabric8-init-tenant/controller"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdminRoles(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+
+	token := func(claims jwt.MapClaims) *jwt.Token {
+		return jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	}
+	isAdmin := controller.AdminRoles([]string{"tenant-admin"}, "fabric8-tenant")
+
+	assert.True(t, isAdmin(token(jwt.MapClaims{
+		"realm_access": map[string]interface{}{"roles": []interface{}{"offline_access", "tenant-admin"}},
+	})))
+	assert.True(t, isAdmin(token(jwt.MapClaims{
+		"resource_access": map[string]interface{}{
+			"fabric8-tenant": map[string]interface{}{"roles": []interface{}{"tenant-admin"}},
+		},
+	})))
+	assert.False(t, isAdmin(token(jwt.MapClaims{
+		"resource_access": map[string]interface{}{
+			"account": map[string]interface{}{"roles": []interface{}{"tenant-admin"}},
+		},
+	})))
+	assert.False(t, isAdmin(token(jwt.MapClaims{
+		"realm_access": map[string]interface{}{"roles": []interface{}{"offline_access"}},
+	})))
+	assert.False(t, isAdmin(token(jwt.MapClaims{"realm_access": "tenant-admin"})))
+}
+
+func TestAnyAdmin(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+
+	isAdmin := controller.AnyAdmin(
+		controller.AdminSubjects([]string{"support"}),
+		controller.AdminRoles([]string{"tenant-admin"}, ""))
+
+	assert.True(t, isAdmin(jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{"sub": "support"})))
+	assert.True(t, isAdmin(jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"realm_access": map[string]interface{}{"roles": []interface{}{"tenant-admin"}},
+	})))
+	assert.False(t, isAdmin(jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{"sub": "someone"})))
+}
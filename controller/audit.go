@@ -0,0 +1,93 @@
+package controller
+
+import (
+	"github.com/fabric8io/fabric8-init-tenant/app"
+	"github.com/fabric8io/fabric8-init-tenant/audit"
+	"github.com/fabric8io/fabric8-init-tenant/jsonapi"
+	"github.com/goadesign/goa"
+	uuid "github.com/satori/go.uuid"
+)
+
+// AuditController implements the audit resource.
+type AuditController struct {
+	*goa.Controller
+	auditor audit.Service
+	isAdmin AdminChecker
+}
+
+// NewAuditController creates an audit controller.
+func NewAuditController(service *goa.Service, auditor audit.Service, isAdmin AdminChecker) *AuditController {
+	return &AuditController{
+		Controller: service.NewController("AuditController"),
+		auditor:    auditor,
+		isAdmin:    isAdmin,
+	}
+}
+
+// List runs the list action.
+func (c *AuditController) List(ctx *app.ListAuditContext) error {
+	if err := requireAdmin(ctx, c.isAdmin); err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	records, err := c.auditor.FindRecords(audit.Filter{
+		TenantID: ctx.Tenant,
+		ActorID:  ctx.Actor,
+		Since:    ctx.Since,
+		Until:    ctx.Until,
+		Limit:    ctx.Limit,
+	})
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	response := &app.AuditRecordList{Data: []*app.AuditRecord{}}
+	for _, r := range records {
+		response.Data = append(response.Data, convertAuditRecord(r))
+	}
+	return ctx.OK(response)
+}
+
+func convertAuditRecord(r *audit.Record) *app.AuditRecord {
+	id := r.ID
+	tenantID := r.TenantID
+	operation := r.Operation
+	outcome := r.Outcome
+	createdAt := r.CreatedAt
+	attrs := &app.AuditRecordAttributes{
+		TenantID:  &tenantID,
+		Operation: &operation,
+		Outcome:   &outcome,
+		CreatedAt: &createdAt,
+	}
+	if r.ActorID != uuid.Nil {
+		actorID := r.ActorID
+		attrs.ActorID = &actorID
+	}
+	if r.ActorEmail != "" {
+		actorEmail := r.ActorEmail
+		attrs.ActorEmail = &actorEmail
+	}
+	if r.ActingAs != "" {
+		actingAs := r.ActingAs
+		attrs.ActingAs = &actingAs
+	}
+	if r.TargetVersion != "" {
+		targetVersion := r.TargetVersion
+		attrs.TargetVersion = &targetVersion
+	}
+	if r.Error != "" {
+		recordError := r.Error
+		attrs.Error = &recordError
+	}
+	if r.RequestID != "" {
+		requestID := r.RequestID
+		attrs.RequestID = &requestID
+	}
+	if len(r.Details) > 0 {
+		attrs.Details = map[string]string(r.Details)
+	}
+	return &app.AuditRecord{
+		ID:         &id,
+		Type:       "audit-records",
+		Attributes: attrs,
+	}
+}
@@ -0,0 +1,66 @@
+package controller
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fabric8io/fabric8-init-tenant/tenant"
+)
+
+// tenantETag returns a strong entity tag of the tenant representation, which changes whenever the
+// tenant or any of its namespaces is updated. The idled time and the cluster are included as well
+// since they are updated without touching the update time of older rows.
+func tenantETag(t *tenant.Tenant, namespaces []*tenant.Namespace, include string) string {
+	versions := []string{}
+	for _, ns := range namespaces {
+		idledAt := ""
+		if ns.IdledAt != nil {
+			idledAt = ns.IdledAt.UTC().Format(time.RFC3339Nano)
+		}
+		versions = append(versions, ns.ID.String()+"@"+ns.UpdatedAt.UTC().Format(time.RFC3339Nano)+"@"+idledAt+"@"+ns.MasterURL)
+	}
+	sort.Strings(versions)
+	h := sha1.New()
+	h.Write([]byte(t.ID.String() + "@" + t.UpdatedAt.UTC().Format(time.RFC3339Nano) + "@" + t.MasterURL))
+	h.Write([]byte(strings.Join(versions, ",")))
+	h.Write([]byte("include=" + include))
+	return `"` + hex.EncodeToString(h.Sum(nil)) + `"`
+}
+
+// tenantLastModified returns when the tenant or any of its namespaces was last updated
+func tenantLastModified(t *tenant.Tenant, namespaces []*tenant.Namespace) time.Time {
+	lastModified := t.UpdatedAt
+	for _, ns := range namespaces {
+		if ns.UpdatedAt.After(lastModified) {
+			lastModified = ns.UpdatedAt
+		}
+	}
+	return lastModified
+}
+
+// notModified evaluates the conditional request headers as described in RFC 7232. If-None-Match
+// takes precedence, If-Modified-Since is only evaluated if it is missing.
+func notModified(ifNoneMatch, ifModifiedSince *string, etag string, lastModified time.Time) bool {
+	if ifNoneMatch != nil {
+		for _, candidate := range strings.Split(*ifNoneMatch, ",") {
+			candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+			if candidate == "*" || candidate == etag {
+				return true
+			}
+		}
+		return false
+	}
+	if ifModifiedSince != nil {
+		since, err := http.ParseTime(*ifModifiedSince)
+		if err != nil {
+			return false
+		}
+		// HTTP dates have a resolution of seconds
+		return !lastModified.Truncate(time.Second).After(since)
+	}
+	return false
+}
@@ -0,0 +1,62 @@
+package controller
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/almighty/almighty-core/resource"
+	"github.com/fabric8io/fabric8-init-tenant/tenant"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTenantETag(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+
+	updatedAt := time.Now()
+	current := &tenant.Tenant{ID: uuid.NewV4(), UpdatedAt: updatedAt}
+	jenkins := &tenant.Namespace{ID: uuid.NewV4(), UpdatedAt: updatedAt}
+	che := &tenant.Namespace{ID: uuid.NewV4(), UpdatedAt: updatedAt}
+
+	etag := tenantETag(current, []*tenant.Namespace{jenkins, che}, "")
+	assert.Equal(t, etag, tenantETag(current, []*tenant.Namespace{che, jenkins}, ""))
+	assert.NotEqual(t, etag, tenantETag(current, []*tenant.Namespace{jenkins, che}, "namespaces"))
+
+	idledAt := updatedAt.Add(time.Minute)
+	che.IdledAt = &idledAt
+	idled := tenantETag(current, []*tenant.Namespace{jenkins, che}, "")
+	assert.NotEqual(t, etag, idled)
+	che.IdledAt = nil
+	assert.Equal(t, etag, tenantETag(current, []*tenant.Namespace{jenkins, che}, ""))
+
+	che.MasterURL = "https://api.starter-us-east-2.openshift.com"
+	assert.NotEqual(t, etag, tenantETag(current, []*tenant.Namespace{jenkins, che}, ""))
+	che.MasterURL = ""
+
+	che.UpdatedAt = updatedAt.Add(time.Second)
+	assert.NotEqual(t, etag, tenantETag(current, []*tenant.Namespace{jenkins, che}, ""))
+	assert.Equal(t, che.UpdatedAt, tenantLastModified(current, []*tenant.Namespace{jenkins, che}))
+}
+
+func TestNotModified(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+
+	etag := `"abc"`
+	lastModified := time.Date(2017, 6, 1, 12, 0, 0, 500, time.UTC)
+	header := func(value string) *string {
+		return &value
+	}
+
+	assert.False(t, notModified(nil, nil, etag, lastModified))
+	assert.True(t, notModified(header(`"xyz", "abc"`), nil, etag, lastModified))
+	assert.True(t, notModified(header(`W/"abc"`), nil, etag, lastModified))
+	assert.True(t, notModified(header("*"), nil, etag, lastModified))
+	assert.False(t, notModified(header(`"xyz"`), nil, etag, lastModified))
+
+	assert.True(t, notModified(nil, header(lastModified.Format(http.TimeFormat)), etag, lastModified))
+	assert.False(t, notModified(nil, header(lastModified.Add(-time.Second).Format(http.TimeFormat)), etag, lastModified))
+	assert.False(t, notModified(nil, header("yesterday"), etag, lastModified))
+	// If-None-Match takes precedence over If-Modified-Since
+	assert.False(t, notModified(header(`"xyz"`), header(lastModified.Format(http.TimeFormat)), etag, lastModified))
+}
@@ -0,0 +1,29 @@
+package controller
+
+import (
+	"context"
+	"time"
+)
+
+// detachedContext keeps the values of the request context, e.g. the request ID reported in the logs
+// and the audit trail, but is not canceled when the request completes
+type detachedContext struct {
+	context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (detachedContext) Err() error {
+	return nil
+}
+
+// detach returns a context for work started by the request that outlives it
+func detach(ctx context.Context) context.Context {
+	return detachedContext{ctx}
+}
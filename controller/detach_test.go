@@ -0,0 +1,23 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/almighty/almighty-core/resource"
+	"github.com/stretchr/testify/assert"
+)
+
+type requestKey struct{}
+
+func TestDetach(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), requestKey{}, "req-1"))
+	detached := detach(ctx)
+	cancel()
+
+	assert.Equal(t, "req-1", detached.Value(requestKey{}))
+	assert.NoError(t, detached.Err())
+	assert.Nil(t, detached.Done())
+}
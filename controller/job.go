@@ -0,0 +1,74 @@
+package controller
+
+import (
+	"github.com/almighty/almighty-core/errors"
+	"github.com/fabric8io/fabric8-init-tenant/app"
+	"github.com/fabric8io/fabric8-init-tenant/jsonapi"
+	"github.com/fabric8io/fabric8-init-tenant/tenant"
+	"github.com/goadesign/goa"
+)
+
+// JobController implements the job resource.
+type JobController struct {
+	*goa.Controller
+	tenantService tenant.Service
+	isAdmin       AdminChecker
+}
+
+// NewJobController creates a job controller.
+func NewJobController(service *goa.Service, tenantService tenant.Service, isAdmin AdminChecker) *JobController {
+	return &JobController{
+		Controller:    service.NewController("JobController"),
+		tenantService: tenantService,
+		isAdmin:       isAdmin,
+	}
+}
+
+// Show runs the show action.
+func (c *JobController) Show(ctx *app.ShowJobContext) error {
+	identity, err := currentIdentity(ctx)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+
+	job, err := c.tenantService.GetJob(ctx.ID)
+	// jobs of other tenants are reported as not found unless the caller is an admin
+	if err != nil || (job.TenantID != identity.ID && !c.isAdmin(identity.Token)) {
+		return jsonapi.JSONErrorResponse(ctx, errors.NewNotFoundError("jobs", ctx.ID.String()))
+	}
+	return ctx.OK(convertJob(job))
+}
+
+func convertJob(job *tenant.Job) *app.JobSingle {
+	id := job.ID
+	jobType := string(job.Type)
+	target := job.Target
+	state := job.State
+	createdAt := job.CreatedAt
+	attrs := &app.JobAttributes{
+		JobType:     &jobType,
+		Target:      &target,
+		State:       &state,
+		CreatedAt:   &createdAt,
+		CompletedAt: job.CompletedAt,
+	}
+	if job.Source != "" {
+		source := job.Source
+		attrs.Source = &source
+	}
+	if job.Step != "" {
+		step := job.Step
+		attrs.Step = &step
+	}
+	if job.Error != "" {
+		jobError := job.Error
+		attrs.Error = &jobError
+	}
+	return &app.JobSingle{
+		Data: &app.Job{
+			ID:         &id,
+			Type:       "jobs",
+			Attributes: attrs,
+		},
+	}
+}
@@ -0,0 +1,143 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/almighty/almighty-core/errors"
+	"github.com/fabric8io/fabric8-init-tenant/app"
+	"github.com/fabric8io/fabric8-init-tenant/jsonapi"
+	"github.com/fabric8io/fabric8-init-tenant/openshift"
+	"github.com/fabric8io/fabric8-init-tenant/progress"
+	"github.com/fabric8io/fabric8-init-tenant/tenant"
+	"github.com/goadesign/goa"
+	uuid "github.com/satori/go.uuid"
+)
+
+// progressHeartbeat is how often a comment is sent on an idle event stream to keep proxies from closing it
+const progressHeartbeat = 15 * time.Second
+
+// Progress runs the progress action.
+func (c *TenantController) Progress(ctx *app.ProgressTenantContext) error {
+	identity, err := currentIdentity(ctx)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	if !c.tenantService.Exists(identity.ID) {
+		return jsonapi.JSONErrorResponse(ctx, errors.NewNotFoundError("tenants", identity.ID.String()))
+	}
+	var lastEventID int64
+	if ctx.LastEventID != nil {
+		lastEventID, err = strconv.ParseInt(*ctx.LastEventID, 10, 64)
+		if err != nil {
+			return jsonapi.JSONErrorResponse(ctx, errors.NewBadParameterError("Last-Event-ID", *ctx.LastEventID))
+		}
+	}
+
+	missed, events, cancel := c.progress.Subscribe(identity.ID, lastEventID)
+	defer cancel()
+
+	rw := ctx.ResponseData
+	rw.Header().Set("Content-Type", "text/event-stream")
+	rw.Header().Set("Cache-Control", "no-cache")
+	rw.WriteHeader(http.StatusOK)
+	for _, e := range missed {
+		if err := writeEvent(rw, e); err != nil {
+			return nil
+		}
+	}
+	flush(rw)
+
+	heartbeat := time.NewTicker(progressHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-ctx.RequestData.Context().Done():
+			return nil
+		case e, ok := <-events:
+			// the subscription is closed when the client falls behind, it reconnects with the last event ID
+			if !ok {
+				return nil
+			}
+			if err := writeEvent(rw, e); err != nil {
+				return nil
+			}
+		case <-heartbeat.C:
+			if _, err := io.WriteString(rw, ": heartbeat\n\n"); err != nil {
+				return nil
+			}
+		}
+		flush(rw)
+	}
+}
+
+// writeEvent writes the event in the Server-Sent Events format
+func writeEvent(w io.Writer, e progress.Event) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Type, data)
+	return err
+}
+
+func flush(rw *goa.ResponseData) {
+	if f, ok := rw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// ReportProgress returns a Callback publishing the objects applied through the given callback as progress
+// events of the tenant
+func ReportProgress(broker *progress.Broker, tenantID uuid.UUID, callback openshift.Callback) openshift.Callback {
+	return func(statusCode int, method string, request, response map[interface{}]interface{}) (string, map[interface{}]interface{}) {
+		action, object := callback(statusCode, method, request, response)
+		event := progress.Event{
+			Namespace: openshift.GetNamespace(request),
+			Kind:      openshift.GetKind(request),
+			Name:      openshift.GetName(request),
+		}
+		switch {
+		case statusCode == http.StatusCreated && event.Kind == openshift.ValKindProjectRequest:
+			event.Type = progress.EventNamespaceReady
+			event.Namespace = event.Name
+		case statusCode == http.StatusCreated:
+			event.Type = progress.EventObjectCreated
+		case statusCode >= http.StatusBadRequest && statusCode != http.StatusConflict:
+			event.Type = progress.EventObjectFailed
+			event.Error = http.StatusText(statusCode)
+		default:
+			return action, object
+		}
+		broker.Publish(tenantID, event)
+		return action, object
+	}
+}
+
+// reportProvisioned publishes the outcome of provisioning the tenant
+func reportProvisioned(broker *progress.Broker, tenantID uuid.UUID, err error) {
+	event := progress.Event{Type: progress.EventProvisioningCompleted}
+	if err != nil {
+		event.Type = progress.EventProvisioningFailed
+		event.Error = err.Error()
+	}
+	broker.Publish(tenantID, event)
+}
+
+// reportJob publishes the outcome of the completed job
+func reportJob(broker *progress.Broker, job *tenant.Job) {
+	event := progress.Event{
+		Type:  progress.EventJobCompleted,
+		Name:  job.Target,
+		JobID: job.ID.String(),
+		Error: job.Error,
+	}
+	if job.State == tenant.JobStateFailed {
+		event.Type = progress.EventJobFailed
+	}
+	broker.Publish(job.TenantID, event)
+}
@@ -0,0 +1,60 @@
+package controller
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+
+	"github.com/almighty/almighty-core/resource"
+	"github.com/fabric8io/fabric8-init-tenant/openshift"
+	"github.com/fabric8io/fabric8-init-tenant/progress"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReportProgress(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+
+	broker := progress.NewBroker(progress.DefaultRetention)
+	tenantID := uuid.NewV4()
+	callback := ReportProgress(broker, tenantID, func(statusCode int, method string, request, response map[interface{}]interface{}) (string, map[interface{}]interface{}) {
+		if statusCode == http.StatusConflict {
+			return "DELETE", request
+		}
+		return "", nil
+	})
+	object := func(kind, namespace, name string) map[interface{}]interface{} {
+		return map[interface{}]interface{}{
+			openshift.FieldKind: kind,
+			openshift.FieldMetadata: map[interface{}]interface{}{
+				openshift.FieldNamespace: namespace,
+				openshift.FieldName:      name,
+			},
+		}
+	}
+
+	callback(http.StatusCreated, "POST", object(openshift.ValKindProjectRequest, "", "aslak-jenkins"), nil)
+	action, _ := callback(http.StatusConflict, "POST", object("Route", "aslak-jenkins", "jenkins"), nil)
+	assert.Equal(t, "DELETE", action)
+	callback(http.StatusCreated, "POST", object("Route", "aslak-jenkins", "jenkins"), nil)
+	callback(http.StatusForbidden, "POST", object("Secret", "aslak-jenkins", "token"), nil)
+
+	events, _, cancel := broker.Subscribe(tenantID, 0)
+	defer cancel()
+	require.Len(t, events, 3)
+	assert.Equal(t, progress.EventNamespaceReady, events[0].Type)
+	assert.Equal(t, "aslak-jenkins", events[0].Namespace)
+	assert.Equal(t, progress.EventObjectCreated, events[1].Type)
+	assert.Equal(t, "Route", events[1].Kind)
+	assert.Equal(t, progress.EventObjectFailed, events[2].Type)
+	assert.Equal(t, "Forbidden", events[2].Error)
+}
+
+func TestWriteEvent(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+
+	var buf bytes.Buffer
+	require.NoError(t, writeEvent(&buf, progress.Event{ID: 42, Type: progress.EventJobFailed, JobID: "1", Error: "boom"}))
+	assert.Equal(t, "id: 42\nevent: job-failed\ndata: {\"time\":\"0001-01-01T00:00:00Z\",\"job_id\":\"1\",\"error\":\"boom\"}\n\n", buf.String())
+}
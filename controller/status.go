@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/fabric8io/fabric8-init-tenant/app"
+	"github.com/fabric8io/fabric8-init-tenant/health"
 	"github.com/goadesign/goa"
 	"github.com/jinzhu/gorm"
 )
@@ -20,14 +21,19 @@ var (
 // StatusController implements the status resource.
 type StatusController struct {
 	*goa.Controller
-	db *gorm.DB
+	db        *gorm.DB
+	monitor   *health.Monitor
+	readiness *health.Monitor
 }
 
-// NewStatusController creates a status controller.
-func NewStatusController(service *goa.Service, db *gorm.DB) *StatusController {
+// NewStatusController creates a status controller. Both monitors are expected to run in the background,
+// deep status requests report the cached results of the monitor instead of checking the dependencies.
+func NewStatusController(service *goa.Service, db *gorm.DB, monitor, readiness *health.Monitor) *StatusController {
 	return &StatusController{
 		Controller: service.NewController("StatusController"),
 		db:         db,
+		monitor:    monitor,
+		readiness:  readiness,
 	}
 }
 
@@ -45,5 +51,70 @@ func (c *StatusController) Show(ctx *app.ShowStatusContext) error {
 		res.Error = &message
 		return ctx.ServiceUnavailable(res)
 	}
+
+	if ctx.Deep {
+		results := c.monitor.Results()
+		if results == nil {
+			message := "dependency checks have not run yet"
+			res.Error = &message
+			return ctx.ServiceUnavailable(res)
+		}
+		res.Dependencies = convertDependencies(results)
+		if !health.Healthy(results) {
+			message := "one or more dependencies are unavailable"
+			res.Error = &message
+			return ctx.ServiceUnavailable(res)
+		}
+	}
 	return ctx.OK(res)
 }
+
+// Live runs the live action.
+func (c *StatusController) Live(ctx *app.LiveStatusContext) error {
+	return ctx.OK(newStatus())
+}
+
+// Ready runs the ready action.
+func (c *StatusController) Ready(ctx *app.ReadyStatusContext) error {
+	res := newStatus()
+	results := c.readiness.Results()
+	if results == nil {
+		message := "readiness checks have not run yet"
+		res.Error = &message
+		return ctx.ServiceUnavailable(res)
+	}
+	res.Dependencies = convertDependencies(results)
+	if !health.Healthy(results) {
+		message := "not ready"
+		res.Error = &message
+		return ctx.ServiceUnavailable(res)
+	}
+	return ctx.OK(res)
+}
+
+func newStatus() *app.Status {
+	return &app.Status{
+		Commit:    Commit,
+		BuildTime: BuildTime,
+		StartTime: StartTime,
+	}
+}
+
+func convertDependencies(results []health.Result) []*app.DependencyStatus {
+	dependencies := []*app.DependencyStatus{}
+	for _, r := range results {
+		dependency := &app.DependencyStatus{
+			Name:        r.Name,
+			Healthy:     r.Healthy,
+			LatencyMs:   int(r.Latency / time.Millisecond),
+			CheckedAt:   r.CheckedAt,
+			LastSuccess: r.LastSuccess,
+		}
+		if r.Error != "" {
+			err := r.Error
+			dependency.Error = &err
+		}
+		dependencies = append(dependencies, dependency)
+	}
+	return dependencies
+}
@@ -3,64 +3,101 @@ package controller
 import (
 	"context"
 	"net/http"
-
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/almighty/almighty-core/errors"
 	"github.com/almighty/almighty-core/log"
 	"github.com/almighty/almighty-core/rest"
 	jwt "github.com/dgrijalva/jwt-go"
 	"github.com/fabric8io/fabric8-init-tenant/app"
+	"github.com/fabric8io/fabric8-init-tenant/audit"
+	"github.com/fabric8io/fabric8-init-tenant/auth"
+	"github.com/fabric8io/fabric8-init-tenant/cluster"
+	"github.com/fabric8io/fabric8-init-tenant/idler"
+	"github.com/fabric8io/fabric8-init-tenant/idp"
 	"github.com/fabric8io/fabric8-init-tenant/jsonapi"
-	"github.com/fabric8io/fabric8-init-tenant/keycloak"
 	"github.com/fabric8io/fabric8-init-tenant/openshift"
+	"github.com/fabric8io/fabric8-init-tenant/plan"
+	"github.com/fabric8io/fabric8-init-tenant/progress"
 	"github.com/fabric8io/fabric8-init-tenant/tenant"
+	"github.com/fabric8io/fabric8-init-tenant/webhook"
 	"github.com/goadesign/goa"
-	goajwt "github.com/goadesign/goa/middleware/security/jwt"
 	uuid "github.com/satori/go.uuid"
 )
 
 // TenantController implements the status resource.
 type TenantController struct {
 	*goa.Controller
-	tenantService   tenant.Service
-	keycloakConfig  keycloak.Config
-	openshiftConfig openshift.Config
-	templateVars    map[string]string
+	tenantService tenant.Service
+	tokens        *idp.TokenCache
+	clusters      *cluster.Registry
+	templateVars  map[string]string
+	idler         *idler.Idler
+	auditor       audit.Service
+	progress      *progress.Broker
 }
 
 // NewTenantController creates a status controller.
-func NewTenantController(service *goa.Service, tenantService tenant.Service, keycloakConfig keycloak.Config, openshiftConfig openshift.Config, templateVars map[string]string) *TenantController {
+func NewTenantController(service *goa.Service, tenantService tenant.Service, tokens *idp.TokenCache, clusters *cluster.Registry, templateVars map[string]string, idler *idler.Idler, auditor audit.Service, progress *progress.Broker) *TenantController {
 	return &TenantController{
-		Controller:      service.NewController("TenantController"),
-		tenantService:   tenantService,
-		keycloakConfig:  keycloakConfig,
-		openshiftConfig: openshiftConfig,
-		templateVars:    templateVars,
+		Controller:    service.NewController("TenantController"),
+		tenantService: tenantService,
+		tokens:        tokens,
+		clusters:      clusters,
+		templateVars:  templateVars,
+		idler:         idler,
+		auditor:       auditor,
+		progress:      progress,
 	}
 }
 
 // Setup runs the setup action.
 func (c *TenantController) Setup(ctx *app.SetupTenantContext) error {
-	token := goajwt.ContextJWT(ctx)
-	if token == nil {
-		return jsonapi.JSONErrorResponse(ctx, errors.NewUnauthorizedError("Missing JWT token"))
+	identity, err := currentIdentity(ctx)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
 	}
-	ttoken := &TenantToken{token: token}
-	exists := c.tenantService.Exists(ttoken.Subject())
+	if identity.Email == "" {
+		return jsonapi.JSONErrorResponse(ctx, errors.NewUnauthorizedError("Missing email in JWT token"))
+	}
+	exists := c.tenantService.Exists(identity.ID)
 	if exists {
-		return ctx.Conflict()
+		return jsonapi.JSONErrorResponse(ctx, errors.NewVersionConflictError("the tenant is already set up"))
+	}
+
+	openshiftUserToken := c.tokens.TokenSource(identity.ID.String(), identity.TokenID, identity.Token.Raw)
+	openshiftToken, err := openshiftUserToken()
+	if err != nil {
+		log.Error(ctx, map[string]interface{}{
+			"err": err,
+		}, "unable to fetch the linked OpenShift token of the user")
+		return jsonapi.JSONErrorResponse(ctx, errors.NewUnauthorizedError("Could not authorize against the identity provider"))
 	}
 
-	openshiftUserToken, err := keycloak.OpenshiftToken(c.keycloakConfig, token.Raw)
+	placement, err := c.clusters.Place(placementRequest(identity))
 	if err != nil {
 		log.Error(ctx, map[string]interface{}{
 			"err": err,
-		}, "unable to authenticate user with keycloak")
-		return jsonapi.JSONErrorResponse(ctx, errors.NewUnauthorizedError("Could not authorization against keycloak"))
+		}, "unable to place the tenant on a cluster")
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	oc, err := c.clusters.Config(placement.Cluster.APIURL)
+	if err != nil {
+		log.Error(ctx, map[string]interface{}{
+			"err":         err,
+			"cluster_url": placement.Cluster.APIURL,
+		}, "unable to configure the tenant cluster")
+		return jsonapi.JSONErrorResponse(ctx, err)
 	}
+	log.Info(ctx, map[string]interface{}{
+		"tenant_id":   identity.ID,
+		"cluster_url": oc.MasterURL,
+		"reason":      placement.Reason,
+	}, "tenant placed")
 
-	openshiftUser, err := openshift.WhoAmI(c.openshiftConfig.WithToken(openshiftUserToken))
+	openshiftUser, err := openshift.WhoAmI(oc.WithToken(openshiftToken))
 	if err != nil {
 		log.Error(ctx, map[string]interface{}{
 			"err": err,
@@ -68,19 +105,39 @@ func (c *TenantController) Setup(ctx *app.SetupTenantContext) error {
 		return jsonapi.JSONErrorResponse(ctx, errors.NewUnauthorizedError("unknown/unauthorized openshift user"))
 	}
 
-	tenant := &tenant.Tenant{ID: ttoken.Subject(), Email: ttoken.Email()}
-	c.tenantService.UpdateTenant(tenant)
+	tenant := &tenant.Tenant{
+		ID:              identity.ID,
+		Email:           identity.Email,
+		OSUsername:      openshiftUser,
+		Plan:            plan.Default,
+		MasterURL:       oc.MasterURL,
+		PlacementReason: placement.Reason,
+	}
+	err = c.storeTenant(oc, tenant)
+	if err != nil {
+		log.Error(ctx, map[string]interface{}{
+			"err":          err,
+			"os_user":      openshiftUser,
+			"ns_base_name": tenant.NsBaseName,
+		}, "unable to store tenant")
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	record := audit.NewRecord(ctx, "setup", tenant.ID)
+	record.ActingAs = openshiftUser
+	record.TargetVersion = oc.TeamVersion
+	record.Details = audit.Details{"cluster_url": oc.MasterURL}
+	recordAudit(ctx, c.auditor, record)
 
 	go func() {
 		ctx := ctx
 		t := tenant
-		oc := c.openshiftConfig
 		err = openshift.InitTenant(
 			oc,
-			InitTenant(ctx, c.openshiftConfig.MasterURL, c.tenantService, t),
+			ReportProgress(c.progress, t.ID, InitTenant(ctx, oc.MasterURL, c.tenantService, t)),
 			openshiftUser,
+			t.NsBaseName,
 			openshiftUserToken,
-			c.templateVars)
+			TemplateVars(t, c.templateVars))
 
 		if err != nil {
 			log.Error(ctx, map[string]interface{}{
@@ -88,6 +145,8 @@ func (c *TenantController) Setup(ctx *app.SetupTenantContext) error {
 				"os_user": openshiftUser,
 			}, "unable initialize tenant")
 		}
+		reportProvisioned(c.progress, t.ID, err)
+		recordAudit(ctx, c.auditor, record.Completed(err), completedEvent(webhook.EventTenantCreated, t.ID, record.Operation, err))
 	}()
 
 	ctx.ResponseData.Header().Set("Location", rest.AbsoluteURL(ctx.RequestData, app.TenantHref()))
@@ -96,42 +155,57 @@ func (c *TenantController) Setup(ctx *app.SetupTenantContext) error {
 
 // Update runs the setup action.
 func (c *TenantController) Update(ctx *app.UpdateTenantContext) error {
-	token := goajwt.ContextJWT(ctx)
-	if token == nil {
-		return jsonapi.JSONErrorResponse(ctx, errors.NewUnauthorizedError("Missing JWT token"))
+	identity, err := currentIdentity(ctx)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
 	}
-	ttoken := &TenantToken{token: token}
-	tenant, err := c.tenantService.GetTenant(ttoken.Subject())
+	tenant, err := c.tenantService.GetTenant(identity.ID)
 	if err != nil {
-		return jsonapi.JSONErrorResponse(ctx, errors.NewNotFoundError("tenants", ttoken.Subject().String()))
+		return jsonapi.JSONErrorResponse(ctx, errors.NewNotFoundError("tenants", identity.ID.String()))
 	}
+	c.recordActivity(ctx, tenant.ID)
 
-	openshiftUserToken, err := keycloak.OpenshiftToken(c.keycloakConfig, token.Raw)
+	oc, err := c.clusters.Config(tenant.MasterURL)
 	if err != nil {
-		log.Error(ctx, map[string]interface{}{
-			"err": err,
-		}, "unable to authenticate user with keycloak")
-		return jsonapi.JSONErrorResponse(ctx, errors.NewUnauthorizedError("Could not authorization against keycloak"))
+		return jsonapi.JSONErrorResponse(ctx, err)
 	}
 
-	openshiftUser, err := openshift.WhoAmI(c.openshiftConfig.WithToken(openshiftUserToken))
-	if err != nil {
-		log.Error(ctx, map[string]interface{}{
-			"err": err,
-		}, "unable to authenticate user with tenant target server")
-		return jsonapi.JSONErrorResponse(ctx, errors.NewUnauthorizedError("unknown/unauthorized openshift user"))
+	// when impersonating the user the tenant can be updated without a live OpenShift session
+	openshiftUser := OpenShiftUsername(tenant)
+	openshiftUserToken := openshift.StaticToken(oc.Token)
+	if !oc.Impersonate {
+		openshiftUserToken = c.tokens.TokenSource(identity.ID.String(), identity.TokenID, identity.Token.Raw)
+		openshiftToken, err := openshiftUserToken()
+		if err != nil {
+			log.Error(ctx, map[string]interface{}{
+				"err": err,
+			}, "unable to fetch the linked OpenShift token of the user")
+			return jsonapi.JSONErrorResponse(ctx, errors.NewUnauthorizedError("Could not authorize against the identity provider"))
+		}
+
+		openshiftUser, err = openshift.WhoAmI(oc.WithToken(openshiftToken))
+		if err != nil {
+			log.Error(ctx, map[string]interface{}{
+				"err": err,
+			}, "unable to authenticate user with tenant target server")
+			return jsonapi.JSONErrorResponse(ctx, errors.NewUnauthorizedError("unknown/unauthorized openshift user"))
+		}
 	}
+	record := audit.NewRecord(ctx, "update", tenant.ID)
+	record.ActingAs = openshiftUser
+	record.TargetVersion = oc.TeamVersion
+	recordAudit(ctx, c.auditor, record)
 
 	go func() {
 		ctx := ctx
 		t := tenant
-		oc := c.openshiftConfig
 		err = openshift.InitTenant(
 			oc,
-			InitTenant(ctx, c.openshiftConfig.MasterURL, c.tenantService, t),
+			ReportProgress(c.progress, t.ID, InitTenant(ctx, oc.MasterURL, c.tenantService, t)),
 			openshiftUser,
+			t.NsBaseName,
 			openshiftUserToken,
-			c.templateVars)
+			TemplateVars(t, c.templateVars))
 
 		if err != nil {
 			log.Error(ctx, map[string]interface{}{
@@ -139,6 +213,8 @@ func (c *TenantController) Update(ctx *app.UpdateTenantContext) error {
 				"os_user": openshiftUser,
 			}, "unable initialize tenant")
 		}
+		reportProvisioned(c.progress, t.ID, err)
+		recordAudit(ctx, c.auditor, record.Completed(err), completedEvent(webhook.EventTenantUpdated, t.ID, record.Operation, err))
 	}()
 
 	ctx.ResponseData.Header().Set("Location", rest.AbsoluteURL(ctx.RequestData, app.TenantHref()))
@@ -147,13 +223,11 @@ func (c *TenantController) Update(ctx *app.UpdateTenantContext) error {
 
 // Show runs the setup action.
 func (c *TenantController) Show(ctx *app.ShowTenantContext) error {
-	token := goajwt.ContextJWT(ctx)
-	if token == nil {
-		return jsonapi.JSONErrorResponse(ctx, errors.NewUnauthorizedError("Missing JWT token"))
+	identity, err := currentIdentity(ctx)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
 	}
-
-	ttoken := &TenantToken{token: token}
-	tenantID := ttoken.Subject()
+	tenantID := identity.ID
 	tenant, err := c.tenantService.GetTenant(tenantID)
 	if err != nil {
 		return jsonapi.JSONErrorResponse(ctx, err)
@@ -164,31 +238,373 @@ func (c *TenantController) Show(ctx *app.ShowTenantContext) error {
 		return jsonapi.JSONErrorResponse(ctx, err)
 	}
 
+	c.recordActivity(ctx, tenantID)
+
+	include := ""
+	if ctx.Include != nil {
+		include = *ctx.Include
+	}
+	etag := tenantETag(tenant, namespaces, include)
+	lastModified := tenantLastModified(tenant, namespaces)
+	ctx.ResponseData.Header().Set("ETag", etag)
+	ctx.ResponseData.Header().Set("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	// the tenant is private to the caller and changes while it is provisioned, caches have to revalidate it
+	ctx.ResponseData.Header().Set("Cache-Control", "private, no-cache")
+	if notModified(ctx.IfNoneMatch, ctx.IfModifiedSince, etag, lastModified) {
+		return ctx.NotModified()
+	}
+
+	response := convertTenant(tenant, namespaces)
+	response.Data.Relationships = tenantRelationships(ctx.RequestData, namespaces)
+	if include == "namespaces" {
+		response.Included = []interface{}{}
+		for _, ns := range namespaces {
+			response.Included = append(response.Included, convertNamespace(ctx.RequestData, ns))
+		}
+	}
+	return ctx.OK(response)
+}
+
+// ListNamespaces runs the list-namespaces action.
+func (c *TenantController) ListNamespaces(ctx *app.ListNamespacesTenantContext) error {
+	identity, err := currentIdentity(ctx)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	if !c.tenantService.Exists(identity.ID) {
+		return jsonapi.JSONErrorResponse(ctx, errors.NewNotFoundError("tenants", identity.ID.String()))
+	}
+	namespaces, err := c.tenantService.GetNamespaces(identity.ID)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	response := &app.NamespaceList{Data: []*app.Namespace{}}
+	for _, ns := range namespaces {
+		response.Data = append(response.Data, convertNamespace(ctx.RequestData, ns))
+	}
+	return ctx.OK(response)
+}
+
+// ShowNamespace runs the show-namespace action.
+func (c *TenantController) ShowNamespace(ctx *app.ShowNamespaceTenantContext) error {
+	identity, err := currentIdentity(ctx)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	namespaces, err := c.tenantService.GetNamespaces(identity.ID)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	// only the namespaces of the caller are found
+	for _, ns := range namespaces {
+		if ns.ID == ctx.NamespaceID {
+			return ctx.OK(&app.NamespaceSingle{Data: convertNamespace(ctx.RequestData, ns)})
+		}
+	}
+	return jsonapi.JSONErrorResponse(ctx, errors.NewNotFoundError("namespaces", ctx.NamespaceID.String()))
+}
+
+// Reset runs the reset action.
+func (c *TenantController) Reset(ctx *app.ResetTenantContext) error {
+	identity, err := currentIdentity(ctx)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	currentTenant, err := c.tenantService.GetTenant(identity.ID)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, errors.NewNotFoundError("tenants", identity.ID.String()))
+	}
+	c.recordActivity(ctx, currentTenant.ID)
+
+	oc, err := c.clusters.Config(currentTenant.MasterURL)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	response, err := resetNamespace(ctx, c.tenantService, c.auditor, c.progress, oc, currentTenant, ctx.Type, ctx.PreservePvcs, c.templateVars)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	ctx.ResponseData.Header().Set("Location", rest.AbsoluteURL(ctx.RequestData, app.JobHref(*response.Data.ID)))
+	return ctx.Accepted(response)
+}
+
+// resetNamespace records a reset job and deletes and re-provisions the namespace of the given type in the
+// background, using the master service token of the cluster
+func resetNamespace(ctx context.Context, tenantService tenant.Service, auditor audit.Service, broker *progress.Broker, oc openshift.Config, t *tenant.Tenant, nsType string, preservePvcs bool, templateVars map[string]string) (*app.JobSingle, error) {
+	namespaces, err := tenantService.GetNamespaces(t.ID)
+	if err != nil {
+		return nil, err
+	}
+	var namespace *tenant.Namespace
+	for _, ns := range namespaces {
+		if string(ns.Type) == nsType {
+			namespace = ns
+		}
+	}
+	if namespace == nil {
+		return nil, errors.NewNotFoundError("namespaces", nsType)
+	}
+
+	job := &tenant.Job{
+		TenantID: t.ID,
+		Type:     tenant.JobTypeReset,
+		Target:   namespace.Name,
+		State:    tenant.JobStateRunning,
+	}
+	err = tenantService.UpdateJob(job)
+	if err != nil {
+		return nil, err
+	}
+	response := convertJob(job)
+	record := audit.NewRecord(ctx, "reset", t.ID)
+	record.ActingAs = actingAs(oc, t)
+	record.TargetVersion = oc.TeamVersion
+	record.Details = audit.Details{
+		"namespace":     namespace.Name,
+		"preserve_pvcs": strconv.FormatBool(preservePvcs),
+		"job_id":        job.ID.String(),
+	}
+	recordAudit(ctx, auditor, record)
+
+	go func() {
+		err := openshift.ResetNamespace(
+			oc,
+			ReportProgress(broker, t.ID, InitTenant(ctx, oc.MasterURL, tenantService, t)),
+			OpenShiftUsername(t),
+			t.NsBaseName,
+			TemplateVars(t, templateVars),
+			nsType,
+			preservePvcs)
+
+		if err != nil {
+			log.Error(ctx, map[string]interface{}{
+				"err":       err,
+				"namespace": job.Target,
+				"job_id":    job.ID,
+			}, "unable to reset namespace")
+		}
+		recordAudit(ctx, auditor, record.Completed(err))
+		job.Complete(err)
+		if err := tenantService.UpdateJob(job, completedEvent(webhook.EventTenantUpdated, t.ID, record.Operation, err)); err != nil {
+			log.Error(ctx, map[string]interface{}{
+				"err":    err,
+				"job_id": job.ID,
+			}, "unable to record job state")
+		}
+		reportJob(broker, job)
+	}()
+	return response, nil
+}
+
+// Unidle runs the unidle action.
+func (c *TenantController) Unidle(ctx *app.UnidleTenantContext) error {
+	identity, err := currentIdentity(ctx)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	tenantID := identity.ID
+	currentTenant, err := c.tenantService.GetTenant(tenantID)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, errors.NewNotFoundError("tenants", tenantID.String()))
+	}
+
+	var types []tenant.NamespaceType
+	if ctx.Type != nil {
+		types = append(types, tenant.NamespaceType(*ctx.Type))
+	}
+	err = c.idler.Unidle(ctx, tenantID, types...)
+	record := audit.NewRecord(ctx, "unidle", tenantID)
+	if ctx.Type != nil {
+		record.Details = audit.Details{"type": *ctx.Type}
+	}
+	recordAudit(ctx, c.auditor, record.Completed(err))
+	if err != nil {
+		log.Error(ctx, map[string]interface{}{
+			"err":       err,
+			"tenant_id": tenantID,
+		}, "unable to unidle tenant")
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+
+	namespaces, err := c.tenantService.GetNamespaces(tenantID)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	return ctx.OK(convertTenant(currentTenant, namespaces))
+}
+
+func (c *TenantController) recordActivity(ctx context.Context, tenantID uuid.UUID) {
+	if err := c.tenantService.RecordActivity(tenantID); err != nil {
+		log.Error(ctx, map[string]interface{}{
+			"err":       err,
+			"tenant_id": tenantID,
+		}, "unable to record tenant activity")
+	}
+}
+
+func convertTenant(t *tenant.Tenant, namespaces []*tenant.Namespace) *app.TenantSingle {
+	return &app.TenantSingle{Data: convertTenantData(t, namespaces)}
+}
+
+func convertTenantData(t *tenant.Tenant, namespaces []*tenant.Namespace) *app.Tenant {
+	tenantID := t.ID
 	response := app.Tenant{
 		ID:   &tenantID,
 		Type: "tenants",
 		Attributes: &app.TenantAttributes{
-			CreatedAt:  &tenant.CreatedAt,
-			Email:      &tenant.Email,
+			CreatedAt:  &t.CreatedAt,
+			Email:      &t.Email,
 			Namespaces: []*app.NamespaceAttributes{},
 		},
 	}
+	if t.Plan != "" {
+		response.Attributes.Plan = &t.Plan
+	}
+	if t.MasterURL != "" {
+		response.Attributes.ClusterURL = &t.MasterURL
+	}
+	if t.PlacementReason != "" {
+		response.Attributes.PlacementReason = &t.PlacementReason
+	}
+	for _, ns := range namespaces {
+		response.Attributes.Namespaces = append(response.Attributes.Namespaces, convertNamespaceAttributes(ns))
+	}
+	return &response
+}
+
+func convertNamespaceAttributes(ns *tenant.Namespace) *app.NamespaceAttributes {
+	tenantType := string(ns.Type)
+	return &app.NamespaceAttributes{
+		CreatedAt:  &ns.CreatedAt,
+		UpdatedAt:  &ns.UpdatedAt,
+		ClusterURL: &ns.MasterURL,
+		Name:       &ns.Name,
+		Type:       &tenantType,
+		Version:    &ns.Version,
+		State:      &ns.State,
+		IdledAt:    ns.IdledAt,
+	}
+}
+
+// convertNamespace returns the namespace as a resource of the tenant of the caller
+func convertNamespace(req *goa.RequestData, ns *tenant.Namespace) *app.Namespace {
+	self := rest.AbsoluteURL(req, app.TenantHref()+"/namespaces/"+ns.ID.String())
+	tenantLink := rest.AbsoluteURL(req, app.TenantHref())
+	tenantType := "tenants"
+	tenantID := ns.TenantID.String()
+	return &app.Namespace{
+		Type:       "namespaces",
+		ID:         ns.ID.String(),
+		Attributes: convertNamespaceAttributes(ns),
+		Relationships: &app.NamespaceRelationships{
+			Tenant: &app.RelationGeneric{
+				Data:  &app.GenericData{Type: &tenantType, ID: &tenantID},
+				Links: &app.GenericLinks{Related: &tenantLink},
+			},
+		},
+		Links: &app.GenericLinks{Self: &self},
+	}
+}
+
+// tenantRelationships links the tenant of the caller to its namespace resources
+func tenantRelationships(req *goa.RequestData, namespaces []*tenant.Namespace) *app.TenantRelationships {
+	related := rest.AbsoluteURL(req, app.TenantHref()+"/namespaces")
+	data := []*app.GenericData{}
 	for _, ns := range namespaces {
-		tenantType := string(ns.Type)
-		response.Attributes.Namespaces = append(
-			response.Attributes.Namespaces,
-			&app.NamespaceAttributes{
-				CreatedAt:  &ns.CreatedAt,
-				UpdatedAt:  &ns.UpdatedAt,
-				ClusterURL: &ns.MasterURL,
-				Name:       &ns.Name,
-				Type:       &tenantType,
-				Version:    &ns.Version,
-				State:      &ns.State,
-			})
-	}
-
-	return ctx.OK(&app.TenantSingle{Data: &response})
+		namespaceType := "namespaces"
+		namespaceID := ns.ID.String()
+		data = append(data, &app.GenericData{Type: &namespaceType, ID: &namespaceID})
+	}
+	return &app.TenantRelationships{
+		Namespaces: &app.RelationGenericList{
+			Data:  data,
+			Links: &app.GenericLinks{Related: &related},
+		},
+	}
+}
+
+// placementRequest describes the tenant to place on a cluster. The string claims of the token are the user
+// attributes matched against the cluster labels, new tenants start on the default plan.
+func placementRequest(identity *auth.Identity) cluster.Request {
+	attributes := map[string]string{}
+	if claims, ok := identity.Token.Claims.(jwt.MapClaims); ok {
+		for name, value := range claims {
+			if s, ok := value.(string); ok {
+				attributes[name] = s
+			}
+		}
+	}
+	attributes["plan"] = plan.Default
+	return cluster.Request{
+		TenantID:   identity.ID,
+		Username:   identity.Email,
+		Attributes: attributes,
+	}
+}
+
+// maxBaseNameAttempts bounds how often setup allocates another namespace base name after a
+// concurrent setup stored the allocated one first
+const maxBaseNameAttempts = 3
+
+// storeTenant allocates a namespace base name for the tenant and stores it. The allocation is
+// repeated when a concurrent setup stored the same base name in the meantime.
+func (c *TenantController) storeTenant(oc openshift.Config, t *tenant.Tenant) error {
+	var err error
+	for attempt := 0; attempt < maxBaseNameAttempts; attempt++ {
+		t.NsBaseName, err = openshift.AllocateBaseName(t.OSUsername, c.isBaseNameTaken(oc))
+		if err != nil {
+			return err
+		}
+		err = c.tenantService.UpdateTenant(t)
+		if err != tenant.ErrBaseNameTaken {
+			return err
+		}
+	}
+	return err
+}
+
+// isBaseNameTaken checks if the namespace base name is used by another tenant or any namespace on the given cluster
+func (c *TenantController) isBaseNameTaken(oc openshift.Config) func(name string) (bool, error) {
+	return func(name string) (bool, error) {
+		used, err := c.tenantService.IsBaseNameUsed(name)
+		if err != nil || used {
+			return used, err
+		}
+		return openshift.NamespacesExist(oc, name)
+	}
+}
+
+// OpenShiftUsername returns the OpenShift user owning the tenant namespaces. Tenants created before
+// the username was recorded fall back to the email, which is the OpenShift Online username.
+func OpenShiftUsername(t *tenant.Tenant) string {
+	if t.OSUsername != "" {
+		return t.OSUsername
+	}
+	return t.Email
+}
+
+// actingAs returns the OpenShift user the user scoped objects of the tenant are applied as when
+// using the master service token of the cluster
+func actingAs(oc openshift.Config, t *tenant.Tenant) string {
+	if oc.Impersonate {
+		return OpenShiftUsername(t)
+	}
+	return oc.MasterUser
+}
+
+// TemplateVars returns the variables used to process the templates of the tenant, the
+// given base variables extended with the variables of the tenant plan
+func TemplateVars(t *tenant.Tenant, base map[string]string) map[string]string {
+	p, err := plan.Get(t.Plan)
+	if err != nil {
+		log.Error(nil, map[string]interface{}{
+			"err":       err,
+			"tenant_id": t.ID,
+			"plan":      t.Plan,
+		}, "unknown plan, using default")
+		p, _ = plan.Get(plan.Default)
+	}
+	return p.TemplateVars(base)
 }
 
 // InitTenant is a Callback that assumes a new tenant is being created
@@ -215,14 +631,32 @@ func InitTenant(ctx context.Context, masterURL string, service tenant.Service, c
 		} else if statusCode == http.StatusCreated {
 			if openshift.GetKind(request) == openshift.ValKindProjectRequest {
 				name := openshift.GetName(request)
-				service.UpdateNamespace(&tenant.Namespace{
+				ns := &tenant.Namespace{
 					TenantID:  currentTenant.ID,
 					Name:      name,
 					State:     "created",
 					Version:   openshift.GetLabelVersion(request),
 					Type:      GetNamespaceType(name),
 					MasterURL: masterURL,
-				})
+				}
+				// re-created namespaces, e.g. after a reset, keep their existing record. They count as
+				// active, the idler would otherwise go by the creation time of the old namespace.
+				if existing, err := service.GetNamespaces(currentTenant.ID); err == nil {
+					for _, e := range existing {
+						if e.Name == name {
+							now := time.Now()
+							ns.ID = e.ID
+							ns.CreatedAt = e.CreatedAt
+							ns.LastActivityAt = &now
+						}
+					}
+				}
+				if err := service.UpdateNamespace(ns, namespaceReadyEvent(ns)); err != nil {
+					log.Error(ctx, map[string]interface{}{
+						"err":       err,
+						"namespace": name,
+					}, "unable to store namespace")
+				}
 			}
 			return "", nil
 		} else if statusCode == http.StatusOK {
@@ -263,25 +697,3 @@ func GetNamespaceType(name string) tenant.NamespaceType {
 	}
 	return tenant.TypeUser
 }
-
-type TenantToken struct {
-	token *jwt.Token
-}
-
-func (t TenantToken) Subject() uuid.UUID {
-	if claims, ok := t.token.Claims.(jwt.MapClaims); ok {
-		id, err := uuid.FromString(claims["sub"].(string))
-		if err != nil {
-			return uuid.UUID{}
-		}
-		return id
-	}
-	return uuid.UUID{}
-}
-
-func (t TenantToken) Email() string {
-	if claims, ok := t.token.Claims.(jwt.MapClaims); ok {
-		return claims["email"].(string)
-	}
-	return ""
-}
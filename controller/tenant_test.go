@@ -0,0 +1,180 @@
+package controller
+
+import (
+	"context"
+	"crypto/rsa"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/almighty/almighty-core/resource"
+	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/fabric8io/fabric8-init-tenant/app"
+	"github.com/fabric8io/fabric8-init-tenant/auth"
+	"github.com/fabric8io/fabric8-init-tenant/cluster"
+	"github.com/fabric8io/fabric8-init-tenant/idp"
+	"github.com/fabric8io/fabric8-init-tenant/openshift"
+	"github.com/fabric8io/fabric8-init-tenant/tenant"
+	"github.com/goadesign/goa"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvertNamespace(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+
+	req, err := http.NewRequest("GET", "http://tenant.example.com/api/tenant/namespaces", nil)
+	require.NoError(t, err)
+	ns := &tenant.Namespace{
+		ID:       uuid.NewV4(),
+		TenantID: uuid.NewV4(),
+		Name:     "aslak-jenkins",
+		Type:     tenant.TypeJenkins,
+	}
+
+	converted := convertNamespace(&goa.RequestData{Request: req}, ns)
+	assert.Equal(t, "namespaces", converted.Type)
+	assert.Equal(t, ns.ID.String(), converted.ID)
+	assert.Equal(t, "aslak-jenkins", *converted.Attributes.Name)
+	assert.Equal(t, "http://tenant.example.com/api/tenant/namespaces/"+ns.ID.String(), *converted.Links.Self)
+	assert.Equal(t, ns.TenantID.String(), *converted.Relationships.Tenant.Data.ID)
+	assert.Equal(t, "http://tenant.example.com/api/tenant", *converted.Relationships.Tenant.Links.Related)
+}
+
+// racingTenants stores tenants like a database where concurrent setups store the base names
+// listed in racing just before this setup does
+type racingTenants struct {
+	tenant.NilService
+	used   map[string]bool
+	racing map[string]bool
+}
+
+func (s *racingTenants) IsBaseNameUsed(name string) (bool, error) {
+	return s.used[name], nil
+}
+
+func (s *racingTenants) UpdateTenant(t *tenant.Tenant) error {
+	if s.racing[t.NsBaseName] {
+		s.used[t.NsBaseName] = true
+		return tenant.ErrBaseNameTaken
+	}
+	s.used[t.NsBaseName] = true
+	return nil
+}
+
+func TestStoreTenant(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+
+	cluster := httptest.NewServer(http.NotFoundHandler())
+	defer cluster.Close()
+	oc := openshift.Config{MasterURL: cluster.URL}
+
+	t.Run("allocates again after losing a race", func(t *testing.T) {
+		service := &racingTenants{used: map[string]bool{}, racing: map[string]bool{"aslak": true}}
+		c := &TenantController{tenantService: service}
+		stored := &tenant.Tenant{ID: uuid.NewV4(), OSUsername: "aslak"}
+
+		require.NoError(t, c.storeTenant(oc, stored))
+		assert.Equal(t, "aslak2", stored.NsBaseName)
+	})
+
+	t.Run("gives up after losing repeatedly", func(t *testing.T) {
+		service := &racingTenants{used: map[string]bool{}, racing: map[string]bool{"aslak": true, "aslak2": true, "aslak3": true}}
+		c := &TenantController{tenantService: service}
+
+		err := c.storeTenant(oc, &tenant.Tenant{ID: uuid.NewV4(), OSUsername: "aslak"})
+		assert.Equal(t, tenant.ErrBaseNameTaken, err)
+	})
+}
+
+// linkedAccounts is an identity provider holding the same OpenShift token for every user
+type linkedAccounts struct{}
+
+func (linkedAccounts) PublicKeys() (map[string]*rsa.PublicKey, error) {
+	return nil, nil
+}
+
+func (linkedAccounts) BrokerToken(token string) (*idp.BrokerToken, error) {
+	return &idp.BrokerToken{AccessToken: "aslak"}, nil
+}
+
+func (linkedAccounts) RefreshBrokerToken(refreshToken string) (*idp.BrokerToken, error) {
+	return nil, fmt.Errorf("no refresh token")
+}
+
+// oneCluster registers a single cluster
+type oneCluster struct {
+	cluster.NilService
+	cluster *cluster.Cluster
+}
+
+func (s *oneCluster) GetClusters() ([]*cluster.Cluster, error) {
+	return []*cluster.Cluster{s.cluster}, nil
+}
+
+func (s *oneCluster) GetCluster(apiURL string) (*cluster.Cluster, error) {
+	if apiURL == s.cluster.APIURL {
+		return s.cluster, nil
+	}
+	return nil, nil
+}
+
+func TestSetupBaseNameTaken(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+
+	// the cluster knows every token as the user named after it and has no projects
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/oapi/v1/users/~" {
+			fmt.Fprintf(w, "metadata:\n  name: %v\n", r.Header.Get("Authorization")[len("Bearer "):])
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	clusters := cluster.NewRegistry(&oneCluster{cluster: &cluster.Cluster{APIURL: server.URL, Token: "master"}}, openshift.Config{MasterURL: server.URL}, nil)
+	service := &racingTenants{used: map[string]bool{}, racing: map[string]bool{"aslak": true, "aslak2": true, "aslak3": true}}
+	c := &TenantController{
+		tenantService: service,
+		tokens:        idp.NewTokenCache(linkedAccounts{}, 0),
+		clusters:      clusters,
+	}
+
+	identity := &auth.Identity{ID: uuid.NewV4(), Email: "aslak@redhat.com", TokenID: "1", Token: &jwt.Token{Raw: "t0k3n", Claims: jwt.MapClaims{}}}
+	req, err := http.NewRequest("POST", "http://tenant.example.com/api/tenant", nil)
+	require.NoError(t, err)
+	rw := httptest.NewRecorder()
+	ctx, err := app.NewSetupTenantContext(goa.NewContext(auth.WithIdentity(context.Background(), identity), rw, req, url.Values{}), req, goa.New("test"))
+	require.NoError(t, err)
+
+	require.NoError(t, c.Setup(ctx))
+	assert.Equal(t, http.StatusConflict, rw.Code)
+	assert.Contains(t, rw.Body.String(), "already taken")
+}
+
+func TestInitTenantKeepsRecreatedNamespaceActive(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+
+	createdAt := time.Now().Add(-30 * 24 * time.Hour)
+	existing := &tenant.Namespace{ID: uuid.NewV4(), Name: "aslak-jenkins", Type: tenant.TypeJenkins, CreatedAt: createdAt}
+	service := &namespaceEvents{existing: []*tenant.Namespace{existing}}
+	callback := InitTenant(context.Background(), "https://api.cluster1", service, &tenant.Tenant{ID: uuid.NewV4()})
+
+	callback(http.StatusCreated, "POST", map[interface{}]interface{}{
+		openshift.FieldKind: openshift.ValKindProjectRequest,
+		openshift.FieldMetadata: map[interface{}]interface{}{
+			openshift.FieldName: "aslak-jenkins",
+		},
+	}, nil)
+
+	require.Len(t, service.stored, 1)
+	recreated := service.stored[0]
+	assert.Equal(t, existing.ID, recreated.ID)
+	assert.Equal(t, createdAt, recreated.CreatedAt)
+	require.NotNil(t, recreated.LastActivityAt)
+	assert.WithinDuration(t, time.Now(), *recreated.LastActivityAt, time.Minute)
+}
@@ -0,0 +1,440 @@
+package controller
+
+import (
+	"context"
+	"strings"
+
+	"github.com/almighty/almighty-core/errors"
+	"github.com/almighty/almighty-core/log"
+	"github.com/almighty/almighty-core/rest"
+	"github.com/fabric8io/fabric8-init-tenant/app"
+	"github.com/fabric8io/fabric8-init-tenant/audit"
+	"github.com/fabric8io/fabric8-init-tenant/cluster"
+	"github.com/fabric8io/fabric8-init-tenant/jsonapi"
+	"github.com/fabric8io/fabric8-init-tenant/openshift"
+	"github.com/fabric8io/fabric8-init-tenant/plan"
+	"github.com/fabric8io/fabric8-init-tenant/progress"
+	"github.com/fabric8io/fabric8-init-tenant/relocate"
+	"github.com/fabric8io/fabric8-init-tenant/tenant"
+	"github.com/fabric8io/fabric8-init-tenant/webhook"
+	"github.com/goadesign/goa"
+	uuid "github.com/satori/go.uuid"
+)
+
+// TenantsController implements the tenants resource, the administrative operations on all tenants.
+type TenantsController struct {
+	*goa.Controller
+	tenantService tenant.Service
+	clusters      *cluster.Registry
+	templateVars  map[string]string
+	migrator      *relocate.Migrator
+	isAdmin       AdminChecker
+	auditor       audit.Service
+	progress      *progress.Broker
+}
+
+// NewTenantsController creates a tenants controller.
+func NewTenantsController(service *goa.Service, tenantService tenant.Service, clusters *cluster.Registry, templateVars map[string]string, migrator *relocate.Migrator, isAdmin AdminChecker, auditor audit.Service, progress *progress.Broker) *TenantsController {
+	return &TenantsController{
+		Controller:    service.NewController("TenantsController"),
+		tenantService: tenantService,
+		clusters:      clusters,
+		templateVars:  templateVars,
+		migrator:      migrator,
+		isAdmin:       isAdmin,
+		auditor:       auditor,
+		progress:      progress,
+	}
+}
+
+// List runs the list action.
+func (c *TenantsController) List(ctx *app.ListTenantsContext) error {
+	if err := requireAdmin(ctx, c.isAdmin); err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	filter := tenant.TenantFilter{
+		CreatedAfter:  ctx.FilterCreatedAfter,
+		CreatedBefore: ctx.FilterCreatedBefore,
+	}
+	if ctx.FilterEmail != nil {
+		filter.Email = *ctx.FilterEmail
+	}
+	if ctx.FilterNamespaceState != nil {
+		filter.NamespaceState = *ctx.FilterNamespaceState
+	}
+	if ctx.FilterNamespaceVersion != nil {
+		filter.NamespaceVersion = *ctx.FilterNamespaceVersion
+	}
+	if ctx.FilterClusterURL != nil {
+		filter.MasterURL = *ctx.FilterClusterURL
+	}
+	page := tenantPage(ctx.Sort, ctx.PageAfter, ctx.PageLimit)
+
+	tenants, err := c.tenantService.ListTenants(filter, page)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	total, err := c.tenantService.CountTenants(filter)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	var tenantIDs []uuid.UUID
+	for _, t := range tenants {
+		tenantIDs = append(tenantIDs, t.ID)
+	}
+	namespaces, err := c.tenantService.FindNamespaces(tenantIDs)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	tenantNamespaces := map[uuid.UUID][]*tenant.Namespace{}
+	for _, ns := range namespaces {
+		tenantNamespaces[ns.TenantID] = append(tenantNamespaces[ns.TenantID], ns)
+	}
+
+	first := pageLink(ctx.RequestData, nil)
+	response := &app.TenantList{
+		Data:  []*app.Tenant{},
+		Links: &app.PagingLinks{First: &first},
+		Meta:  &app.TenantListMeta{TotalCount: total},
+	}
+	for _, t := range tenants {
+		response.Data = append(response.Data, convertTenantData(t, tenantNamespaces[t.ID]))
+	}
+	if len(tenants) == page.Limit {
+		next := pageLink(ctx.RequestData, &tenants[len(tenants)-1].ID)
+		response.Links.Next = &next
+	}
+	return ctx.OK(response)
+}
+
+// tenantPage returns the page selected by the JSON:API sort and page parameters
+func tenantPage(sort string, after *uuid.UUID, limit int) tenant.Page {
+	page := tenant.Page{
+		SortBy: tenant.SortByCreatedAt,
+		After:  after,
+		Limit:  limit,
+	}
+	if strings.HasPrefix(sort, "-") {
+		page.Descending = true
+		sort = sort[1:]
+	}
+	if sort == "email" {
+		page.SortBy = tenant.SortByEmail
+	}
+	return page
+}
+
+// pageLink returns the URL of the request listing the page starting after the given tenant,
+// or the first page if no tenant is given
+func pageLink(req *goa.RequestData, after *uuid.UUID) string {
+	query := req.URL.Query()
+	query.Del("page[after]")
+	if after != nil {
+		query.Set("page[after]", after.String())
+	}
+	return rest.AbsoluteURL(req, req.URL.Path+"?"+query.Encode())
+}
+
+// Show runs the show action.
+func (c *TenantsController) Show(ctx *app.ShowTenantsContext) error {
+	if err := requireAdmin(ctx, c.isAdmin); err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	currentTenant, err := c.tenantService.GetTenant(ctx.TenantID)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, errors.NewNotFoundError("tenants", ctx.TenantID.String()))
+	}
+	namespaces, err := c.tenantService.GetNamespaces(ctx.TenantID)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	record := audit.NewRecord(ctx, "show", ctx.TenantID)
+	record.Outcome = audit.OutcomeSucceeded
+	recordAudit(ctx, c.auditor, record)
+	return ctx.OK(convertTenant(currentTenant, namespaces))
+}
+
+// Update runs the update action.
+func (c *TenantsController) Update(ctx *app.UpdateTenantsContext) error {
+	if err := requireAdmin(ctx, c.isAdmin); err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	currentTenant, err := c.tenantService.GetTenant(ctx.TenantID)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, errors.NewNotFoundError("tenants", ctx.TenantID.String()))
+	}
+	oc, err := c.clusters.Config(currentTenant.MasterURL)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	record := audit.NewRecord(ctx, "update", ctx.TenantID)
+	record.ActingAs = actingAs(oc, currentTenant)
+	record.TargetVersion = oc.TeamVersion
+	recordAudit(ctx, c.auditor, record)
+
+	go func() {
+		ctx := ctx
+		t := currentTenant
+		err := openshift.InitTenant(
+			oc,
+			ReportProgress(c.progress, t.ID, InitTenant(ctx, oc.MasterURL, c.tenantService, t)),
+			OpenShiftUsername(t),
+			t.NsBaseName,
+			openshift.StaticToken(oc.Token),
+			TemplateVars(t, c.templateVars))
+
+		if err != nil {
+			log.Error(ctx, map[string]interface{}{
+				"err":       err,
+				"tenant_id": t.ID,
+			}, "unable to update tenant")
+		}
+		reportProvisioned(c.progress, t.ID, err)
+		recordAudit(ctx, c.auditor, record.Completed(err), completedEvent(webhook.EventTenantUpdated, t.ID, record.Operation, err))
+	}()
+
+	ctx.ResponseData.Header().Set("Location", rest.AbsoluteURL(ctx.RequestData, app.TenantsHref(ctx.TenantID)))
+	return ctx.Accepted()
+}
+
+// Reset runs the reset action.
+func (c *TenantsController) Reset(ctx *app.ResetTenantsContext) error {
+	if err := requireAdmin(ctx, c.isAdmin); err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	currentTenant, err := c.tenantService.GetTenant(ctx.TenantID)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, errors.NewNotFoundError("tenants", ctx.TenantID.String()))
+	}
+	oc, err := c.clusters.Config(currentTenant.MasterURL)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	response, err := resetNamespace(ctx, c.tenantService, c.auditor, c.progress, oc, currentTenant, ctx.Type, ctx.PreservePvcs, c.templateVars)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	ctx.ResponseData.Header().Set("Location", rest.AbsoluteURL(ctx.RequestData, app.JobHref(*response.Data.ID)))
+	return ctx.Accepted(response)
+}
+
+// Delete runs the delete action.
+func (c *TenantsController) Delete(ctx *app.DeleteTenantsContext) error {
+	if err := requireAdmin(ctx, c.isAdmin); err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	currentTenant, err := c.tenantService.GetTenant(ctx.TenantID)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, errors.NewNotFoundError("tenants", ctx.TenantID.String()))
+	}
+	namespaces, err := c.tenantService.GetNamespaces(ctx.TenantID)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	oc, err := c.clusters.Config(currentTenant.MasterURL)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+
+	job := &tenant.Job{
+		TenantID: currentTenant.ID,
+		Type:     tenant.JobTypeDelete,
+		Target:   currentTenant.NsBaseName,
+		State:    tenant.JobStateRunning,
+	}
+	err = c.tenantService.UpdateJob(job)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	response := convertJob(job)
+	record := audit.NewRecord(ctx, "delete", ctx.TenantID)
+	record.ActingAs = oc.MasterUser
+	record.Details = audit.Details{"job_id": job.ID.String()}
+	recordAudit(ctx, c.auditor, record)
+
+	go func() {
+		ctx := ctx
+		var names []string
+		for _, ns := range namespaces {
+			names = append(names, ns.Name)
+		}
+		err := openshift.DeleteNamespaces(oc, names)
+		if err == nil {
+			err = c.tenantService.DeleteTenant(currentTenant.ID, completedEvent(webhook.EventTenantDeleted, currentTenant.ID, record.Operation, nil))
+		}
+		if err != nil {
+			log.Error(ctx, map[string]interface{}{
+				"err":       err,
+				"tenant_id": currentTenant.ID,
+			}, "unable to delete tenant")
+		}
+		recordAudit(ctx, c.auditor, record.Completed(err))
+		job.Complete(err)
+		// the deleted event is published along with the tenant deletion, only a failure is left to report
+		var events []webhook.Event
+		if err != nil {
+			events = append(events, completedEvent(webhook.EventTenantDeleted, currentTenant.ID, record.Operation, err))
+		}
+		if err := c.tenantService.UpdateJob(job, events...); err != nil {
+			log.Error(ctx, map[string]interface{}{
+				"err":    err,
+				"job_id": job.ID,
+			}, "unable to record job state")
+		}
+		reportJob(c.progress, job)
+	}()
+
+	ctx.ResponseData.Header().Set("Location", rest.AbsoluteURL(ctx.RequestData, app.JobHref(*response.Data.ID)))
+	return ctx.Accepted(response)
+}
+
+// UpdatePlan runs the update-plan action.
+func (c *TenantsController) UpdatePlan(ctx *app.UpdatePlanTenantsContext) error {
+	if err := requireAdmin(ctx, c.isAdmin); err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	attrs := ctx.Payload.Data.Attributes
+	if attrs == nil || attrs.Plan == nil {
+		return jsonapi.JSONErrorResponse(ctx, errors.NewBadParameterError("plan", nil))
+	}
+	newPlan, err := plan.Get(*attrs.Plan)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, errors.NewBadParameterError("plan", *attrs.Plan))
+	}
+	currentTenant, err := c.tenantService.GetTenant(ctx.TenantID)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, errors.NewNotFoundError("tenants", ctx.TenantID.String()))
+	}
+	oc, err := c.clusters.Config(currentTenant.MasterURL)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+
+	oldVars := TemplateVars(currentTenant, c.templateVars)
+	currentTenant.Plan = newPlan.Name
+	err = c.tenantService.UpdateTenant(currentTenant)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	newVars := TemplateVars(currentTenant, c.templateVars)
+
+	job := &tenant.Job{
+		TenantID: currentTenant.ID,
+		Type:     tenant.JobTypePlanChange,
+		Target:   newPlan.Name,
+		State:    tenant.JobStateRunning,
+	}
+	err = c.tenantService.UpdateJob(job)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	response := convertJob(job)
+	record := audit.NewRecord(ctx, "update-plan", ctx.TenantID)
+	record.ActingAs = actingAs(oc, currentTenant)
+	record.TargetVersion = oc.TeamVersion
+	record.Details = audit.Details{
+		"plan":   newPlan.Name,
+		"job_id": job.ID.String(),
+	}
+	recordAudit(ctx, c.auditor, record)
+
+	go func() {
+		ctx := ctx
+		t := currentTenant
+		err := openshift.ApplyChanged(
+			oc,
+			ReportProgress(c.progress, t.ID, InitTenant(ctx, oc.MasterURL, c.tenantService, t)),
+			OpenShiftUsername(t),
+			t.NsBaseName,
+			oldVars,
+			newVars)
+
+		if err != nil {
+			log.Error(ctx, map[string]interface{}{
+				"err":       err,
+				"tenant_id": t.ID,
+				"plan":      t.Plan,
+			}, "unable to apply plan change")
+		}
+		recordAudit(ctx, c.auditor, record.Completed(err))
+		job.Complete(err)
+		if err := c.tenantService.UpdateJob(job, completedEvent(webhook.EventTenantUpdated, t.ID, record.Operation, err)); err != nil {
+			log.Error(ctx, map[string]interface{}{
+				"err":    err,
+				"job_id": job.ID,
+			}, "unable to record job state")
+		}
+		reportJob(c.progress, job)
+	}()
+
+	ctx.ResponseData.Header().Set("Location", rest.AbsoluteURL(ctx.RequestData, app.JobHref(*response.Data.ID)))
+	return ctx.Accepted(response)
+}
+
+// Migrate runs the migrate action.
+func (c *TenantsController) Migrate(ctx *app.MigrateTenantsContext) error {
+	if err := requireAdmin(ctx, c.isAdmin); err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	attrs := ctx.Payload.Data.Attributes
+	if attrs == nil || attrs.ClusterURL == nil {
+		return jsonapi.JSONErrorResponse(ctx, errors.NewBadParameterError("cluster-url", nil))
+	}
+	currentTenant, err := c.tenantService.GetTenant(ctx.TenantID)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, errors.NewNotFoundError("tenants", ctx.TenantID.String()))
+	}
+
+	job, err := c.migrator.Start(detach(ctx), currentTenant, *attrs.ClusterURL)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	response := convertJob(&job)
+	record := audit.NewRecord(ctx, "migrate", ctx.TenantID)
+	record.Details = audit.Details{
+		"cluster_url": job.Target,
+		"job_id":      job.ID.String(),
+	}
+	recordAudit(ctx, c.auditor, record)
+	ctx.ResponseData.Header().Set("Location", rest.AbsoluteURL(ctx.RequestData, app.JobHref(*response.Data.ID)))
+	return ctx.Accepted(response)
+}
+
+// UpdatePlacement runs the update-placement action.
+func (c *TenantsController) UpdatePlacement(ctx *app.UpdatePlacementTenantsContext) error {
+	if err := requireAdmin(ctx, c.isAdmin); err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	attrs := ctx.Payload.Data.Attributes
+	if attrs == nil || attrs.ClusterURL == nil {
+		return jsonapi.JSONErrorResponse(ctx, errors.NewBadParameterError("cluster-url", nil))
+	}
+	if c.tenantService.Exists(ctx.TenantID) {
+		return jsonapi.JSONErrorResponse(ctx, errors.NewVersionConflictError("tenant is already placed on a cluster"))
+	}
+	err := c.clusters.Override(ctx.TenantID, *attrs.ClusterURL)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	record := audit.NewRecord(ctx, "update-placement", ctx.TenantID)
+	record.Outcome = audit.OutcomeSucceeded
+	record.Details = audit.Details{"cluster_url": *attrs.ClusterURL}
+	recordAudit(ctx, c.auditor, record)
+	return ctx.NoContent()
+}
+
+// ProvisionTenant returns a Provisioner that applies the tenant templates in the given version using
+// the master service token of the cluster. The namespaces are not recorded, the tenant is switched to
+// the cluster once the migration completes.
+func ProvisionTenant(templateVars map[string]string) relocate.Provisioner {
+	return func(ctx context.Context, oc openshift.Config, t *tenant.Tenant, version string) error {
+		oc.TeamVersion = version
+		return openshift.InitTenant(
+			oc,
+			InitTenant(ctx, oc.MasterURL, tenant.NilService{}, t),
+			OpenShiftUsername(t),
+			t.NsBaseName,
+			openshift.StaticToken(oc.Token),
+			TemplateVars(t, templateVars))
+	}
+}
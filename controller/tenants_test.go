@@ -0,0 +1,20 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/almighty/almighty-core/resource"
+	"github.com/fabric8io/fabric8-init-tenant/tenant"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTenantPage(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+
+	after := uuid.NewV4()
+	assert.Equal(t, tenant.Page{SortBy: tenant.SortByCreatedAt, Limit: 100}, tenantPage("created-at", nil, 100))
+	assert.Equal(t, tenant.Page{SortBy: tenant.SortByCreatedAt, Descending: true, Limit: 10}, tenantPage("-created-at", nil, 10))
+	assert.Equal(t, tenant.Page{SortBy: tenant.SortByEmail, After: &after, Limit: 10}, tenantPage("email", &after, 10))
+	assert.Equal(t, tenant.Page{SortBy: tenant.SortByEmail, Descending: true, Limit: 10}, tenantPage("-email", nil, 10))
+}
@@ -0,0 +1,225 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/almighty/almighty-core/errors"
+	"github.com/almighty/almighty-core/log"
+	"github.com/almighty/almighty-core/rest"
+	"github.com/fabric8io/fabric8-init-tenant/app"
+	"github.com/fabric8io/fabric8-init-tenant/audit"
+	"github.com/fabric8io/fabric8-init-tenant/cluster"
+	"github.com/fabric8io/fabric8-init-tenant/jsonapi"
+	"github.com/fabric8io/fabric8-init-tenant/openshift"
+	"github.com/fabric8io/fabric8-init-tenant/tenant"
+	"github.com/fabric8io/fabric8-init-tenant/upgrade"
+	"github.com/fabric8io/fabric8-init-tenant/webhook"
+	"github.com/goadesign/goa"
+)
+
+// UpgradeController implements the upgrade resource.
+type UpgradeController struct {
+	*goa.Controller
+	orchestrator *upgrade.Orchestrator
+	isAdmin      AdminChecker
+}
+
+// NewUpgradeController creates a upgrade controller.
+func NewUpgradeController(service *goa.Service, orchestrator *upgrade.Orchestrator, isAdmin AdminChecker) *UpgradeController {
+	return &UpgradeController{
+		Controller:   service.NewController("UpgradeController"),
+		orchestrator: orchestrator,
+		isAdmin:      isAdmin,
+	}
+}
+
+// Create runs the create action.
+func (c *UpgradeController) Create(ctx *app.CreateUpgradeContext) error {
+	if err := requireAdmin(ctx, c.isAdmin); err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	attrs := ctx.Payload.Data.Attributes
+	if attrs == nil || attrs.TargetVersion == nil || *attrs.TargetVersion == "" {
+		return jsonapi.JSONErrorResponse(ctx, errors.NewBadParameterError("target-version", nil))
+	}
+	opts := upgrade.Options{
+		TargetVersion: *attrs.TargetVersion,
+	}
+	if attrs.FromVersion != nil {
+		opts.FromVersion = *attrs.FromVersion
+	}
+	if attrs.ClusterURL != nil {
+		opts.MasterURL = *attrs.ClusterURL
+	}
+	if attrs.BatchSize != nil {
+		opts.BatchSize = *attrs.BatchSize
+	}
+	if attrs.MaxConcurrency != nil {
+		opts.MaxConcurrency = *attrs.MaxConcurrency
+	}
+	if attrs.CanaryPercentage != nil {
+		opts.CanaryPercentage = *attrs.CanaryPercentage
+	}
+	if attrs.FailureThreshold != nil {
+		opts.FailureThreshold = *attrs.FailureThreshold
+	}
+
+	rollout, err := c.orchestrator.Start(detach(ctx), opts)
+	if err == upgrade.ErrRolloutInProgress {
+		return ctx.Conflict()
+	}
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+
+	ctx.ResponseData.Header().Set("Location", rest.AbsoluteURL(ctx.RequestData, app.UpgradeHref(rollout.ID())))
+	return ctx.Accepted(convertUpgrade(rollout.Progress()))
+}
+
+// Show runs the show action.
+func (c *UpgradeController) Show(ctx *app.ShowUpgradeContext) error {
+	if err := requireAdmin(ctx, c.isAdmin); err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	rollout, found := c.orchestrator.Get(ctx.ID)
+	if !found {
+		return jsonapi.JSONErrorResponse(ctx, errors.NewNotFoundError("upgrades", ctx.ID.String()))
+	}
+	return ctx.OK(convertUpgrade(rollout.Progress()))
+}
+
+// Pause runs the pause action.
+func (c *UpgradeController) Pause(ctx *app.PauseUpgradeContext) error {
+	if err := requireAdmin(ctx, c.isAdmin); err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	rollout, found := c.orchestrator.Get(ctx.ID)
+	if !found {
+		return jsonapi.JSONErrorResponse(ctx, errors.NewNotFoundError("upgrades", ctx.ID.String()))
+	}
+	if err := rollout.Pause(); err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	return ctx.OK(convertUpgrade(rollout.Progress()))
+}
+
+// Resume runs the resume action.
+func (c *UpgradeController) Resume(ctx *app.ResumeUpgradeContext) error {
+	if err := requireAdmin(ctx, c.isAdmin); err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	rollout, found := c.orchestrator.Get(ctx.ID)
+	if !found {
+		return jsonapi.JSONErrorResponse(ctx, errors.NewNotFoundError("upgrades", ctx.ID.String()))
+	}
+	if err := rollout.Resume(); err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	return ctx.OK(convertUpgrade(rollout.Progress()))
+}
+
+// Abort runs the abort action.
+func (c *UpgradeController) Abort(ctx *app.AbortUpgradeContext) error {
+	if err := requireAdmin(ctx, c.isAdmin); err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	rollout, found := c.orchestrator.Get(ctx.ID)
+	if !found {
+		return jsonapi.JSONErrorResponse(ctx, errors.NewNotFoundError("upgrades", ctx.ID.String()))
+	}
+	if err := rollout.Abort(); err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	return ctx.OK(convertUpgrade(rollout.Progress()))
+}
+
+func convertUpgrade(p upgrade.Progress) *app.UpgradeSingle {
+	id := p.ID
+	state := string(p.State)
+	startedAt := p.StartedAt
+	targetVersion := p.Options.TargetVersion
+	fromVersion := p.Options.FromVersion
+	clusterURL := p.Options.MasterURL
+	batchSize := p.Options.BatchSize
+	maxConcurrency := p.Options.MaxConcurrency
+	canaryPercentage := p.Options.CanaryPercentage
+	failureThreshold := p.Options.FailureThreshold
+	total := p.Total
+	succeeded := p.Succeeded
+	failed := p.Failed
+	attrs := &app.UpgradeAttributes{
+		TargetVersion:    &targetVersion,
+		FromVersion:      &fromVersion,
+		ClusterURL:       &clusterURL,
+		BatchSize:        &batchSize,
+		MaxConcurrency:   &maxConcurrency,
+		CanaryPercentage: &canaryPercentage,
+		FailureThreshold: &failureThreshold,
+		State:            &state,
+		Total:            &total,
+		Succeeded:        &succeeded,
+		Failed:           &failed,
+		FailedTenants:    p.FailedIDs,
+		StartedAt:        &startedAt,
+		CompletedAt:      p.CompletedAt,
+	}
+	if p.Reason != "" {
+		reason := p.Reason
+		attrs.Reason = &reason
+	}
+	return &app.UpgradeSingle{
+		Data: &app.Upgrade{
+			ID:         &id,
+			Type:       "upgrades",
+			Attributes: attrs,
+		},
+	}
+}
+
+// UpgradeTenant returns an Upgrader that re-applies the tenant templates in the given version
+// using the master service token, and records the new version on the tenant namespaces and the
+// outcome in the audit trail
+func UpgradeTenant(clusters *cluster.Registry, service tenant.Service, templateVars map[string]string, auditor audit.Service) upgrade.Upgrader {
+	upgradeTenant := func(ctx context.Context, t *tenant.Tenant, targetVersion string, record *audit.Record) error {
+		oc, err := clusters.Config(t.MasterURL)
+		if err != nil {
+			return err
+		}
+		oc.TeamVersion = targetVersion
+		record.ActingAs = actingAs(oc, t)
+		err = openshift.InitTenant(
+			oc,
+			InitTenant(ctx, oc.MasterURL, service, t),
+			OpenShiftUsername(t),
+			t.NsBaseName,
+			openshift.StaticToken(oc.Token),
+			TemplateVars(t, templateVars))
+		if err != nil {
+			return err
+		}
+		namespaces, err := service.GetNamespaces(t.ID)
+		if err != nil {
+			return err
+		}
+		for _, ns := range namespaces {
+			ns.Version = targetVersion
+			ns.State = "updated"
+			if err := service.UpdateNamespace(ns); err != nil {
+				return fmt.Errorf("unable to record version of namespace %v: %v", ns.Name, err)
+			}
+		}
+		log.Info(ctx, map[string]interface{}{
+			"tenant_id":      t.ID,
+			"target_version": targetVersion,
+		}, "tenant upgraded")
+		return nil
+	}
+	return func(ctx context.Context, t *tenant.Tenant, targetVersion string) error {
+		record := audit.NewRecord(ctx, "upgrade", t.ID)
+		record.TargetVersion = targetVersion
+		err := upgradeTenant(ctx, t, targetVersion, record)
+		recordAudit(ctx, auditor, record.Completed(err), completedEvent(webhook.EventTenantUpdated, t.ID, record.Operation, err))
+		return err
+	}
+}
@@ -0,0 +1,204 @@
+package controller
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/almighty/almighty-core/errors"
+	"github.com/almighty/almighty-core/rest"
+	"github.com/fabric8io/fabric8-init-tenant/app"
+	"github.com/fabric8io/fabric8-init-tenant/jsonapi"
+	"github.com/fabric8io/fabric8-init-tenant/tenant"
+	"github.com/fabric8io/fabric8-init-tenant/webhook"
+	"github.com/goadesign/goa"
+	uuid "github.com/satori/go.uuid"
+)
+
+// WebhooksController implements the webhooks resource.
+type WebhooksController struct {
+	*goa.Controller
+	webhooks webhook.Service
+	isAdmin  AdminChecker
+}
+
+// NewWebhooksController creates a webhooks controller.
+func NewWebhooksController(service *goa.Service, webhooks webhook.Service, isAdmin AdminChecker) *WebhooksController {
+	return &WebhooksController{
+		Controller: service.NewController("WebhooksController"),
+		webhooks:   webhooks,
+		isAdmin:    isAdmin,
+	}
+}
+
+// Create runs the create action.
+func (c *WebhooksController) Create(ctx *app.CreateWebhooksContext) error {
+	if err := requireAdmin(ctx, c.isAdmin); err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	attrs := ctx.Payload.Data.Attributes
+	if attrs == nil || attrs.URL == nil || !validWebhookURL(*attrs.URL) {
+		return jsonapi.JSONErrorResponse(ctx, errors.NewBadParameterError("url", nil))
+	}
+	if attrs.Secret == nil || *attrs.Secret == "" {
+		return jsonapi.JSONErrorResponse(ctx, errors.NewBadParameterError("secret", nil))
+	}
+	if len(attrs.EventTypes) == 0 {
+		return jsonapi.JSONErrorResponse(ctx, errors.NewBadParameterError("event-types", nil))
+	}
+	for _, t := range attrs.EventTypes {
+		if !webhook.Types(webhook.EventTypes).Contains(t) {
+			return jsonapi.JSONErrorResponse(ctx, errors.NewBadParameterError("event-types", t))
+		}
+	}
+
+	sub := &webhook.Subscription{
+		ID:         uuid.NewV4(),
+		URL:        *attrs.URL,
+		Secret:     *attrs.Secret,
+		EventTypes: webhook.Types(attrs.EventTypes),
+	}
+	if err := c.webhooks.CreateSubscription(sub); err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	ctx.ResponseData.Header().Set("Location", rest.AbsoluteURL(ctx.RequestData, fmt.Sprintf("%v/%v/deliveries", app.WebhooksHref(), sub.ID)))
+	return ctx.Created(&app.WebhookSingle{Data: convertWebhook(sub)})
+}
+
+// List runs the list action.
+func (c *WebhooksController) List(ctx *app.ListWebhooksContext) error {
+	if err := requireAdmin(ctx, c.isAdmin); err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	subs, err := c.webhooks.GetSubscriptions()
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	response := &app.WebhookList{Data: []*app.Webhook{}}
+	for _, sub := range subs {
+		response.Data = append(response.Data, convertWebhook(sub))
+	}
+	return ctx.OK(response)
+}
+
+// Delete runs the delete action.
+func (c *WebhooksController) Delete(ctx *app.DeleteWebhooksContext) error {
+	if err := requireAdmin(ctx, c.isAdmin); err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	sub, err := c.webhooks.GetSubscription(ctx.ID)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	if sub == nil {
+		return jsonapi.JSONErrorResponse(ctx, errors.NewNotFoundError("webhooks", ctx.ID.String()))
+	}
+	if err := c.webhooks.DeleteSubscription(ctx.ID); err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	return ctx.NoContent()
+}
+
+// Deliveries runs the deliveries action.
+func (c *WebhooksController) Deliveries(ctx *app.DeliveriesWebhooksContext) error {
+	if err := requireAdmin(ctx, c.isAdmin); err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	sub, err := c.webhooks.GetSubscription(ctx.ID)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	if sub == nil {
+		return jsonapi.JSONErrorResponse(ctx, errors.NewNotFoundError("webhooks", ctx.ID.String()))
+	}
+	state := ""
+	if ctx.State != nil {
+		state = *ctx.State
+	}
+	deliveries, err := c.webhooks.FindDeliveries(ctx.ID, state, ctx.Limit)
+	if err != nil {
+		return jsonapi.JSONErrorResponse(ctx, err)
+	}
+	response := &app.WebhookDeliveryList{Data: []*app.WebhookDelivery{}}
+	for _, d := range deliveries {
+		response.Data = append(response.Data, convertWebhookDelivery(d))
+	}
+	return ctx.OK(response)
+}
+
+// validWebhookURL only accepts absolute http(s) URLs
+func validWebhookURL(raw string) bool {
+	u, err := url.Parse(raw)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// convertWebhook leaves out the secret, it is only ever sent by the client
+func convertWebhook(sub *webhook.Subscription) *app.Webhook {
+	id := sub.ID
+	subURL := sub.URL
+	createdAt := sub.CreatedAt
+	return &app.Webhook{
+		ID:   &id,
+		Type: "webhooks",
+		Attributes: &app.WebhookAttributes{
+			URL:        &subURL,
+			EventTypes: []string(sub.EventTypes),
+			CreatedAt:  &createdAt,
+		},
+	}
+}
+
+func convertWebhookDelivery(d *webhook.Delivery) *app.WebhookDelivery {
+	id := d.ID
+	eventID := d.EventID
+	eventType := d.EventType
+	tenantID := d.TenantID
+	state := d.State
+	attempts := d.Attempts
+	createdAt := d.CreatedAt
+	attrs := &app.WebhookDeliveryAttributes{
+		EventID:       &eventID,
+		EventType:     &eventType,
+		TenantID:      &tenantID,
+		State:         &state,
+		Attempts:      &attempts,
+		CreatedAt:     &createdAt,
+		LastAttemptAt: d.LastAttemptAt,
+		NextAttemptAt: d.NextAttemptAt,
+	}
+	if d.ResponseStatus != 0 {
+		status := d.ResponseStatus
+		attrs.ResponseStatus = &status
+	}
+	if d.Error != "" {
+		deliveryError := d.Error
+		attrs.Error = &deliveryError
+	}
+	return &app.WebhookDelivery{
+		ID:         &id,
+		Type:       "webhook-deliveries",
+		Attributes: attrs,
+	}
+}
+
+// completedEvent returns the event reporting a completed operation on the tenant, or tenant.failed if
+// the operation failed
+func completedEvent(eventType string, tenantID uuid.UUID, operation string, err error) webhook.Event {
+	data := map[string]string{"operation": operation}
+	if err != nil {
+		eventType = webhook.EventTenantFailed
+		data["error"] = err.Error()
+	}
+	return webhook.Event{Type: eventType, TenantID: tenantID, Data: data}
+}
+
+// namespaceReadyEvent returns the event reporting a created namespace
+func namespaceReadyEvent(ns *tenant.Namespace) webhook.Event {
+	return webhook.Event{
+		Type:     webhook.EventNamespaceReady,
+		TenantID: ns.TenantID,
+		Data: map[string]string{
+			"namespace": ns.Name,
+			"type":      string(ns.Type),
+		},
+	}
+}
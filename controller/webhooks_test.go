@@ -0,0 +1,80 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/almighty/almighty-core/resource"
+	"github.com/fabric8io/fabric8-init-tenant/openshift"
+	"github.com/fabric8io/fabric8-init-tenant/tenant"
+	"github.com/fabric8io/fabric8-init-tenant/webhook"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type namespaceEvents struct {
+	tenant.NilService
+	existing []*tenant.Namespace
+	stored   []*tenant.Namespace
+	events   []webhook.Event
+}
+
+func (s *namespaceEvents) GetNamespaces(tenantID uuid.UUID) ([]*tenant.Namespace, error) {
+	return s.existing, nil
+}
+
+func (s *namespaceEvents) UpdateNamespace(namespace *tenant.Namespace, events ...webhook.Event) error {
+	s.stored = append(s.stored, namespace)
+	s.events = append(s.events, events...)
+	return nil
+}
+
+func TestInitTenantPublishesNamespaceReady(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+
+	service := &namespaceEvents{}
+	current := &tenant.Tenant{ID: uuid.NewV4()}
+	callback := InitTenant(context.Background(), "https://api.cluster1", service, current)
+	object := func(kind, name string) map[interface{}]interface{} {
+		return map[interface{}]interface{}{
+			openshift.FieldKind: kind,
+			openshift.FieldMetadata: map[interface{}]interface{}{
+				openshift.FieldName: name,
+			},
+		}
+	}
+
+	callback(http.StatusCreated, "POST", object(openshift.ValKindProjectRequest, "aslak-jenkins"), nil)
+	callback(http.StatusConflict, "POST", object(openshift.ValKindProjectRequest, "aslak-che"), nil)
+	callback(http.StatusCreated, "POST", object("Route", "jenkins"), nil)
+
+	require.Len(t, service.events, 1)
+	assert.Equal(t, webhook.EventNamespaceReady, service.events[0].Type)
+	assert.Equal(t, current.ID, service.events[0].TenantID)
+	assert.Equal(t, map[string]string{"namespace": "aslak-jenkins", "type": "jenkins"}, service.events[0].Data)
+}
+
+func TestCompletedEvent(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+
+	tenantID := uuid.NewV4()
+	succeeded := completedEvent(webhook.EventTenantCreated, tenantID, "setup", nil)
+	failed := completedEvent(webhook.EventTenantCreated, tenantID, "setup", errors.New("quota exceeded"))
+
+	assert.Equal(t, webhook.EventTenantCreated, succeeded.Type)
+	assert.Equal(t, tenantID, succeeded.TenantID)
+	assert.Equal(t, map[string]string{"operation": "setup"}, succeeded.Data)
+	assert.Equal(t, webhook.EventTenantFailed, failed.Type)
+	assert.Equal(t, map[string]string{"operation": "setup", "error": "quota exceeded"}, failed.Data)
+}
+
+func TestValidWebhookURL(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+
+	assert.True(t, validWebhookURL("https://example.com/hooks"))
+	assert.False(t, validWebhookURL("ftp://example.com/hooks"))
+	assert.False(t, validWebhookURL("/hooks"))
+}
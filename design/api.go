@@ -21,7 +21,8 @@ var _ = a.API("tenant", func() {
 	})
 	a.Origin("/[.*openshift.io|localhost]/", func() {
 		a.Methods("GET", "POST", "PUT", "PATCH", "DELETE")
-		a.Headers("X-Request-Id", "Content-Type", "Authorization")
+		a.Headers("X-Request-Id", "Content-Type", "Authorization", "If-None-Match", "If-Modified-Since")
+		a.Expose("ETag", "Last-Modified")
 		a.MaxAge(600)
 		a.Credentials()
 	})
@@ -0,0 +1,83 @@
+package design
+
+import (
+	d "github.com/goadesign/goa/design"
+	a "github.com/goadesign/goa/design/apidsl"
+)
+
+var auditRecord = a.Type("AuditRecord", func() {
+	a.Description(`JSONAPI for the audit record object. See also http://jsonapi.org/format/#document-resource-object`)
+	a.Attribute("type", d.String, func() {
+		a.Enum("audit-records")
+	})
+	a.Attribute("id", d.UUID, "ID of the audit record", func() {
+		a.Example("40bbdd3d-8b5d-4fd6-ac90-7236b669af04")
+	})
+	a.Attribute("attributes", auditRecordAttributes)
+	a.Required("type", "attributes")
+})
+
+var auditRecordAttributes = a.Type("AuditRecordAttributes", func() {
+	a.Description(`JSONAPI store for all the "attributes" of an audit record. See also see http://jsonapi.org/format/#document-resource-object-attributes`)
+	a.Attribute("actor-id", d.UUID, "The subject of the caller who requested the operation", func() {
+	})
+	a.Attribute("actor-email", d.String, "The email of the caller who requested the operation", func() {
+	})
+	a.Attribute("acting-as", d.String, "The OpenShift user the operation was performed as", func() {
+		a.Example("aslak")
+	})
+	a.Attribute("operation", d.String, "The operation performed on the tenant", func() {
+		a.Example("reset")
+	})
+	a.Attribute("tenant-id", d.UUID, "The tenant the operation was performed on", func() {
+	})
+	a.Attribute("target-version", d.String, "The team version the tenant was provisioned in", func() {
+		a.Example("1.0.92")
+	})
+	a.Attribute("outcome", d.String, "The outcome of the operation", func() {
+		a.Enum("accepted", "succeeded", "failed")
+	})
+	a.Attribute("error", d.String, "The error if the operation failed", func() {
+	})
+	a.Attribute("request-id", d.String, "The ID of the request the operation was part of", func() {
+	})
+	a.Attribute("details", a.HashOf(d.String, d.String), "Operation specific details", func() {
+	})
+	a.Attribute("created-at", d.DateTime, "When the record was created", func() {
+		a.Example("2016-11-29T23:18:14Z")
+	})
+})
+
+var auditRecordList = JSONList(
+	"AuditRecord", "Holds the list of audit records",
+	auditRecord,
+	nil,
+	nil)
+
+var _ = a.Resource("audit", func() {
+	a.BasePath("/audit")
+
+	a.Action("list", func() {
+		a.Security("jwt")
+		a.Routing(
+			a.GET(""),
+		)
+		a.Params(func() {
+			a.Param("tenant", d.UUID, "Only list the records of this tenant")
+			a.Param("actor", d.UUID, "Only list the operations requested by this subject")
+			a.Param("since", d.DateTime, "Only list the records created at or after this time")
+			a.Param("until", d.DateTime, "Only list the records created before this time")
+			a.Param("limit", d.Integer, "The maximum number of records to list", func() {
+				a.Minimum(1)
+				a.Maximum(1000)
+				a.Default(100)
+			})
+		})
+		a.Description("List the audit trail of the tenant operations, the newest first.")
+		a.Response(d.OK, auditRecordList)
+		a.Response(d.BadRequest, JSONAPIErrors)
+		a.Response(d.InternalServerError, JSONAPIErrors)
+		a.Response(d.Unauthorized, JSONAPIErrors)
+		a.Response(d.Forbidden, JSONAPIErrors)
+	})
+})
@@ -0,0 +1,70 @@
+package design
+
+import (
+	d "github.com/goadesign/goa/design"
+	a "github.com/goadesign/goa/design/apidsl"
+)
+
+var job = a.Type("Job", func() {
+	a.Description(`JSONAPI for the job object. See also http://jsonapi.org/format/#document-resource-object`)
+	a.Attribute("type", d.String, func() {
+		a.Enum("jobs")
+	})
+	a.Attribute("id", d.UUID, "ID of the job", func() {
+		a.Example("40bbdd3d-8b5d-4fd6-ac90-7236b669af04")
+	})
+	a.Attribute("attributes", jobAttributes)
+	a.Attribute("links", genericLinks)
+	a.Required("type", "attributes")
+})
+
+var jobAttributes = a.Type("JobAttributes", func() {
+	a.Description(`JSONAPI store for all the "attributes" of a job. See also see http://jsonapi.org/format/#document-resource-object-attributes`)
+	a.Attribute("job-type", d.String, "The operation performed by the job", func() {
+		a.Example("reset")
+	})
+	a.Attribute("target", d.String, "The object the job operates on", func() {
+		a.Example("aslak-jenkins")
+	})
+	a.Attribute("source", d.String, "Where the job moves the target from", func() {
+		a.Example("https://api.starter-us-east-1.openshift.com")
+	})
+	a.Attribute("step", d.String, "The last completed step of a multi-step job", func() {
+		a.Example("copy")
+	})
+	a.Attribute("state", d.String, "The job state", func() {
+		a.Enum("running", "completed", "failed")
+	})
+	a.Attribute("error", d.String, "The error if the job failed", func() {
+	})
+	a.Attribute("created-at", d.DateTime, "When the job was created", func() {
+		a.Example("2016-11-29T23:18:14Z")
+	})
+	a.Attribute("completed-at", d.DateTime, "When the job completed", func() {
+		a.Example("2016-11-29T23:18:14Z")
+	})
+})
+
+var jobSingle = JSONSingle(
+	"job", "Holds a single job",
+	job,
+	nil)
+
+var _ = a.Resource("job", func() {
+	a.BasePath("/jobs")
+
+	a.Action("show", func() {
+		a.Security("jwt")
+		a.Routing(
+			a.GET("/:id"),
+		)
+		a.Params(func() {
+			a.Param("id", d.UUID, "ID of the job")
+		})
+		a.Description("Show the state of a job.")
+		a.Response(d.OK, jobSingle)
+		a.Response(d.NotFound, JSONAPIErrors)
+		a.Response(d.InternalServerError, JSONAPIErrors)
+		a.Response(d.Unauthorized, JSONAPIErrors)
+	})
+})
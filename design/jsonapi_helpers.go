@@ -95,6 +95,12 @@ var genericLinks = a.Type("GenericLinks", func() {
 	a.Attribute("meta", a.HashOf(d.String, d.Any))
 })
 
+// pagingLinks defines the links between the pages of a list
+var pagingLinks = a.Type("PagingLinks", func() {
+	a.Attribute("first", d.String)
+	a.Attribute("next", d.String)
+})
+
 // JSONResourceObject creates a single resource object
 func JSONResourceObject(name string, attributes *d.UserTypeDefinition, relationships *d.UserTypeDefinition) *d.UserTypeDefinition {
 	return a.Type(name, func() {
@@ -108,6 +114,7 @@ func JSONResourceObject(name string, attributes *d.UserTypeDefinition, relations
 		if relationships != nil {
 			a.Attribute("relationships", relationships)
 		}
+		a.Attribute("links", genericLinks)
 		a.Required("type", "id", "attributes")
 	})
 }
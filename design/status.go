@@ -5,6 +5,19 @@ import (
 	a "github.com/goadesign/goa/design/apidsl"
 )
 
+var dependencyStatus = a.Type("DependencyStatus", func() {
+	a.Description("The result of the latest check of a dependency")
+	a.Attribute("name", d.String, "The checked dependency", func() {
+		a.Example("keycloak")
+	})
+	a.Attribute("healthy", d.Boolean, "If the dependency is available")
+	a.Attribute("latency-ms", d.Integer, "How long the check took in milliseconds")
+	a.Attribute("checked-at", d.DateTime, "When the check was run")
+	a.Attribute("last-success", d.DateTime, "When the check last passed")
+	a.Attribute("error", d.String, "The error if the check failed")
+	a.Required("name", "healthy", "latency-ms", "checked-at")
+})
+
 // ALMStatus defines the status of the current running ALM instance
 var ALMStatus = a.MediaType("application/vnd.status+json", func() {
 	a.Description("The status of the current running instance")
@@ -13,6 +26,7 @@ var ALMStatus = a.MediaType("application/vnd.status+json", func() {
 		a.Attribute("buildTime", d.String, "The time when built")
 		a.Attribute("startTime", d.String, "The time when started")
 		a.Attribute("error", d.String, "The error if any")
+		a.Attribute("dependencies", a.ArrayOf(dependencyStatus), "The status of the dependencies, if requested")
 		a.Required("commit", "buildTime", "startTime")
 	})
 	a.View("default", func() {
@@ -20,6 +34,7 @@ var ALMStatus = a.MediaType("application/vnd.status+json", func() {
 		a.Attribute("buildTime")
 		a.Attribute("startTime")
 		a.Attribute("error")
+		a.Attribute("dependencies")
 	})
 })
 
@@ -32,8 +47,30 @@ var _ = a.Resource("status", func() {
 		a.Routing(
 			a.GET(""),
 		)
+		a.Params(func() {
+			a.Param("deep", d.Boolean, "Report the latest background check of the OpenShift clusters, Keycloak and the template source as well as the database", func() {
+				a.Default(false)
+			})
+		})
 		a.Description("Show the status of the current running instance")
 		a.Response(d.OK)
 		a.Response(d.ServiceUnavailable, ALMStatus)
 	})
+
+	a.Action("live", func() {
+		a.Routing(
+			a.GET("/live"),
+		)
+		a.Description("Liveness probe, reports the process is up without checking any dependency")
+		a.Response(d.OK)
+	})
+
+	a.Action("ready", func() {
+		a.Routing(
+			a.GET("/ready"),
+		)
+		a.Description("Readiness probe, reports the cached results of the checks run in the background")
+		a.Response(d.OK)
+		a.Response(d.ServiceUnavailable, ALMStatus)
+	})
 })
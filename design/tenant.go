@@ -14,10 +14,15 @@ var tenant = a.Type("Tenant", func() {
 		a.Example("40bbdd3d-8b5d-4fd6-ac90-7236b669af04")
 	})
 	a.Attribute("attributes", tenantAttributes)
+	a.Attribute("relationships", tenantRelationships)
 	a.Attribute("links", genericLinks)
 	a.Required("type", "attributes")
 })
 
+var tenantRelationships = a.Type("TenantRelationships", func() {
+	a.Attribute("namespaces", relationGenericList, "The tenant namespaces")
+})
+
 var tenantAttributes = a.Type("TenantAttributes", func() {
 	a.Description(`JSONAPI store for all the "attributes" of a Tenant. See also see http://jsonapi.org/format/#document-resource-object-attributes`)
 	a.Attribute("email", d.String, "The tenant name", func() {
@@ -26,6 +31,13 @@ var tenantAttributes = a.Type("TenantAttributes", func() {
 	a.Attribute("created-at", d.DateTime, "When the tenant was created", func() {
 		a.Example("2016-11-29T23:18:14Z")
 	})
+	a.Attribute("plan", d.String, "The plan driving the tenant quotas and limits", func() {
+		a.Enum("free", "team", "enterprise")
+	})
+	a.Attribute("cluster-url", d.String, "The cluster hosting the tenant namespaces", func() {
+	})
+	a.Attribute("placement-reason", d.String, "Why the tenant was placed on its cluster", func() {
+	})
 	a.Attribute("namespaces", a.ArrayOf(namespaceAttributes), "The tenant namespaces", func() {
 	})
 })
@@ -47,16 +59,47 @@ var namespaceAttributes = a.Type("NamespaceAttributes", func() {
 	})
 	a.Attribute("cluster-url", d.String, "The cluster url", func() {
 	})
+	a.Attribute("idled-at", d.DateTime, "When the namespace was idled", func() {
+		a.Example("2016-11-29T23:18:14Z")
+	})
 	a.Attribute("type", d.String, "The tenant namespaces", func() {
 		a.Enum("che", "jenkins", "stage", "test", "run")
 	})
 })
 
+var namespace = JSONResourceObject("Namespace", namespaceAttributes, namespaceRelationships)
+
+var namespaceRelationships = a.Type("NamespaceRelationships", func() {
+	a.Attribute("tenant", relationGeneric, "The tenant owning the namespace")
+})
+
+var namespaceSingle = JSONSingle(
+	"Namespace", "Holds a single namespace",
+	namespace,
+	nil)
+
+var namespaceList = JSONList(
+	"Namespace", "Holds the list of namespaces",
+	namespace,
+	nil,
+	nil)
+
 var tenantSingle = JSONSingle(
 	"tenant", "Holds a single Tenant",
 	tenant,
 	nil)
 
+var tenantListMeta = a.Type("TenantListMeta", func() {
+	a.Attribute("total-count", d.Integer, "Number of tenants matching the filters")
+	a.Required("total-count")
+})
+
+var tenantList = JSONList(
+	"Tenant", "Holds a page of Tenants",
+	tenant,
+	pagingLinks,
+	tenantListMeta)
+
 var _ = a.Resource("tenant", func() {
 	a.BasePath("/tenant")
 	a.Action("setup", func() {
@@ -67,7 +110,7 @@ var _ = a.Resource("tenant", func() {
 
 		a.Description("Initialize new tenant environment.")
 		a.Response(d.Accepted)
-		a.Response(d.Conflict)
+		a.Response(d.Conflict, JSONAPIErrors)
 		a.Response(d.BadRequest, JSONAPIErrors)
 		a.Response(d.NotFound, JSONAPIErrors)
 		a.Response(d.InternalServerError, JSONAPIErrors)
@@ -93,9 +136,104 @@ var _ = a.Resource("tenant", func() {
 		a.Routing(
 			a.GET(""),
 		)
+		a.Params(func() {
+			a.Param("include", d.String, "Include the related resources in the response", func() {
+				a.Enum("namespaces")
+			})
+		})
+		a.UseTrait("conditional")
 
 		a.Description("Initialize new tenant environment.")
 		a.Response(d.OK, tenantSingle)
+		a.Response(d.NotModified)
+		a.Response(d.BadRequest, JSONAPIErrors)
+		a.Response(d.NotFound, JSONAPIErrors)
+		a.Response(d.InternalServerError, JSONAPIErrors)
+		a.Response(d.Unauthorized, JSONAPIErrors)
+	})
+
+	a.Action("progress", func() {
+		a.Security("jwt")
+		a.Routing(
+			a.GET("/progress"),
+		)
+		a.Headers(func() {
+			a.Header("Last-Event-ID", d.String, "Resume the stream after this event")
+		})
+
+		a.Description("Stream the provisioning progress of the tenant as Server-Sent Events.")
+		a.Response(d.OK, func() {
+			a.Media("text/event-stream")
+		})
+		a.Response(d.BadRequest, JSONAPIErrors)
+		a.Response(d.NotFound, JSONAPIErrors)
+		a.Response(d.Unauthorized, JSONAPIErrors)
+	})
+
+	a.Action("list-namespaces", func() {
+		a.Security("jwt")
+		a.Routing(
+			a.GET("/namespaces"),
+		)
+
+		a.Description("List the tenant namespaces.")
+		a.Response(d.OK, namespaceList)
+		a.Response(d.NotFound, JSONAPIErrors)
+		a.Response(d.InternalServerError, JSONAPIErrors)
+		a.Response(d.Unauthorized, JSONAPIErrors)
+	})
+
+	a.Action("show-namespace", func() {
+		a.Security("jwt")
+		a.Routing(
+			a.GET("/namespaces/:namespaceID"),
+		)
+		a.Params(func() {
+			a.Param("namespaceID", d.UUID, "ID of the namespace")
+		})
+
+		a.Description("Show a single tenant namespace.")
+		a.Response(d.OK, namespaceSingle)
+		a.Response(d.NotFound, JSONAPIErrors)
+		a.Response(d.InternalServerError, JSONAPIErrors)
+		a.Response(d.Unauthorized, JSONAPIErrors)
+	})
+
+	a.Action("reset", func() {
+		a.Security("jwt")
+		a.Routing(
+			a.POST("/namespaces/:type/reset"),
+		)
+		a.Params(func() {
+			a.Param("type", d.String, "The type of namespace to reset", func() {
+				a.Enum("che", "jenkins", "stage", "test", "run")
+			})
+			a.Param("preserve-pvcs", d.Boolean, "Keep the persistent volume claims of the namespace", func() {
+				a.Default(false)
+			})
+		})
+
+		a.Description("Delete and re-provision a single tenant namespace.")
+		a.Response(d.Accepted, jobSingle)
+		a.Response(d.BadRequest, JSONAPIErrors)
+		a.Response(d.NotFound, JSONAPIErrors)
+		a.Response(d.InternalServerError, JSONAPIErrors)
+		a.Response(d.Unauthorized, JSONAPIErrors)
+	})
+
+	a.Action("unidle", func() {
+		a.Security("jwt")
+		a.Routing(
+			a.POST("/unidle"),
+		)
+		a.Params(func() {
+			a.Param("type", d.String, "Only scale up namespaces of this type", func() {
+				a.Enum("che", "jenkins")
+			})
+		})
+
+		a.Description("Scale the idled tenant namespaces back up.")
+		a.Response(d.OK, tenantSingle)
 		a.Response(d.BadRequest, JSONAPIErrors)
 		a.Response(d.NotFound, JSONAPIErrors)
 		a.Response(d.InternalServerError, JSONAPIErrors)
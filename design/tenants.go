@@ -0,0 +1,173 @@
+package design
+
+import (
+	d "github.com/goadesign/goa/design"
+	a "github.com/goadesign/goa/design/apidsl"
+)
+
+var updateTenantPayload = a.Type("UpdateTenantPayload", func() {
+	a.Attribute("data", tenant)
+	a.Required("data")
+})
+
+var _ = a.Resource("tenants", func() {
+	a.BasePath("/tenants")
+
+	a.Action("list", func() {
+		a.Security("jwt")
+		a.Routing(
+			a.GET(""),
+		)
+		a.Params(func() {
+			a.Param("filter[email]", d.String, "Only list tenants whose email contains the value")
+			a.Param("filter[namespace-state]", d.String, "Only list tenants with a namespace in this state")
+			a.Param("filter[namespace-version]", d.String, "Only list tenants with a namespace on this version")
+			a.Param("filter[cluster-url]", d.String, "Only list tenants placed on this cluster")
+			a.Param("filter[created-after]", d.DateTime, "Only list tenants created at or after this time")
+			a.Param("filter[created-before]", d.DateTime, "Only list tenants created before this time")
+			a.Param("sort", d.String, "The attribute to sort by, prefixed with - for descending order", func() {
+				a.Enum("created-at", "-created-at", "email", "-email")
+				a.Default("created-at")
+			})
+			a.Param("page[after]", d.UUID, "Start the page after this tenant, the cursor of the next link")
+			a.Param("page[limit]", d.Integer, "The maximum number of tenants in the page", func() {
+				a.Minimum(1)
+				a.Maximum(1000)
+				a.Default(100)
+			})
+		})
+		a.Description("List the tenants of all users.")
+		a.Response(d.OK, tenantList)
+		a.Response(d.BadRequest, JSONAPIErrors)
+		a.Response(d.InternalServerError, JSONAPIErrors)
+		a.Response(d.Unauthorized, JSONAPIErrors)
+		a.Response(d.Forbidden, JSONAPIErrors)
+	})
+
+	a.Action("update-plan", func() {
+		a.Security("jwt")
+		a.Routing(
+			a.PUT("/:tenantID/plan"),
+		)
+		a.Params(func() {
+			a.Param("tenantID", d.UUID, "ID of the tenant")
+		})
+		a.Description("Change the plan of a tenant and re-apply the objects affected by it.")
+		a.Payload(updateTenantPayload)
+		a.Response(d.Accepted, jobSingle)
+		a.Response(d.BadRequest, JSONAPIErrors)
+		a.Response(d.NotFound, JSONAPIErrors)
+		a.Response(d.InternalServerError, JSONAPIErrors)
+		a.Response(d.Unauthorized, JSONAPIErrors)
+		a.Response(d.Forbidden, JSONAPIErrors)
+	})
+
+	a.Action("migrate", func() {
+		a.Security("jwt")
+		a.Routing(
+			a.POST("/:tenantID/migrate"),
+		)
+		a.Params(func() {
+			a.Param("tenantID", d.UUID, "ID of the tenant")
+		})
+		a.Description("Move the tenant namespaces to another cluster. An unfinished migration to the same cluster is resumed.")
+		a.Payload(updateTenantPayload)
+		a.Response(d.Accepted, jobSingle)
+		a.Response(d.BadRequest, JSONAPIErrors)
+		a.Response(d.NotFound, JSONAPIErrors)
+		a.Response(d.Conflict, JSONAPIErrors)
+		a.Response(d.InternalServerError, JSONAPIErrors)
+		a.Response(d.Unauthorized, JSONAPIErrors)
+		a.Response(d.Forbidden, JSONAPIErrors)
+	})
+
+	a.Action("update-placement", func() {
+		a.Security("jwt")
+		a.Routing(
+			a.PUT("/:tenantID/placement"),
+		)
+		a.Params(func() {
+			a.Param("tenantID", d.UUID, "ID of the tenant")
+		})
+		a.Description("Override the placement policy and place the tenant on the given cluster when it is set up.")
+		a.Payload(updateTenantPayload)
+		a.Response(d.NoContent)
+		a.Response(d.BadRequest, JSONAPIErrors)
+		a.Response(d.NotFound, JSONAPIErrors)
+		a.Response(d.Conflict, JSONAPIErrors)
+		a.Response(d.InternalServerError, JSONAPIErrors)
+		a.Response(d.Unauthorized, JSONAPIErrors)
+		a.Response(d.Forbidden, JSONAPIErrors)
+	})
+
+	a.Action("show", func() {
+		a.Security("jwt")
+		a.Routing(
+			a.GET("/:tenantID"),
+		)
+		a.Params(func() {
+			a.Param("tenantID", d.UUID, "ID of the tenant")
+		})
+		a.Description("Show the tenant of any user.")
+		a.Response(d.OK, tenantSingle)
+		a.Response(d.NotFound, JSONAPIErrors)
+		a.Response(d.InternalServerError, JSONAPIErrors)
+		a.Response(d.Unauthorized, JSONAPIErrors)
+		a.Response(d.Forbidden, JSONAPIErrors)
+	})
+
+	a.Action("update", func() {
+		a.Security("jwt")
+		a.Routing(
+			a.PATCH("/:tenantID"),
+		)
+		a.Params(func() {
+			a.Param("tenantID", d.UUID, "ID of the tenant")
+		})
+		a.Description("Re-apply the templates to the namespaces of any user using the master service token.")
+		a.Response(d.Accepted)
+		a.Response(d.NotFound, JSONAPIErrors)
+		a.Response(d.InternalServerError, JSONAPIErrors)
+		a.Response(d.Unauthorized, JSONAPIErrors)
+		a.Response(d.Forbidden, JSONAPIErrors)
+	})
+
+	a.Action("reset", func() {
+		a.Security("jwt")
+		a.Routing(
+			a.POST("/:tenantID/namespaces/:type/reset"),
+		)
+		a.Params(func() {
+			a.Param("tenantID", d.UUID, "ID of the tenant")
+			a.Param("type", d.String, "The type of namespace to reset", func() {
+				a.Enum("che", "jenkins", "stage", "test", "run")
+			})
+			a.Param("preserve-pvcs", d.Boolean, "Keep the persistent volume claims of the namespace", func() {
+				a.Default(false)
+			})
+		})
+		a.Description("Delete and re-provision a single namespace of any user.")
+		a.Response(d.Accepted, jobSingle)
+		a.Response(d.BadRequest, JSONAPIErrors)
+		a.Response(d.NotFound, JSONAPIErrors)
+		a.Response(d.InternalServerError, JSONAPIErrors)
+		a.Response(d.Unauthorized, JSONAPIErrors)
+		a.Response(d.Forbidden, JSONAPIErrors)
+	})
+
+	a.Action("delete", func() {
+		a.Security("jwt")
+		a.Routing(
+			a.DELETE("/:tenantID"),
+		)
+		a.Params(func() {
+			a.Param("tenantID", d.UUID, "ID of the tenant")
+		})
+		a.Description("Delete the namespaces of any user and forget the tenant, which can then be set up again.")
+		a.Response(d.Accepted, jobSingle)
+		a.Response(d.NotFound, JSONAPIErrors)
+		a.Response(d.InternalServerError, JSONAPIErrors)
+		a.Response(d.Unauthorized, JSONAPIErrors)
+		a.Response(d.Forbidden, JSONAPIErrors)
+	})
+})
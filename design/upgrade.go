@@ -0,0 +1,133 @@
+package design
+
+import (
+	d "github.com/goadesign/goa/design"
+	a "github.com/goadesign/goa/design/apidsl"
+)
+
+var upgrade = a.Type("Upgrade", func() {
+	a.Description(`JSONAPI for the fleet upgrade object. See also http://jsonapi.org/format/#document-resource-object`)
+	a.Attribute("type", d.String, func() {
+		a.Enum("upgrades")
+	})
+	a.Attribute("id", d.UUID, "ID of the upgrade", func() {
+		a.Example("40bbdd3d-8b5d-4fd6-ac90-7236b669af04")
+	})
+	a.Attribute("attributes", upgradeAttributes)
+	a.Attribute("links", genericLinks)
+	a.Required("type", "attributes")
+})
+
+var upgradeAttributes = a.Type("UpgradeAttributes", func() {
+	a.Description(`JSONAPI store for all the "attributes" of a fleet upgrade. See also see http://jsonapi.org/format/#document-resource-object-attributes`)
+	a.Attribute("target-version", d.String, "The team version to upgrade the tenants to", func() {
+		a.Example("1.0.92")
+	})
+	a.Attribute("from-version", d.String, "Only upgrade tenants with namespaces on this version", func() {
+		a.Example("1.0.91")
+	})
+	a.Attribute("cluster-url", d.String, "Only upgrade tenants with namespaces on this cluster", func() {
+	})
+	a.Attribute("batch-size", d.Integer, "Number of tenants upgraded between failure rate checks", func() {
+		a.Minimum(1)
+	})
+	a.Attribute("max-concurrency", d.Integer, "Number of tenants upgraded in parallel", func() {
+		a.Minimum(1)
+	})
+	a.Attribute("canary-percentage", d.Integer, "Percentage of the tenants upgraded in the first batch", func() {
+		a.Minimum(1)
+		a.Maximum(100)
+	})
+	a.Attribute("failure-threshold", d.Integer, "Failure rate in percent that pauses the upgrade", func() {
+		a.Minimum(1)
+		a.Maximum(100)
+	})
+	a.Attribute("state", d.String, "The upgrade state", func() {
+		a.Enum("running", "paused", "aborted", "completed")
+	})
+	a.Attribute("reason", d.String, "Why the upgrade is in the current state", func() {
+	})
+	a.Attribute("total", d.Integer, "Number of tenants selected for upgrade", func() {
+	})
+	a.Attribute("succeeded", d.Integer, "Number of tenants upgraded", func() {
+	})
+	a.Attribute("failed", d.Integer, "Number of tenants that failed to upgrade", func() {
+	})
+	a.Attribute("failed-tenants", a.ArrayOf(d.UUID), "IDs of the tenants that failed to upgrade", func() {
+	})
+	a.Attribute("started-at", d.DateTime, "When the upgrade was started", func() {
+		a.Example("2016-11-29T23:18:14Z")
+	})
+	a.Attribute("completed-at", d.DateTime, "When the upgrade completed or was aborted", func() {
+		a.Example("2016-11-29T23:18:14Z")
+	})
+})
+
+var upgradeSingle = JSONSingle(
+	"upgrade", "Holds a single fleet upgrade",
+	upgrade,
+	nil)
+
+var createUpgradePayload = a.Type("CreateUpgradePayload", func() {
+	a.Attribute("data", upgrade)
+	a.Required("data")
+})
+
+var _ = a.Resource("upgrade", func() {
+	a.BasePath("/upgrades")
+
+	a.Action("create", func() {
+		a.Security("jwt")
+		a.Routing(
+			a.POST(""),
+		)
+		a.Description("Start upgrading all selected tenants to a target version.")
+		a.Payload(createUpgradePayload)
+		a.Response(d.Accepted, upgradeSingle)
+		a.Response(d.Conflict)
+		a.Response(d.BadRequest, JSONAPIErrors)
+		a.Response(d.InternalServerError, JSONAPIErrors)
+		a.Response(d.Unauthorized, JSONAPIErrors)
+		a.Response(d.Forbidden, JSONAPIErrors)
+	})
+
+	a.Action("show", func() {
+		a.Security("jwt")
+		a.Routing(
+			a.GET("/:id"),
+		)
+		a.Params(func() {
+			a.Param("id", d.UUID, "ID of the upgrade")
+		})
+		a.Description("Show the progress of an upgrade.")
+		a.Response(d.OK, upgradeSingle)
+		a.Response(d.NotFound, JSONAPIErrors)
+		a.Response(d.InternalServerError, JSONAPIErrors)
+		a.Response(d.Unauthorized, JSONAPIErrors)
+		a.Response(d.Forbidden, JSONAPIErrors)
+	})
+
+	a.Action("pause", upgradeStateAction("pause"))
+	a.Action("resume", upgradeStateAction("resume"))
+	a.Action("abort", upgradeStateAction("abort"))
+})
+
+// upgradeStateAction defines an action that changes the state of a running upgrade
+func upgradeStateAction(name string) func() {
+	return func() {
+		a.Security("jwt")
+		a.Routing(
+			a.POST("/:id/" + name),
+		)
+		a.Params(func() {
+			a.Param("id", d.UUID, "ID of the upgrade")
+		})
+		a.Description("Change the state of an upgrade.")
+		a.Response(d.OK, upgradeSingle)
+		a.Response(d.BadRequest, JSONAPIErrors)
+		a.Response(d.NotFound, JSONAPIErrors)
+		a.Response(d.InternalServerError, JSONAPIErrors)
+		a.Response(d.Unauthorized, JSONAPIErrors)
+		a.Response(d.Forbidden, JSONAPIErrors)
+	}
+}
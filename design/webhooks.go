@@ -0,0 +1,175 @@
+package design
+
+import (
+	d "github.com/goadesign/goa/design"
+	a "github.com/goadesign/goa/design/apidsl"
+)
+
+var webhookEventTypes = []interface{}{"tenant.created", "namespace.ready", "tenant.updated", "tenant.failed", "tenant.deleted"}
+
+var webhook = a.Type("Webhook", func() {
+	a.Description(`JSONAPI for the webhook subscription object. See also http://jsonapi.org/format/#document-resource-object`)
+	a.Attribute("type", d.String, func() {
+		a.Enum("webhooks")
+	})
+	a.Attribute("id", d.UUID, "ID of the webhook", func() {
+		a.Example("40bbdd3d-8b5d-4fd6-ac90-7236b669af04")
+	})
+	a.Attribute("attributes", webhookAttributes)
+	a.Required("type", "attributes")
+})
+
+var webhookAttributes = a.Type("WebhookAttributes", func() {
+	a.Description(`JSONAPI store for all the "attributes" of a webhook. See also see http://jsonapi.org/format/#document-resource-object-attributes`)
+	a.Attribute("url", d.String, "The URL the events are posted to", func() {
+		a.Example("https://example.com/hooks/tenant")
+	})
+	a.Attribute("secret", d.String, "The key of the HMAC-SHA256 signature sent in the X-Tenant-Signature header, never returned", func() {
+		a.MinLength(16)
+	})
+	a.Attribute("event-types", a.ArrayOf(d.String, func() {
+		a.Enum(webhookEventTypes...)
+	}), "The event types sent to the webhook", func() {
+		a.Example([]string{"tenant.created", "tenant.failed"})
+	})
+	a.Attribute("created-at", d.DateTime, "When the webhook was created", func() {
+		a.Example("2016-11-29T23:18:14Z")
+	})
+})
+
+var webhookSingle = JSONSingle(
+	"webhook", "Holds a single webhook",
+	webhook,
+	nil)
+
+var webhookList = JSONList(
+	"Webhook", "Holds the list of webhooks",
+	webhook,
+	nil,
+	nil)
+
+var createWebhookPayload = a.Type("CreateWebhookPayload", func() {
+	a.Attribute("data", webhook)
+	a.Required("data")
+})
+
+var webhookDelivery = a.Type("WebhookDelivery", func() {
+	a.Description(`JSONAPI for the webhook delivery object. See also http://jsonapi.org/format/#document-resource-object`)
+	a.Attribute("type", d.String, func() {
+		a.Enum("webhook-deliveries")
+	})
+	a.Attribute("id", d.UUID, "ID of the delivery, sent in the X-Tenant-Delivery header", func() {
+		a.Example("40bbdd3d-8b5d-4fd6-ac90-7236b669af04")
+	})
+	a.Attribute("attributes", webhookDeliveryAttributes)
+	a.Required("type", "attributes")
+})
+
+var webhookDeliveryAttributes = a.Type("WebhookDeliveryAttributes", func() {
+	a.Description(`JSONAPI store for all the "attributes" of a webhook delivery. See also see http://jsonapi.org/format/#document-resource-object-attributes`)
+	a.Attribute("event-id", d.UUID, "ID of the delivered event", func() {
+	})
+	a.Attribute("event-type", d.String, "Type of the delivered event", func() {
+		a.Enum(webhookEventTypes...)
+	})
+	a.Attribute("tenant-id", d.UUID, "The tenant the event is about", func() {
+	})
+	a.Attribute("state", d.String, "The delivery state", func() {
+		a.Enum("pending", "delivered", "failed")
+	})
+	a.Attribute("attempts", d.Integer, "How often the delivery was attempted", func() {
+		a.Example(1)
+	})
+	a.Attribute("response-status", d.Integer, "The HTTP status the webhook responded with to the last attempt", func() {
+		a.Example(200)
+	})
+	a.Attribute("error", d.String, "Why the last attempt failed", func() {
+	})
+	a.Attribute("created-at", d.DateTime, "When the event was published", func() {
+		a.Example("2016-11-29T23:18:14Z")
+	})
+	a.Attribute("last-attempt-at", d.DateTime, "When the delivery was last attempted", func() {
+		a.Example("2016-11-29T23:18:14Z")
+	})
+	a.Attribute("next-attempt-at", d.DateTime, "When the delivery is attempted next", func() {
+		a.Example("2016-11-29T23:18:14Z")
+	})
+})
+
+var webhookDeliveryList = JSONList(
+	"WebhookDelivery", "Holds the list of webhook deliveries",
+	webhookDelivery,
+	nil,
+	nil)
+
+var _ = a.Resource("webhooks", func() {
+	a.BasePath("/webhooks")
+
+	a.Action("create", func() {
+		a.Security("jwt")
+		a.Routing(
+			a.POST(""),
+		)
+		a.Description("Subscribe a URL to tenant lifecycle events.")
+		a.Payload(createWebhookPayload)
+		a.Response(d.Created, "/webhooks/.+/deliveries", func() {
+			a.Media(webhookSingle)
+		})
+		a.Response(d.BadRequest, JSONAPIErrors)
+		a.Response(d.InternalServerError, JSONAPIErrors)
+		a.Response(d.Unauthorized, JSONAPIErrors)
+		a.Response(d.Forbidden, JSONAPIErrors)
+	})
+
+	a.Action("list", func() {
+		a.Security("jwt")
+		a.Routing(
+			a.GET(""),
+		)
+		a.Description("List the webhook subscriptions.")
+		a.Response(d.OK, webhookList)
+		a.Response(d.InternalServerError, JSONAPIErrors)
+		a.Response(d.Unauthorized, JSONAPIErrors)
+		a.Response(d.Forbidden, JSONAPIErrors)
+	})
+
+	a.Action("delete", func() {
+		a.Security("jwt")
+		a.Routing(
+			a.DELETE("/:id"),
+		)
+		a.Params(func() {
+			a.Param("id", d.UUID, "ID of the webhook")
+		})
+		a.Description("Unsubscribe a webhook, its pending deliveries are dropped.")
+		a.Response(d.NoContent)
+		a.Response(d.NotFound, JSONAPIErrors)
+		a.Response(d.InternalServerError, JSONAPIErrors)
+		a.Response(d.Unauthorized, JSONAPIErrors)
+		a.Response(d.Forbidden, JSONAPIErrors)
+	})
+
+	a.Action("deliveries", func() {
+		a.Security("jwt")
+		a.Routing(
+			a.GET("/:id/deliveries"),
+		)
+		a.Params(func() {
+			a.Param("id", d.UUID, "ID of the webhook")
+			a.Param("state", d.String, "Only list the deliveries in this state", func() {
+				a.Enum("pending", "delivered", "failed")
+			})
+			a.Param("limit", d.Integer, "The maximum number of deliveries to list", func() {
+				a.Minimum(1)
+				a.Maximum(1000)
+				a.Default(100)
+			})
+		})
+		a.Description("List the deliveries of a webhook, the newest first.")
+		a.Response(d.OK, webhookDeliveryList)
+		a.Response(d.NotFound, JSONAPIErrors)
+		a.Response(d.InternalServerError, JSONAPIErrors)
+		a.Response(d.Unauthorized, JSONAPIErrors)
+		a.Response(d.Forbidden, JSONAPIErrors)
+	})
+})
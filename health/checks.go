@@ -0,0 +1,76 @@
+package health
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/fabric8io/fabric8-init-tenant/cluster"
+	"github.com/fabric8io/fabric8-init-tenant/idp"
+	"github.com/fabric8io/fabric8-init-tenant/migration"
+	"github.com/fabric8io/fabric8-init-tenant/openshift"
+)
+
+// Migrated checks that the database is migrated to the version this build expects
+func Migrated(db *sql.DB) Check {
+	return func() error {
+		return migration.CheckVersion(db)
+	}
+}
+
+// AnyCluster checks that the API server of at least one registered cluster is reachable
+func AnyCluster(clusters *cluster.Registry) Check {
+	return func() error {
+		registered, err := clusters.Clusters()
+		if err != nil {
+			return err
+		}
+		var last error
+		for _, c := range registered {
+			if last = ClusterAPI(clusters, c.APIURL)(); last == nil {
+				return nil
+			}
+		}
+		if last == nil {
+			return fmt.Errorf("no cluster registered")
+		}
+		return last
+	}
+}
+
+// ClusterAPI checks that the API server of the registered cluster is reachable
+func ClusterAPI(clusters *cluster.Registry, apiURL string) Check {
+	return func() error {
+		config, err := clusters.Config(apiURL)
+		if err != nil {
+			return err
+		}
+		return openshift.Ping(config)
+	}
+}
+
+// ClusterToken checks that the service token of the registered cluster is accepted
+func ClusterToken(clusters *cluster.Registry, apiURL string) Check {
+	return func() error {
+		config, err := clusters.Config(apiURL)
+		if err != nil {
+			return err
+		}
+		_, err = openshift.WhoAmI(config)
+		return err
+	}
+}
+
+// IdentityProvider checks that the signing keys of the identity provider can be fetched
+func IdentityProvider(provider idp.IdentityProvider) Check {
+	return func() error {
+		_, err := provider.PublicKeys()
+		return err
+	}
+}
+
+// Templates checks that the tenant templates can be loaded
+func Templates(config openshift.Config) Check {
+	return func() error {
+		return openshift.CheckTemplates(config)
+	}
+}
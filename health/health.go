@@ -0,0 +1,141 @@
+// Package health checks the availability of the services the tenant service depends on.
+package health
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Check verifies that a dependency is available
+type Check func() error
+
+// Result is the outcome of the latest run of a check
+type Result struct {
+	Name      string
+	Healthy   bool
+	Latency   time.Duration
+	CheckedAt time.Time
+	// LastSuccess is the last time the check passed, nil if it never did
+	LastSuccess *time.Time
+	Error       string
+}
+
+type namedCheck struct {
+	name  string
+	check Check
+}
+
+// Monitor runs the registered checks and remembers when each last succeeded
+type Monitor struct {
+	timeout time.Duration
+
+	mu          sync.Mutex
+	checks      []namedCheck
+	lastSuccess map[string]time.Time
+	cached      []Result
+}
+
+// NewMonitor creates a Monitor failing checks that take longer than the timeout
+func NewMonitor(timeout time.Duration) *Monitor {
+	return &Monitor{
+		timeout:     timeout,
+		lastSuccess: map[string]time.Time{},
+	}
+}
+
+// Register adds a named check
+func (m *Monitor) Register(name string, check Check) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.checks = append(m.checks, namedCheck{name: name, check: check})
+}
+
+// Run runs all checks concurrently and returns the results in the order the checks were registered
+func (m *Monitor) Run() []Result {
+	m.mu.Lock()
+	checks := make([]namedCheck, len(m.checks))
+	copy(checks, m.checks)
+	m.mu.Unlock()
+
+	results := make([]Result, len(checks))
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func(i int, c namedCheck) {
+			defer wg.Done()
+			results[i] = m.run(c)
+		}(i, c)
+	}
+	wg.Wait()
+	return results
+}
+
+// Start runs the checks every interval until the context is done, caching the results returned by Results
+func (m *Monitor) Start(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			results := m.Run()
+			m.mu.Lock()
+			m.cached = results
+			m.mu.Unlock()
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Results returns the results of the latest background run, nil if the checks have not run yet
+func (m *Monitor) Results() []Result {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.cached
+}
+
+// Healthy returns if all results are healthy
+func Healthy(results []Result) bool {
+	for _, r := range results {
+		if !r.Healthy {
+			return false
+		}
+	}
+	return true
+}
+
+func (m *Monitor) run(c namedCheck) Result {
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		done <- c.check()
+	}()
+	var err error
+	select {
+	case err = <-done:
+	case <-time.After(m.timeout):
+		err = fmt.Errorf("timed out after %v", m.timeout)
+	}
+	result := Result{
+		Name:      c.name,
+		Healthy:   err == nil,
+		Latency:   time.Since(start),
+		CheckedAt: start,
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err != nil {
+		result.Error = err.Error()
+	} else {
+		m.lastSuccess[c.name] = start
+	}
+	if last, found := m.lastSuccess[c.name]; found {
+		result.LastSuccess = &last
+	}
+	return result
+}
@@ -0,0 +1,64 @@
+package health_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/almighty/almighty-core/resource"
+	"github.com/fabric8io/fabric8-init-tenant/health"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+
+	var failing error
+	m := health.NewMonitor(100 * time.Millisecond)
+	m.Register("db", func() error { return nil })
+	m.Register("keycloak", func() error { return failing })
+	m.Register("slow", func() error {
+		time.Sleep(time.Second)
+		return nil
+	})
+
+	results := m.Run()
+	require.Len(t, results, 3)
+	assert.Equal(t, "db", results[0].Name)
+	assert.True(t, results[0].Healthy)
+	assert.NotNil(t, results[0].LastSuccess)
+	assert.True(t, results[1].Healthy)
+	assert.False(t, results[2].Healthy)
+	assert.Contains(t, results[2].Error, "timed out")
+	assert.Nil(t, results[2].LastSuccess)
+	assert.False(t, health.Healthy(results))
+
+	lastSuccess := *results[1].LastSuccess
+	failing = fmt.Errorf("connection refused")
+	results = m.Run()
+	assert.False(t, results[1].Healthy)
+	assert.Equal(t, "connection refused", results[1].Error)
+	require.NotNil(t, results[1].LastSuccess)
+	assert.Equal(t, lastSuccess, *results[1].LastSuccess)
+	assert.True(t, health.Healthy(results[:1]))
+}
+
+func TestStart(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+
+	m := health.NewMonitor(100 * time.Millisecond)
+	m.Register("db", func() error { return nil })
+	assert.Nil(t, m.Results())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	m.Start(ctx, 10*time.Millisecond)
+	for i := 0; i < 100 && m.Results() == nil; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	results := m.Results()
+	require.Len(t, results, 1)
+	assert.True(t, health.Healthy(results))
+}
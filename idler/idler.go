@@ -0,0 +1,167 @@
+package idler
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/almighty/almighty-core/log"
+	"github.com/fabric8io/fabric8-init-tenant/audit"
+	"github.com/fabric8io/fabric8-init-tenant/cluster"
+	"github.com/fabric8io/fabric8-init-tenant/openshift"
+	"github.com/fabric8io/fabric8-init-tenant/tenant"
+	uuid "github.com/satori/go.uuid"
+)
+
+// IdledTypes are the namespace types whose deployments are scaled down when inactive
+var IdledTypes = []tenant.NamespaceType{tenant.TypeJenkins, tenant.TypeChe}
+
+// Idler scales down the Jenkins and Che deployments of inactive tenants and back up on demand
+type Idler struct {
+	service  tenant.Service
+	clusters *cluster.Registry
+	auditor  audit.Service
+	timeout  time.Duration
+	interval time.Duration
+}
+
+// New creates a new Idler idling namespaces without activity for the given timeout, checked every interval.
+// The outcome of idling each namespace is recorded in the audit trail.
+func New(service tenant.Service, clusters *cluster.Registry, auditor audit.Service, timeout, interval time.Duration) *Idler {
+	return &Idler{
+		service:  service,
+		clusters: clusters,
+		auditor:  auditor,
+		timeout:  timeout,
+		interval: interval,
+	}
+}
+
+// Start runs IdleInactive every interval until the context is done
+func (i *Idler) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(i.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := i.IdleInactive(ctx); err != nil {
+					log.Error(ctx, map[string]interface{}{
+						"err": err,
+					}, "unable to idle inactive namespaces")
+				}
+			}
+		}
+	}()
+}
+
+// IdleInactive scales down all namespaces without activity within the timeout
+func (i *Idler) IdleInactive(ctx context.Context) error {
+	inactiveSince := time.Now().Add(-i.timeout)
+	namespaces, err := i.service.GetInactiveNamespaces(inactiveSince, IdledTypes...)
+	if err != nil {
+		return err
+	}
+	for _, ns := range namespaces {
+		if err := i.idle(ctx, ns, inactiveSince); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// idle scales down the namespace and records the outcome. Only failing to record the namespace as
+// idled is returned, the other namespaces are still idled if the namespace can not be scaled down.
+func (i *Idler) idle(ctx context.Context, ns *tenant.Namespace, inactiveSince time.Time) error {
+	record := audit.NewRecord(ctx, "idle", ns.TenantID)
+	record.Details = audit.Details{"namespace": ns.Name, "cluster_url": ns.MasterURL}
+	config, err := i.clusters.Config(ns.MasterURL)
+	if err == nil {
+		err = openshift.IdleNamespace(config, ns.Name)
+	}
+	if err != nil {
+		log.Error(ctx, map[string]interface{}{
+			"err":       err,
+			"namespace": ns.Name,
+		}, "unable to idle namespace")
+		i.recordAudit(ctx, record.Completed(err))
+		return nil
+	}
+	idled, err := i.service.MarkIdled(ns.ID, inactiveSince)
+	if err != nil {
+		i.recordAudit(ctx, record.Completed(err))
+		return err
+	}
+	if !idled {
+		// activity was recorded while scaling down, the namespace is in use again
+		if err := openshift.UnidleNamespace(config, ns.Name); err != nil {
+			log.Error(ctx, map[string]interface{}{
+				"err":       err,
+				"namespace": ns.Name,
+			}, "unable to unidle namespace that became active")
+		}
+		i.recordAudit(ctx, record.Completed(fmt.Errorf("activity recorded while idling")))
+		return nil
+	}
+	i.recordAudit(ctx, record.Completed(nil))
+	log.Info(ctx, map[string]interface{}{
+		"namespace": ns.Name,
+		"tenant_id": ns.TenantID,
+	}, "namespace idled")
+	return nil
+}
+
+// recordAudit appends the record to the audit trail, a record that can not be stored is logged instead
+func (i *Idler) recordAudit(ctx context.Context, record *audit.Record) {
+	if err := i.auditor.AddRecord(record); err != nil {
+		log.Error(ctx, map[string]interface{}{
+			"err":       err,
+			"operation": record.Operation,
+			"tenant_id": record.TenantID,
+		}, "unable to record audit trail")
+	}
+}
+
+// Unidle scales the idled namespaces of the tenant back up. If types are given only
+// namespaces of those types are considered.
+func (i *Idler) Unidle(ctx context.Context, tenantID uuid.UUID, types ...tenant.NamespaceType) error {
+	namespaces, err := i.service.GetNamespaces(tenantID)
+	if err != nil {
+		return err
+	}
+	for _, ns := range namespaces {
+		if ns.IdledAt == nil || !matches(ns.Type, types) {
+			continue
+		}
+		config, err := i.clusters.Config(ns.MasterURL)
+		if err != nil {
+			return err
+		}
+		err = openshift.UnidleNamespace(config, ns.Name)
+		if err != nil {
+			return err
+		}
+		if err := i.service.MarkUnidled(ns.ID); err != nil {
+			return err
+		}
+		log.Info(ctx, map[string]interface{}{
+			"namespace": ns.Name,
+			"tenant_id": ns.TenantID,
+		}, "namespace unidled")
+	}
+	return i.service.RecordActivity(tenantID)
+}
+
+func matches(t tenant.NamespaceType, types []tenant.NamespaceType) bool {
+	if len(types) == 0 {
+		return true
+	}
+	for _, candidate := range types {
+		if t == candidate {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,184 @@
+package idler_test
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/almighty/almighty-core/resource"
+	"github.com/fabric8io/fabric8-init-tenant/audit"
+	"github.com/fabric8io/fabric8-init-tenant/cluster"
+	"github.com/fabric8io/fabric8-init-tenant/idler"
+	"github.com/fabric8io/fabric8-init-tenant/openshift"
+	"github.com/fabric8io/fabric8-init-tenant/tenant"
+	"github.com/fabric8io/fabric8-init-tenant/webhook"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type namespaces struct {
+	tenant.NilService
+	mu         sync.Mutex
+	namespaces []*tenant.Namespace
+	active     []uuid.UUID
+	// activeWhileIdling simulates activity recorded while the namespace is scaled down
+	activeWhileIdling bool
+}
+
+func (s *namespaces) GetInactiveNamespaces(inactiveSince time.Time, types ...tenant.NamespaceType) ([]*tenant.Namespace, error) {
+	return s.namespaces, nil
+}
+
+func (s *namespaces) GetNamespaces(tenantID uuid.UUID) ([]*tenant.Namespace, error) {
+	return s.namespaces, nil
+}
+
+func (s *namespaces) MarkIdled(namespaceID uuid.UUID, inactiveSince time.Time) (bool, error) {
+	if s.activeWhileIdling {
+		return false, nil
+	}
+	now := time.Now()
+	s.namespace(namespaceID).IdledAt = &now
+	return true, nil
+}
+
+func (s *namespaces) MarkUnidled(namespaceID uuid.UUID) error {
+	s.namespace(namespaceID).IdledAt = nil
+	return nil
+}
+
+func (s *namespaces) namespace(id uuid.UUID) *tenant.Namespace {
+	for _, ns := range s.namespaces {
+		if ns.ID == id {
+			return ns
+		}
+	}
+	return nil
+}
+
+func (s *namespaces) RecordActivity(tenantID uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.active = append(s.active, tenantID)
+	return nil
+}
+
+type auditTrail struct {
+	audit.NilService
+	records []*audit.Record
+}
+
+func (s *auditTrail) AddRecord(record *audit.Record, events ...webhook.Event) error {
+	s.records = append(s.records, record)
+	return nil
+}
+
+// cluster fakes the DeploymentConfig endpoints of a single namespace
+type cluster struct {
+	mu       sync.Mutex
+	replicas int
+	previous string
+}
+
+func (c *cluster) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	switch r.Method {
+	case "GET":
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"items": []interface{}{
+				map[string]interface{}{
+					"metadata": map[string]interface{}{
+						"name":        "jenkins",
+						"annotations": map[string]string{openshift.AnnotationIdledReplicas: c.previous},
+					},
+					"spec": map[string]interface{}{"replicas": c.replicas},
+				},
+			},
+		})
+	case "PATCH":
+		b, _ := ioutil.ReadAll(r.Body)
+		var patch struct {
+			Metadata struct {
+				Annotations map[string]*string
+			}
+			Spec struct {
+				Replicas int
+			}
+		}
+		json.Unmarshal(b, &patch)
+		c.replicas = patch.Spec.Replicas
+		if v := patch.Metadata.Annotations[openshift.AnnotationIdledReplicas]; v != nil {
+			c.previous = *v
+		} else {
+			c.previous = ""
+		}
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+func TestIdleAndUnidle(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+
+	c := &cluster{replicas: 2}
+	server := httptest.NewServer(c)
+	defer server.Close()
+
+	tenantID := uuid.NewV4()
+	service := &namespaces{namespaces: []*tenant.Namespace{
+		{ID: uuid.NewV4(), TenantID: tenantID, Name: "aslak-jenkins", Type: tenant.TypeJenkins},
+	}}
+	clusters := cluster.NewRegistry(cluster.NilService{}, openshift.Config{MasterURL: server.URL}, nil)
+	trail := &auditTrail{}
+	i := idler.New(service, clusters, trail, time.Hour, time.Minute)
+
+	require.NoError(t, i.IdleInactive(context.Background()))
+	assert.Equal(t, 0, c.replicas)
+	assert.Equal(t, "2", c.previous)
+	assert.NotNil(t, service.namespaces[0].IdledAt)
+	require.Len(t, trail.records, 1)
+	assert.Equal(t, "idle", trail.records[0].Operation)
+	assert.Equal(t, tenantID, trail.records[0].TenantID)
+	assert.Equal(t, audit.OutcomeSucceeded, trail.records[0].Outcome)
+	assert.Equal(t, uuid.Nil, trail.records[0].ActorID)
+	assert.Equal(t, "aslak-jenkins", trail.records[0].Details["namespace"])
+
+	require.NoError(t, i.Unidle(context.Background(), tenantID))
+	assert.Equal(t, 2, c.replicas)
+	assert.Equal(t, "", c.previous)
+	assert.Nil(t, service.namespaces[0].IdledAt)
+	assert.Equal(t, []uuid.UUID{tenantID}, service.active)
+}
+
+func TestIdleRevertedOnActivity(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+
+	c := &cluster{replicas: 2}
+	server := httptest.NewServer(c)
+	defer server.Close()
+
+	service := &namespaces{
+		namespaces: []*tenant.Namespace{
+			{ID: uuid.NewV4(), TenantID: uuid.NewV4(), Name: "aslak-jenkins", Type: tenant.TypeJenkins},
+		},
+		activeWhileIdling: true,
+	}
+	clusters := cluster.NewRegistry(cluster.NilService{}, openshift.Config{MasterURL: server.URL}, nil)
+	trail := &auditTrail{}
+	i := idler.New(service, clusters, trail, time.Hour, time.Minute)
+
+	require.NoError(t, i.IdleInactive(context.Background()))
+	assert.Equal(t, 2, c.replicas)
+	assert.Equal(t, "", c.previous)
+	assert.Nil(t, service.namespaces[0].IdledAt)
+	require.Len(t, trail.records, 1)
+	assert.Equal(t, audit.OutcomeFailed, trail.records[0].Outcome)
+}
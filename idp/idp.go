@@ -0,0 +1,150 @@
+package idp
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"time"
+)
+
+// IdentityProvider issues the tokens of the callers and stores the tokens of their linked accounts,
+// e.g. the OpenShift account of the user
+type IdentityProvider interface {
+	// PublicKeys fetches the keys the tokens are signed with, mapped by key id
+	PublicKeys() (map[string]*rsa.PublicKey, error)
+	// BrokerToken fetches the linked account token of the user owning the token
+	BrokerToken(token string) (*BrokerToken, error)
+	// RefreshBrokerToken exchanges the refresh token for a new linked account token
+	RefreshBrokerToken(refreshToken string) (*BrokerToken, error)
+}
+
+// BrokerToken is the linked account token of a user stored by the identity provider
+type BrokerToken struct {
+	AccessToken  string
+	RefreshToken string
+	// ExpiresAt is zero if the provider did not tell when the token expires
+	ExpiresAt time.Time
+}
+
+// ErrUnknownKey is returned when no signing key is known for a key id
+type ErrUnknownKey struct {
+	Kid string
+}
+
+func (e ErrUnknownKey) Error() string {
+	return fmt.Sprintf("unknown signing key %q", e.Kid)
+}
+
+type jsonWebKey struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+type jsonWebKeySet struct {
+	Keys []jsonWebKey `json:"keys"`
+}
+
+// FetchJWKS fetches the RSA signing keys of the JSON Web Key Set at the URL, mapped by key id
+func FetchJWKS(client *http.Client, url string) (map[string]*rsa.PublicKey, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	var set jsonWebKeySet
+	err = doJSON(client, req, &set)
+	if err != nil {
+		return nil, err
+	}
+	keys := map[string]*rsa.PublicKey{}
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" || (k.Use != "" && k.Use != "sig") {
+			continue
+		}
+		key, err := parseRSAKey(k)
+		if err != nil {
+			return nil, fmt.Errorf("invalid key %q: %v", k.Kid, err)
+		}
+		keys[k.Kid] = key
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no RSA signing key found at %v", url)
+	}
+	return keys, nil
+}
+
+func parseRSAKey(k jsonWebKey) (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(k.N)
+	if err != nil {
+		return nil, err
+	}
+	e, err := base64.RawURLEncoding.DecodeString(k.E)
+	if err != nil {
+		return nil, err
+	}
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(n),
+		E: int(new(big.Int).SetBytes(e).Int64()),
+	}, nil
+}
+
+type tokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"`
+}
+
+// FetchToken performs the token request and reads the OAuth 2.0 token response
+func FetchToken(client *http.Client, req *http.Request) (*BrokerToken, error) {
+	var resp tokenResponse
+	err := doJSON(client, req, &resp)
+	if err != nil {
+		return nil, err
+	}
+	if resp.AccessToken == "" {
+		return nil, fmt.Errorf("no access token in response from %v %v", req.Method, req.URL)
+	}
+	token := &BrokerToken{
+		AccessToken:  resp.AccessToken,
+		RefreshToken: resp.RefreshToken,
+	}
+	if resp.ExpiresIn > 0 {
+		token.ExpiresAt = time.Now().Add(time.Duration(resp.ExpiresIn) * time.Second)
+	}
+	return token, nil
+}
+
+func doJSON(client *http.Client, req *http.Request, v interface{}) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Unknown response from %v %v:\n%v", req.Method, req.URL, string(b))
+	}
+	return json.Unmarshal(b, v)
+}
+
+// HTTPClient returns a client using the transport, or the default client if nil
+func HTTPClient(tr *http.Transport) *http.Client {
+	if tr != nil {
+		return &http.Client{
+			Transport: tr,
+		}
+	}
+	return http.DefaultClient
+}
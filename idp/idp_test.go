@@ -0,0 +1,41 @@
+package idp_test
+
+import (
+	"crypto/rsa"
+	"fmt"
+
+	"github.com/fabric8io/fabric8-init-tenant/idp"
+)
+
+type fakeProvider struct {
+	keys      map[string]*rsa.PublicKey
+	keysErr   error
+	fetches   int
+	fetched   []*idp.BrokerToken
+	refresh   *idp.BrokerToken
+	brokered  int
+	refreshes int
+}
+
+func (p *fakeProvider) PublicKeys() (map[string]*rsa.PublicKey, error) {
+	p.fetches++
+	return p.keys, p.keysErr
+}
+
+func (p *fakeProvider) BrokerToken(token string) (*idp.BrokerToken, error) {
+	if p.brokered >= len(p.fetched) {
+		return nil, fmt.Errorf("unexpected broker token request")
+	}
+	t := *p.fetched[p.brokered]
+	p.brokered++
+	return &t, nil
+}
+
+func (p *fakeProvider) RefreshBrokerToken(refreshToken string) (*idp.BrokerToken, error) {
+	p.refreshes++
+	if p.refresh == nil {
+		return nil, fmt.Errorf("refresh not supported")
+	}
+	t := *p.refresh
+	return &t, nil
+}
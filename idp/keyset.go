@@ -0,0 +1,103 @@
+package idp
+
+import (
+	"context"
+	"crypto/rsa"
+	"sync"
+	"time"
+
+	"github.com/almighty/almighty-core/log"
+)
+
+// KeySet caches the signing keys of the identity provider. Keys are fetched again when a token refers to an unknown key id,
+// at most once per refresh delay, so rotated keys are picked up without a restart.
+type KeySet struct {
+	provider     IdentityProvider
+	refreshDelay time.Duration
+
+	mu        sync.RWMutex
+	keys      map[string]*rsa.PublicKey
+	fetchedAt time.Time
+}
+
+// NewKeySet creates an empty KeySet for the identity provider. Call Refresh or Start to load the keys.
+func NewKeySet(provider IdentityProvider, refreshDelay time.Duration) *KeySet {
+	return &KeySet{
+		provider:     provider,
+		refreshDelay: refreshDelay,
+		keys:         map[string]*rsa.PublicKey{},
+	}
+}
+
+// Refresh fetches the signing keys. The known keys are kept if the identity provider can not be reached.
+func (s *KeySet) Refresh() error {
+	s.mu.Lock()
+	s.fetchedAt = time.Now()
+	s.mu.Unlock()
+
+	keys, err := s.provider.PublicKeys()
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.keys = keys
+	s.mu.Unlock()
+	return nil
+}
+
+// Start refreshes the signing keys right away and then on every interval until the context is done
+func (s *KeySet) Start(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if err := s.Refresh(); err != nil {
+				log.Error(ctx, map[string]interface{}{
+					"err": err,
+				}, "failed to refresh the signing keys")
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Loaded reports if any signing key is known
+func (s *KeySet) Loaded() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.keys) > 0
+}
+
+// Key returns the signing key with the given key id. An empty key id selects the only known key.
+func (s *KeySet) Key(kid string) (*rsa.PublicKey, error) {
+	if key := s.lookup(kid); key != nil {
+		return key, nil
+	}
+	s.mu.RLock()
+	recent := time.Since(s.fetchedAt) < s.refreshDelay
+	s.mu.RUnlock()
+	if !recent {
+		if err := s.Refresh(); err != nil {
+			return nil, err
+		}
+		if key := s.lookup(kid); key != nil {
+			return key, nil
+		}
+	}
+	return nil, ErrUnknownKey{Kid: kid}
+}
+
+func (s *KeySet) lookup(kid string) *rsa.PublicKey {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if kid == "" && len(s.keys) == 1 {
+		for _, key := range s.keys {
+			return key
+		}
+	}
+	return s.keys[kid]
+}
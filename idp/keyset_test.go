@@ -0,0 +1,71 @@
+package idp_test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/almighty/almighty-core/resource"
+	"github.com/fabric8io/fabric8-init-tenant/idp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeySet(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+
+	first, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+	second, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+
+	provider := &fakeProvider{keys: map[string]*rsa.PublicKey{"first": &first.PublicKey}}
+	set := idp.NewKeySet(provider, time.Hour)
+	assert.False(t, set.Loaded())
+
+	t.Run("fetch on first use", func(t *testing.T) {
+		key, err := set.Key("first")
+		require.NoError(t, err)
+		assert.Equal(t, first.PublicKey.N, key.N)
+		assert.True(t, set.Loaded())
+		assert.Equal(t, 1, provider.fetches)
+	})
+
+	t.Run("single key without kid", func(t *testing.T) {
+		key, err := set.Key("")
+		require.NoError(t, err)
+		assert.Equal(t, first.PublicKey.N, key.N)
+	})
+
+	t.Run("unknown kid within refresh delay", func(t *testing.T) {
+		provider.keys = map[string]*rsa.PublicKey{"first": &first.PublicKey, "second": &second.PublicKey}
+		_, err := set.Key("second")
+		assert.IsType(t, idp.ErrUnknownKey{}, err)
+		assert.Equal(t, 1, provider.fetches)
+	})
+
+	t.Run("refresh on unknown kid", func(t *testing.T) {
+		provider := &fakeProvider{keys: map[string]*rsa.PublicKey{"first": &first.PublicKey}}
+		set := idp.NewKeySet(provider, 0)
+		require.NoError(t, set.Refresh())
+		provider.keys = map[string]*rsa.PublicKey{"second": &second.PublicKey}
+		key, err := set.Key("second")
+		require.NoError(t, err)
+		assert.Equal(t, second.PublicKey.N, key.N)
+		_, err = set.Key("first")
+		assert.IsType(t, idp.ErrUnknownKey{}, err)
+	})
+
+	t.Run("keys kept when unreachable", func(t *testing.T) {
+		provider := &fakeProvider{keys: map[string]*rsa.PublicKey{"first": &first.PublicKey}}
+		set := idp.NewKeySet(provider, 0)
+		require.NoError(t, set.Refresh())
+		provider.keysErr = fmt.Errorf("connection refused")
+		assert.Error(t, set.Refresh())
+		assert.True(t, set.Loaded())
+		_, err := set.Key("first")
+		assert.NoError(t, err)
+	})
+}
@@ -0,0 +1,150 @@
+package idp
+
+import (
+	"crypto/rsa"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// Represents the OAuth 2.0 Token Exchange (RFC 8693) parameters
+const (
+	grantTypeTokenExchange = "urn:ietf:params:oauth:grant-type:token-exchange"
+	tokenTypeAccessToken   = "urn:ietf:params:oauth:token-type:access_token"
+)
+
+// OIDCConfig configures a generic OpenID Connect provider
+type OIDCConfig struct {
+	// IssuerURL is where the provider publishes its discovery document, /.well-known/openid-configuration
+	IssuerURL     string
+	ClientID      string
+	ClientSecret  string
+	HttpTransport *http.Transport
+	// BrokerTokenURL returns the linked account token when called with the user token. If not set, the user
+	// token is exchanged for a token of the Audience at the token endpoint of the provider.
+	BrokerTokenURL string
+	Audience       string
+}
+
+// OIDC is an OpenID Connect provider configured through discovery. The discovery document is fetched
+// on first use, so the service can start before the provider is reachable.
+type OIDC struct {
+	config OIDCConfig
+
+	mu        sync.Mutex
+	discovery *discovery
+}
+
+type discovery struct {
+	Issuer        string `json:"issuer"`
+	JWKSURI       string `json:"jwks_uri"`
+	TokenEndpoint string `json:"token_endpoint"`
+}
+
+// NewOIDC creates an OIDC provider
+func NewOIDC(config OIDCConfig) *OIDC {
+	return &OIDC{config: config}
+}
+
+func (p *OIDC) discover() (*discovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.discovery != nil {
+		return p.discovery, nil
+	}
+	issuer := strings.TrimSuffix(p.config.IssuerURL, "/")
+	req, err := http.NewRequest("GET", issuer+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	var d discovery
+	err = doJSON(p.client(), req, &d)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSuffix(d.Issuer, "/") != issuer {
+		return nil, fmt.Errorf("discovered issuer %v does not match %v", d.Issuer, p.config.IssuerURL)
+	}
+	if d.JWKSURI == "" {
+		return nil, fmt.Errorf("no jwks_uri discovered for %v", p.config.IssuerURL)
+	}
+	p.discovery = &d
+	return p.discovery, nil
+}
+
+func (p *OIDC) client() *http.Client {
+	return HTTPClient(p.config.HttpTransport)
+}
+
+// PublicKeys implements IdentityProvider
+func (p *OIDC) PublicKeys() (map[string]*rsa.PublicKey, error) {
+	d, err := p.discover()
+	if err != nil {
+		return nil, err
+	}
+	return FetchJWKS(p.client(), d.JWKSURI)
+}
+
+// BrokerToken implements IdentityProvider
+func (p *OIDC) BrokerToken(token string) (*BrokerToken, error) {
+	if p.config.BrokerTokenURL != "" {
+		req, err := http.NewRequest("GET", p.config.BrokerTokenURL, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Accept", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		return FetchToken(p.client(), req)
+	}
+	form := url.Values{
+		"grant_type":           {grantTypeTokenExchange},
+		"subject_token":        {token},
+		"subject_token_type":   {tokenTypeAccessToken},
+		"requested_token_type": {tokenTypeAccessToken},
+	}
+	if p.config.Audience != "" {
+		form.Set("audience", p.config.Audience)
+	}
+	return p.tokenRequest(form)
+}
+
+// RefreshBrokerToken implements IdentityProvider
+func (p *OIDC) RefreshBrokerToken(refreshToken string) (*BrokerToken, error) {
+	token, err := p.tokenRequest(url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {refreshToken},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if token.RefreshToken == "" {
+		// the refresh token is kept unless a new one is issued
+		token.RefreshToken = refreshToken
+	}
+	return token, nil
+}
+
+func (p *OIDC) tokenRequest(form url.Values) (*BrokerToken, error) {
+	d, err := p.discover()
+	if err != nil {
+		return nil, err
+	}
+	if d.TokenEndpoint == "" {
+		return nil, fmt.Errorf("no token_endpoint discovered for %v", p.config.IssuerURL)
+	}
+	form.Set("client_id", p.config.ClientID)
+	if p.config.ClientSecret != "" {
+		form.Set("client_secret", p.config.ClientSecret)
+	}
+	req, err := http.NewRequest("POST", d.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return FetchToken(p.client(), req)
+}
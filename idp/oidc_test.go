@@ -0,0 +1,92 @@
+package idp_test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/almighty/almighty-core/resource"
+	"github.com/fabric8io/fabric8-init-tenant/idp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOIDC(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+
+	var issuer string
+	discoveries := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/.well-known/openid-configuration":
+			discoveries++
+			fmt.Fprintf(w, `{"issuer":%q,"jwks_uri":"%v/keys","token_endpoint":"%v/token"}`, issuer, issuer, issuer)
+		case "/keys":
+			fmt.Fprintf(w, `{"keys":[{"kid":"k1","kty":"RSA","n":%q,"e":%q}]}`,
+				base64.RawURLEncoding.EncodeToString(key.PublicKey.N.Bytes()),
+				base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.PublicKey.E)).Bytes()))
+		case "/token":
+			require.NoError(t, r.ParseForm())
+			assert.Equal(t, "tenant", r.PostForm.Get("client_id"))
+			switch r.PostForm.Get("grant_type") {
+			case "urn:ietf:params:oauth:grant-type:token-exchange":
+				assert.Equal(t, "user-token", r.PostForm.Get("subject_token"))
+				assert.Equal(t, "openshift", r.PostForm.Get("audience"))
+				fmt.Fprint(w, `{"access_token":"exchanged","refresh_token":"refresh","expires_in":300}`)
+			case "refresh_token":
+				assert.Equal(t, "refresh", r.PostForm.Get("refresh_token"))
+				fmt.Fprint(w, `{"access_token":"refreshed","expires_in":300}`)
+			default:
+				w.WriteHeader(http.StatusBadRequest)
+			}
+		case "/broker":
+			assert.Equal(t, "Bearer user-token", r.Header.Get("Authorization"))
+			fmt.Fprint(w, `{"access_token":"brokered"}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	issuer = server.URL
+
+	provider := idp.NewOIDC(idp.OIDCConfig{IssuerURL: server.URL + "/", ClientID: "tenant", Audience: "openshift"})
+
+	keys, err := provider.PublicKeys()
+	require.NoError(t, err)
+	assert.Equal(t, key.PublicKey.N, keys["k1"].N)
+
+	token, err := provider.BrokerToken("user-token")
+	require.NoError(t, err)
+	assert.Equal(t, "exchanged", token.AccessToken)
+
+	token, err = provider.RefreshBrokerToken(token.RefreshToken)
+	require.NoError(t, err)
+	assert.Equal(t, "refreshed", token.AccessToken)
+	assert.Equal(t, "refresh", token.RefreshToken)
+	assert.Equal(t, 1, discoveries)
+
+	brokered := idp.NewOIDC(idp.OIDCConfig{IssuerURL: server.URL, BrokerTokenURL: server.URL + "/broker"})
+	token, err = brokered.BrokerToken("user-token")
+	require.NoError(t, err)
+	assert.Equal(t, "brokered", token.AccessToken)
+}
+
+func TestOIDCIssuerMismatch(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"issuer":"https://sso.example.com","jwks_uri":"https://sso.example.com/keys"}`)
+	}))
+	defer server.Close()
+
+	_, err := idp.NewOIDC(idp.OIDCConfig{IssuerURL: server.URL}).PublicKeys()
+	assert.Error(t, err)
+}
@@ -0,0 +1,79 @@
+package idp
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// defaultBrokerTokenTTL is how long a broker token without expiry is cached
+	defaultBrokerTokenTTL = 5 * time.Minute
+	// refreshTokenTTL is how long an expired broker token is kept to be refreshed
+	refreshTokenTTL = time.Hour
+)
+
+// TokenCache caches the broker tokens by the subject and ID (jti) of the token they were fetched
+// with. A cached token is used until it is about to expire, then it is refreshed if the identity
+// provider returned a refresh token, or fetched again.
+type TokenCache struct {
+	provider IdentityProvider
+	margin   time.Duration
+
+	mu     sync.Mutex
+	tokens map[string]*BrokerToken
+}
+
+// NewTokenCache creates a TokenCache. Tokens expiring within the margin are no longer used.
+func NewTokenCache(provider IdentityProvider, margin time.Duration) *TokenCache {
+	return &TokenCache{
+		provider: provider,
+		margin:   margin,
+		tokens:   map[string]*BrokerToken{},
+	}
+}
+
+// OpenshiftToken returns the Openshift token of the user owning the token
+func (c *TokenCache) OpenshiftToken(subject, jti, userToken string) (string, error) {
+	key := subject + "/" + jti
+	now := time.Now()
+
+	c.mu.Lock()
+	cached := c.tokens[key]
+	c.mu.Unlock()
+	if cached != nil && now.Add(c.margin).Before(cached.ExpiresAt) {
+		return cached.AccessToken, nil
+	}
+
+	var token *BrokerToken
+	var err error
+	if cached != nil && cached.RefreshToken != "" {
+		token, err = c.provider.RefreshBrokerToken(cached.RefreshToken)
+	}
+	if token == nil {
+		token, err = c.provider.BrokerToken(userToken)
+	}
+	if err != nil {
+		return "", err
+	}
+	if token.ExpiresAt.IsZero() {
+		token.ExpiresAt = now.Add(defaultBrokerTokenTTL)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for k, t := range c.tokens {
+		if t.ExpiresAt.Before(now) && (t.RefreshToken == "" || t.ExpiresAt.Add(refreshTokenTTL).Before(now)) {
+			delete(c.tokens, k)
+		}
+	}
+	c.tokens[key] = token
+	return token.AccessToken, nil
+}
+
+// TokenSource returns a source of the Openshift token of the user owning the token, the
+// token is refreshed when it is about to expire
+func (c *TokenCache) TokenSource(subject, jti, userToken string) func() (string, error) {
+	return func() (string, error) {
+		return c.OpenshiftToken(subject, jti, userToken)
+	}
+}
@@ -0,0 +1,64 @@
+package idp_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/almighty/almighty-core/resource"
+	"github.com/fabric8io/fabric8-init-tenant/idp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenCache(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+
+	t.Run("cached by subject and jti", func(t *testing.T) {
+		provider := &fakeProvider{fetched: []*idp.BrokerToken{
+			{AccessToken: "fetched-1", ExpiresAt: time.Now().Add(time.Hour)},
+			{AccessToken: "fetched-2", ExpiresAt: time.Now().Add(time.Hour)},
+		}}
+		cache := idp.NewTokenCache(provider, time.Minute)
+		token, err := cache.OpenshiftToken("user", "jti-1", "user-token")
+		require.NoError(t, err)
+		assert.Equal(t, "fetched-1", token)
+		token, err = cache.OpenshiftToken("user", "jti-1", "user-token")
+		require.NoError(t, err)
+		assert.Equal(t, "fetched-1", token)
+		token, err = cache.OpenshiftToken("user", "jti-2", "user-token")
+		require.NoError(t, err)
+		assert.Equal(t, "fetched-2", token)
+		assert.Equal(t, 2, provider.brokered)
+	})
+
+	t.Run("refreshed when expiring", func(t *testing.T) {
+		provider := &fakeProvider{
+			fetched: []*idp.BrokerToken{
+				{AccessToken: "fetched", RefreshToken: "refresh", ExpiresAt: time.Now().Add(30 * time.Second)},
+			},
+			refresh: &idp.BrokerToken{AccessToken: "refreshed", RefreshToken: "refresh", ExpiresAt: time.Now().Add(time.Hour)},
+		}
+		source := idp.NewTokenCache(provider, time.Minute).TokenSource("user", "jti-1", "user-token")
+		for _, expected := range []string{"fetched", "refreshed", "refreshed"} {
+			token, err := source()
+			require.NoError(t, err)
+			assert.Equal(t, expected, token)
+		}
+		assert.Equal(t, 1, provider.brokered)
+		assert.Equal(t, 1, provider.refreshes)
+	})
+
+	t.Run("fetched again when refresh fails", func(t *testing.T) {
+		provider := &fakeProvider{fetched: []*idp.BrokerToken{
+			{AccessToken: "fetched-1", RefreshToken: "refresh", ExpiresAt: time.Now().Add(30 * time.Second)},
+			{AccessToken: "fetched-2", ExpiresAt: time.Now().Add(time.Hour)},
+		}}
+		cache := idp.NewTokenCache(provider, time.Minute)
+		_, err := cache.OpenshiftToken("user", "jti-1", "user-token")
+		require.NoError(t, err)
+		token, err := cache.OpenshiftToken("user", "jti-1", "user-token")
+		require.NoError(t, err)
+		assert.Equal(t, "fetched-2", token)
+		assert.Equal(t, 1, provider.refreshes)
+	})
+}
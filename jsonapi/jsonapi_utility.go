@@ -19,8 +19,26 @@ const (
 	ErrorCodeInternalError     = "internal_error"
 	ErrorCodeUnauthorizedError = "unauthorized_error"
 	ErrorCodeJWTSecurityError  = "jwt_security_error"
+	ErrorCodeTokenExpired      = "token_expired"
+	ErrorCodeInvalidAudience   = "invalid_audience"
+	ErrorCodeForbiddenError    = "forbidden_error"
 )
 
+// ForbiddenError means the caller is known but not allowed to perform the operation
+type ForbiddenError struct {
+	msg string
+}
+
+// NewForbiddenError returns the custom defined error of type ForbiddenError.
+func NewForbiddenError(msg string) ForbiddenError {
+	return ForbiddenError{msg: msg}
+}
+
+// Error implements the error interface
+func (err ForbiddenError) Error() string {
+	return err.msg
+}
+
 // ErrorToJSONAPIError returns the JSONAPI representation
 // of an error and the HTTP status code that will be associated with it.
 // This function knows about the models package and the errors from there
@@ -47,7 +65,7 @@ func ErrorToJSONAPIError(err error) (app.JSONAPIError, int) {
 	case errors.VersionConflictError:
 		code = ErrorCodeVersionConflict
 		title = "Version conflict error"
-		statusCode = http.StatusBadRequest
+		statusCode = http.StatusConflict
 	case errors.InternalError:
 		code = ErrorCodeInternalError
 		title = "Internal error"
@@ -56,6 +74,10 @@ func ErrorToJSONAPIError(err error) (app.JSONAPIError, int) {
 		code = ErrorCodeUnauthorizedError
 		title = "Unauthorized error"
 		statusCode = http.StatusUnauthorized
+	case ForbiddenError:
+		code = ErrorCodeForbiddenError
+		title = "Forbidden error"
+		statusCode = http.StatusForbidden
 	default:
 		code = ErrorCodeUnknownError
 		title = "Unknown error"
@@ -114,6 +136,11 @@ type Unauthorized interface {
 	Unauthorized(*app.JSONAPIErrors) error
 }
 
+// Conflict represent a Context that can return a Conflict HTTP status
+type Conflict interface {
+	Conflict(*app.JSONAPIErrors) error
+}
+
 // Forbidden represent a Context that can return a Unauthorized HTTP status
 type Forbidden interface {
 	Forbidden(*app.JSONAPIErrors) error
@@ -140,8 +167,10 @@ func JSONErrorResponse(x InternalServerError, err error) error {
 		if ctx, ok := x.(Forbidden); ok {
 			return errs.WithStack(ctx.Forbidden(jsonErr))
 		}
-	default:
-		return errs.WithStack(x.InternalServerError(jsonErr))
+	case http.StatusConflict:
+		if ctx, ok := x.(Conflict); ok {
+			return errs.WithStack(ctx.Conflict(jsonErr))
+		}
 	}
-	return nil
+	return errs.WithStack(x.InternalServerError(jsonErr))
 }
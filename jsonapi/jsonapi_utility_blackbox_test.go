@@ -60,6 +60,22 @@ func TestErrorToJSONAPIError(t *testing.T) {
 	require.Equal(t, jsonapi.ErrorCodeUnauthorizedError, *jerr.Code)
 	require.Equal(t, strconv.Itoa(httpStatus), *jerr.Status)
 
+	// test forbidden error
+	jerr, httpStatus = jsonapi.ErrorToJSONAPIError(jsonapi.NewForbiddenError("foo"))
+	require.Equal(t, http.StatusForbidden, httpStatus)
+	require.NotNil(t, jerr.Code)
+	require.NotNil(t, jerr.Status)
+	require.Equal(t, jsonapi.ErrorCodeForbiddenError, *jerr.Code)
+	require.Equal(t, strconv.Itoa(httpStatus), *jerr.Status)
+
+	// test version conflict error
+	jerr, httpStatus = jsonapi.ErrorToJSONAPIError(errors.NewVersionConflictError("foo"))
+	require.Equal(t, http.StatusConflict, httpStatus)
+	require.NotNil(t, jerr.Code)
+	require.NotNil(t, jerr.Status)
+	require.Equal(t, jsonapi.ErrorCodeVersionConflict, *jerr.Code)
+	require.Equal(t, strconv.Itoa(httpStatus), *jerr.Status)
+
 	// test unspecified error
 	jerr, httpStatus = jsonapi.ErrorToJSONAPIError(fmt.Errorf("foobar"))
 	require.Equal(t, http.StatusInternalServerError, httpStatus)
@@ -68,3 +84,24 @@ func TestErrorToJSONAPIError(t *testing.T) {
 	require.Equal(t, jsonapi.ErrorCodeUnknownError, *jerr.Code)
 	require.Equal(t, strconv.Itoa(httpStatus), *jerr.Status)
 }
+
+// internalServerErrorOnly is a context without a response for the specific error status
+type internalServerErrorOnly struct {
+	errors *app.JSONAPIErrors
+}
+
+func (c *internalServerErrorOnly) InternalServerError(errors *app.JSONAPIErrors) error {
+	c.errors = errors
+	return nil
+}
+
+func TestJSONErrorResponseFallsBackToInternalServerError(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+
+	ctx := &internalServerErrorOnly{}
+	jsonapi.JSONErrorResponse(ctx, errors.NewVersionConflictError("foo"))
+	require.NotNil(t, ctx.errors)
+	require.Len(t, ctx.errors.Errors, 1)
+	require.Equal(t, jsonapi.ErrorCodeVersionConflict, *ctx.errors.Errors[0].Code)
+}
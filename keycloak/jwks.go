@@ -0,0 +1,18 @@
+package keycloak
+
+import (
+	"crypto/rsa"
+	"fmt"
+
+	"github.com/fabric8io/fabric8-init-tenant/idp"
+)
+
+// JWKSURL return endpoint for the realm signing keys "{BaseURL}/auth/realms/{Realm}/protocol/openid-connect/certs"
+func (c Config) JWKSURL() string {
+	return fmt.Sprintf("%v/protocol/openid-connect/certs", c.RealmAuthURL())
+}
+
+// GetPublicKeys fetches the RSA signing keys of the Keycloak realm, mapped by key id
+func GetPublicKeys(config Config) (map[string]*rsa.PublicKey, error) {
+	return idp.FetchJWKS(idp.HTTPClient(config.HttpTransport), config.JWKSURL())
+}
@@ -0,0 +1,55 @@
+package keycloak_test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/almighty/almighty-core/resource"
+	"github.com/fabric8io/fabric8-init-tenant/keycloak"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func jwk(kid string, key *rsa.PublicKey) string {
+	return fmt.Sprintf(`{"kid":%q,"kty":"RSA","alg":"RS256","use":"sig","n":%q,"e":%q}`,
+		kid,
+		base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()))
+}
+
+func TestGetPublicKeys(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/auth/realms/fabric8/protocol/openid-connect/certs", r.URL.Path)
+		fmt.Fprintf(w, `{"keys":[%v,{"kid":"enc","kty":"RSA","use":"enc","n":"AQAB","e":"AQAB"}]}`, jwk("first", &key.PublicKey))
+	}))
+	defer server.Close()
+
+	keys, err := keycloak.GetPublicKeys(keycloak.Config{BaseURL: server.URL, Realm: "fabric8"})
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	assert.Equal(t, key.PublicKey.N, keys["first"].N)
+	assert.Equal(t, key.PublicKey.E, keys["first"].E)
+}
+
+func TestGetPublicKeysUnreachable(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	_, err := keycloak.GetPublicKeys(keycloak.Config{BaseURL: server.URL, Realm: "fabric8"})
+	assert.Error(t, err)
+}
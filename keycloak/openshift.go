@@ -1,20 +1,25 @@
 package keycloak
 
 import (
-	"bytes"
 	"fmt"
 	"net/http"
-	"net/http/httputil"
-	"unsafe"
+	"net/url"
+	"strings"
 
-	yaml "gopkg.in/yaml.v2"
+	"github.com/fabric8io/fabric8-init-tenant/idp"
 )
 
 // Config contains basic configuration data for Keycloak
 type Config struct {
-	BaseURL string
-	Realm   string
-	Broker  string
+	BaseURL       string
+	Realm         string
+	Broker        string
+	HttpTransport *http.Transport
+	// BrokerRefreshURL is the token endpoint of the identity provider the broker tokens are refreshed with.
+	// Expired broker tokens are fetched from Keycloak again if not set.
+	BrokerRefreshURL   string
+	BrokerClientID     string
+	BrokerClientSecret string
 }
 
 // RealmAuthURL return endpoint for realm auth config "{BaseURL}/auth/realms/{Realm}/broker/{Broker}/token"
@@ -29,7 +34,7 @@ func (c Config) BrokerTokenURL() string {
 
 // OpenshiftToken fetches the Openshift token defined for the current user in Keycloak
 func OpenshiftToken(config Config, token string) (string, error) {
-	ut, err := get(config.BrokerTokenURL(), token)
+	ut, err := GetBrokerToken(config, token)
 	if err != nil {
 		return "", err
 	}
@@ -37,12 +42,9 @@ func OpenshiftToken(config Config, token string) (string, error) {
 	return ut.AccessToken, nil
 }
 
-type usertoken struct {
-	AccessToken string `yaml:"access_token"`
-}
-
-func get(url, token string) (*usertoken, error) {
-	req, err := http.NewRequest("GET", url, nil)
+// GetBrokerToken fetches the identity provider token defined for the current user in Keycloak
+func GetBrokerToken(config Config, token string) (*idp.BrokerToken, error) {
+	req, err := http.NewRequest("GET", config.BrokerTokenURL(), nil)
 	if err != nil {
 		return nil, err
 	}
@@ -50,32 +52,34 @@ func get(url, token string) (*usertoken, error) {
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", "Bearer "+token)
 
-	// for debug only
-	rb, _ := httputil.DumpRequest(req, true)
-	if false {
-		fmt.Println(string(rb))
-	}
+	return idp.FetchToken(idp.HTTPClient(config.HttpTransport), req)
+}
 
-	client := createHttpClient()
-	resp, err := client.Do(req)
+// RefreshBrokerToken exchanges the refresh token for a new identity provider token
+func RefreshBrokerToken(config Config, refreshToken string) (*idp.BrokerToken, error) {
+	if config.BrokerRefreshURL == "" {
+		return nil, fmt.Errorf("no refresh URL configured for broker %v", config.Broker)
+	}
+	form := url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {refreshToken},
+		"client_id":     {config.BrokerClientID},
+		"client_secret": {config.BrokerClientSecret},
+	}
+	req, err := http.NewRequest("POST", config.BrokerRefreshURL, strings.NewReader(form.Encode()))
 	if err != nil {
 		return nil, err
 	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 
-	defer resp.Body.Close()
-
-	buf := new(bytes.Buffer)
-	buf.ReadFrom(resp.Body)
-	b := buf.Bytes()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("Unknown response:\n%v\n%v", *(*string)(unsafe.Pointer(&b)), string(rb))
-	}
-
-	var u usertoken
-	err = yaml.Unmarshal(b, &u)
+	token, err := idp.FetchToken(idp.HTTPClient(config.HttpTransport), req)
 	if err != nil {
 		return nil, err
 	}
-	return &u, nil
+	if token.RefreshToken == "" {
+		// the refresh token is kept unless a new one is issued
+		token.RefreshToken = refreshToken
+	}
+	return token, nil
 }
@@ -1,10 +1,16 @@
 package keycloak_test
 
 import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
+	"github.com/almighty/almighty-core/resource"
 	"github.com/fabric8io/fabric8-init-tenant/keycloak"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // ignore for now, require vcr recording
@@ -22,3 +28,46 @@ func xTestOpenshiftToken(t *testing.T) {
 	assert.NoError(t, err)
 	assert.NotEqual(t, "", u)
 }
+
+func TestBrokerToken(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/auth/realms/fabric8/broker/openshift-v3/token":
+			assert.Equal(t, "Bearer keycloak-token", r.Header.Get("Authorization"))
+			fmt.Fprint(w, `{"access_token":"openshift-token","refresh_token":"refresh","expires_in":3600}`)
+		case "/oauth/token":
+			require.NoError(t, r.ParseForm())
+			assert.Equal(t, "refresh_token", r.PostForm.Get("grant_type"))
+			assert.Equal(t, "refresh", r.PostForm.Get("refresh_token"))
+			assert.Equal(t, "tenant", r.PostForm.Get("client_id"))
+			fmt.Fprint(w, `{"access_token":"refreshed-token","expires_in":3600}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	c := keycloak.Config{
+		BaseURL: server.URL,
+		Realm:   "fabric8",
+		Broker:  "openshift-v3",
+	}
+
+	token, err := keycloak.GetBrokerToken(c, "keycloak-token")
+	require.NoError(t, err)
+	assert.Equal(t, "openshift-token", token.AccessToken)
+	assert.Equal(t, "refresh", token.RefreshToken)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), token.ExpiresAt, time.Minute)
+
+	_, err = keycloak.RefreshBrokerToken(c, "refresh")
+	assert.Error(t, err)
+
+	c.BrokerRefreshURL = server.URL + "/oauth/token"
+	c.BrokerClientID = "tenant"
+	token, err = keycloak.RefreshBrokerToken(c, "refresh")
+	require.NoError(t, err)
+	assert.Equal(t, "refreshed-token", token.AccessToken)
+	assert.Equal(t, "refresh", token.RefreshToken)
+}
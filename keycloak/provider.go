@@ -0,0 +1,32 @@
+package keycloak
+
+import (
+	"crypto/rsa"
+
+	"github.com/fabric8io/fabric8-init-tenant/idp"
+)
+
+// Provider is the Keycloak realm as identity provider, with the OpenShift account linked through the broker
+type Provider struct {
+	config Config
+}
+
+// NewProvider creates a Provider for the configured realm and broker
+func NewProvider(config Config) *Provider {
+	return &Provider{config: config}
+}
+
+// PublicKeys implements idp.IdentityProvider
+func (p *Provider) PublicKeys() (map[string]*rsa.PublicKey, error) {
+	return GetPublicKeys(p.config)
+}
+
+// BrokerToken implements idp.IdentityProvider
+func (p *Provider) BrokerToken(token string) (*idp.BrokerToken, error) {
+	return GetBrokerToken(p.config, token)
+}
+
+// RefreshBrokerToken implements idp.IdentityProvider
+func (p *Provider) RefreshBrokerToken(refreshToken string) (*idp.BrokerToken, error) {
+	return RefreshBrokerToken(p.config, refreshToken)
+}
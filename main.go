@@ -1,8 +1,9 @@
 package main
 
 import (
-	"crypto/tls"
+	"context"
 	"flag"
+	"fmt"
 	"io/ioutil"
 	"net/http"
 	"os"
@@ -14,17 +15,27 @@ import (
 	"github.com/Sirupsen/logrus"
 	"github.com/almighty/almighty-core/log"
 	"github.com/fabric8io/fabric8-init-tenant/app"
+	"github.com/fabric8io/fabric8-init-tenant/audit"
+	"github.com/fabric8io/fabric8-init-tenant/auth"
+	"github.com/fabric8io/fabric8-init-tenant/cluster"
 	"github.com/fabric8io/fabric8-init-tenant/configuration"
 	"github.com/fabric8io/fabric8-init-tenant/controller"
+	"github.com/fabric8io/fabric8-init-tenant/health"
+	"github.com/fabric8io/fabric8-init-tenant/idler"
+	"github.com/fabric8io/fabric8-init-tenant/idp"
 	"github.com/fabric8io/fabric8-init-tenant/jsonapi"
 	"github.com/fabric8io/fabric8-init-tenant/keycloak"
 	"github.com/fabric8io/fabric8-init-tenant/migration"
 	"github.com/fabric8io/fabric8-init-tenant/openshift"
+	"github.com/fabric8io/fabric8-init-tenant/progress"
+	"github.com/fabric8io/fabric8-init-tenant/relocate"
 	"github.com/fabric8io/fabric8-init-tenant/tenant"
+	"github.com/fabric8io/fabric8-init-tenant/transport"
+	"github.com/fabric8io/fabric8-init-tenant/upgrade"
+	"github.com/fabric8io/fabric8-init-tenant/webhook"
 	"github.com/goadesign/goa"
 	"github.com/goadesign/goa/middleware"
 	"github.com/goadesign/goa/middleware/gzip"
-	goajwt "github.com/goadesign/goa/middleware/security/jwt"
 	"github.com/jinzhu/gorm"
 
 	goalogrus "github.com/goadesign/goa/logging/logrus"
@@ -74,17 +85,24 @@ func main() {
 		}
 	}
 
-	var tr *http.Transport
-	if config.APIServerInsecureSkipTLSVerify() {
-		tr = &http.Transport{
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-		}
+	openshiftTransport, err := config.GetOpenshiftTransportConfig()
+	if err != nil {
+		logrus.Panic(nil, map[string]interface{}{
+			"err": err,
+		}, "failed to read the openshift TLS configuration")
+	}
+	tr, err := transport.New(openshiftTransport)
+	if err != nil {
+		logrus.Panic(nil, map[string]interface{}{
+			"err": err,
+		}, "invalid openshift TLS configuration")
 	}
 
 	openshiftConfig := openshift.Config{
 		MasterURL:     config.GetOpenshiftTenantMasterURL(),
 		Token:         serviceToken,
 		HttpTransport: tr,
+		Impersonate:   config.IsOpenshiftImpersonate(),
 	}
 
 	openshiftMasterUser, err := openshift.WhoAmI(openshiftConfig)
@@ -95,44 +113,181 @@ func main() {
 	}
 	openshiftConfig.MasterUser = openshiftMasterUser
 
-	keycloakConfig := keycloak.Config{
-		BaseURL: config.GetKeycloakURL(),
-		Realm:   config.GetKeycloakRealm(),
-		Broker:  config.GetKeycloakOpenshiftBroker(),
+	fixedPlacement, err := config.GetPlacementFixed()
+	if err != nil {
+		logrus.Panic(nil, map[string]interface{}{
+			"err": err,
+		}, "failed to read the placement configuration")
+	}
+	placementPolicy, err := cluster.NewPolicy(config.GetPlacementPolicy(), config.GetPlacementLabels(), fixedPlacement)
+	if err != nil {
+		logrus.Panic(nil, map[string]interface{}{
+			"err": err,
+		}, "failed to create the placement policy")
 	}
 
-	templateVars, err := config.GetTemplateValues()
+	clusters := cluster.NewRegistry(cluster.NewDBService(db), openshiftConfig, placementPolicy)
+	err = clusters.Seed(clusterSeeds(config, openshiftConfig, openshiftTransport))
 	if err != nil {
-		panic(err)
+		logrus.Panic(nil, map[string]interface{}{
+			"err": err,
+		}, "failed to register clusters")
 	}
 
-	publicKey, err := keycloak.GetPublicKey(keycloakConfig)
+	keycloakTransport, err := config.GetKeycloakTransportConfig()
 	if err != nil {
-		log.Panic(nil, map[string]interface{}{
+		logrus.Panic(nil, map[string]interface{}{
+			"err": err,
+		}, "failed to read the keycloak TLS configuration")
+	}
+	keycloakTr, err := transport.New(keycloakTransport)
+	if err != nil {
+		logrus.Panic(nil, map[string]interface{}{
 			"err": err,
-		}, "failed to parse public token")
+		}, "invalid keycloak TLS configuration")
+	}
+
+	keycloakConfig := keycloak.Config{
+		BaseURL:       config.GetKeycloakURL(),
+		Realm:         config.GetKeycloakRealm(),
+		Broker:        config.GetKeycloakOpenshiftBroker(),
+		HttpTransport: keycloakTr,
+
+		BrokerRefreshURL:   config.GetKeycloakOpenshiftRefreshURL(),
+		BrokerClientID:     config.GetKeycloakOpenshiftClientID(),
+		BrokerClientSecret: config.GetKeycloakOpenshiftClientSecret(),
+	}
+	var provider idp.IdentityProvider
+	switch config.GetIdentityProvider() {
+	case configuration.IdentityProviderKeycloak:
+		provider = keycloak.NewProvider(keycloakConfig)
+	case configuration.IdentityProviderOIDC:
+		provider = idp.NewOIDC(idp.OIDCConfig{
+			IssuerURL:      config.GetOIDCIssuerURL(),
+			ClientID:       config.GetOIDCClientID(),
+			ClientSecret:   config.GetOIDCClientSecret(),
+			HttpTransport:  keycloakTr,
+			BrokerTokenURL: config.GetOIDCBrokerTokenURL(),
+			Audience:       config.GetOIDCAudience(),
+		})
+	default:
+		log.Panic(nil, map[string]interface{}{
+			"identity_provider": config.GetIdentityProvider(),
+		}, "unknown identity provider")
+	}
+	brokerTokens := idp.NewTokenCache(provider, config.GetKeycloakOpenshiftTokenMargin())
+
+	templateVars, err := config.GetTemplateValues()
+	if err != nil {
+		panic(err)
 	}
 
+	// the identity provider may not be reachable yet, tokens are rejected until the signing keys are fetched
+	keys := idp.NewKeySet(provider, config.GetKeycloakKeysRefreshDelay())
+	keys.Start(context.Background(), config.GetKeycloakKeysRefreshInterval())
+
 	// Create service
 	service := goa.New("tenant")
 
 	// Mount middleware
 	service.Use(middleware.RequestID())
 	service.Use(middleware.LogRequest(config.IsDeveloperModeEnabled()))
-	service.Use(gzip.Middleware(9))
+	service.Use(withoutEventStreams(gzip.Middleware(9)))
 	service.Use(jsonapi.ErrorHandler(service, true))
 	service.Use(middleware.Recover())
 	service.WithLogger(goalogrus.New(log.Logger()))
-	app.UseJWTMiddleware(service, goajwt.New(publicKey, nil, app.NewJWTSecurity()))
+	validator := auth.Validator{
+		Issuer:         config.GetKeycloakTokenIssuer(),
+		Audiences:      config.GetKeycloakTokenAudiences(),
+		Leeway:         config.GetKeycloakTokenLeeway(),
+		RequiredClaims: config.GetKeycloakTokenRequiredClaims(),
+	}
+	app.UseJWTMiddleware(service, auth.New(keys.Key, validator, config.GetKeycloakTokenClaimNames(), app.NewJWTSecurity()))
+
+	monitor := health.NewMonitor(config.GetHealthCheckTimeout())
+	monitor.Register("identity-provider", health.IdentityProvider(provider))
+	monitor.Register("templates", health.Templates(openshiftConfig))
+	registered, err := clusters.Clusters()
+	if err != nil {
+		log.Panic(nil, map[string]interface{}{
+			"err": err,
+		}, "failed to list the registered clusters")
+	}
+	for _, c := range registered {
+		monitor.Register("openshift-api "+c.APIURL, health.ClusterAPI(clusters, c.APIURL))
+		monitor.Register("openshift-whoami "+c.APIURL, health.ClusterToken(clusters, c.APIURL))
+	}
+	monitor.Start(context.Background(), config.GetHealthCheckInterval())
+
+	readiness := health.NewMonitor(config.GetHealthCheckTimeout())
+	readiness.Register("database", health.Migrated(db.DB()))
+	readiness.Register("signing-keys", func() error {
+		if !keys.Loaded() {
+			return fmt.Errorf("signing keys not loaded")
+		}
+		return nil
+	})
+	readiness.Register("openshift-api", health.AnyCluster(clusters))
+	readiness.Start(context.Background(), config.GetHealthCheckInterval())
 
 	// Mount "status" controller
-	statusCtrl := controller.NewStatusController(service, db)
+	statusCtrl := controller.NewStatusController(service, db, monitor, readiness)
 	app.MountStatusController(service, statusCtrl)
 
+	tenantService := tenant.NewDBService(db)
+	auditor := audit.NewDBService(db)
+	isAdmin := controller.AnyAdmin(
+		controller.AdminSubjects(config.GetAdminSubjects()),
+		controller.AdminRoles(config.GetAdminRoles(), config.GetAdminRolesClient()))
+
+	progressBroker := progress.NewBroker(progress.DefaultRetention)
+
+	webhooks := webhook.NewDBService(db)
+	dispatcher := webhook.NewDispatcher(webhooks, webhook.Options{MaxAttempts: config.GetWebhookMaxAttempts()})
+	dispatcher.Start(context.Background(), config.GetWebhookDispatchInterval())
+
+	tenantIdler := idler.New(tenantService, clusters, auditor, config.GetIdlerTimeout(), config.GetIdlerInterval())
+	if config.IsIdlerEnabled() {
+		tenantIdler.Start(context.Background())
+	}
+
 	// Mount "tenant" controller
-	tenantCtrl := controller.NewTenantController(service, tenant.NewDBService(db), keycloakConfig, openshiftConfig, templateVars)
+	tenantCtrl := controller.NewTenantController(service, tenantService, brokerTokens, clusters, templateVars, tenantIdler, auditor, progressBroker)
 	app.MountTenantController(service, tenantCtrl)
 
+	migrator := relocate.New(tenantService, clusters, controller.ProvisionTenant(templateVars), auditor)
+	if err := migrator.Resume(context.Background()); err != nil {
+		log.Error(nil, map[string]interface{}{
+			"err": err,
+		}, "unable to resume interrupted tenant migrations")
+	}
+
+	// Mount "tenants" controller
+	tenantsCtrl := controller.NewTenantsController(service, tenantService, clusters, templateVars, migrator, isAdmin, auditor, progressBroker)
+	app.MountTenantsController(service, tenantsCtrl)
+
+	// Mount "job" controller
+	jobCtrl := controller.NewJobController(service, tenantService, isAdmin)
+	app.MountJobController(service, jobCtrl)
+
+	// Mount "upgrade" controller
+	orchestrator := upgrade.NewOrchestrator(tenantService, controller.UpgradeTenant(clusters, tenantService, templateVars, auditor))
+	if err := orchestrator.Resume(context.Background()); err != nil {
+		log.Error(nil, map[string]interface{}{
+			"err": err,
+		}, "unable to resume interrupted upgrade rollouts")
+	}
+	upgradeCtrl := controller.NewUpgradeController(service, orchestrator, isAdmin)
+	app.MountUpgradeController(service, upgradeCtrl)
+
+	// Mount "audit" controller
+	auditCtrl := controller.NewAuditController(service, auditor, isAdmin)
+	app.MountAuditController(service, auditCtrl)
+
+	// Mount "webhooks" controller
+	webhooksCtrl := controller.NewWebhooksController(service, webhooks, isAdmin)
+	app.MountWebhooksController(service, webhooksCtrl)
+
 	log.Logger().Infoln("Git Commit SHA: ", controller.Commit)
 	log.Logger().Infoln("UTC Build Time: ", controller.BuildTime)
 	log.Logger().Infoln("UTC Start Time: ", controller.StartTime)
@@ -151,6 +306,66 @@ func main() {
 	}
 }
 
+// clusterSeeds returns the configured clusters, or the cluster of the default configuration if none are configured
+func clusterSeeds(config *configuration.Data, openshiftConfig openshift.Config, openshiftTransport transport.Config) []*cluster.Cluster {
+	configured, err := config.GetOpenshiftClusters()
+	if err != nil {
+		logrus.Panic(nil, map[string]interface{}{
+			"err": err,
+		}, "failed to read the cluster configuration")
+	}
+	if len(configured) == 0 {
+		return []*cluster.Cluster{withTransport(&cluster.Cluster{
+			Name:   "default",
+			APIURL: openshiftConfig.MasterURL,
+			Token:  openshiftConfig.Token,
+		}, openshiftTransport)}
+	}
+	var seeds []*cluster.Cluster
+	for _, c := range configured {
+		tc, err := c.TransportConfig()
+		if err != nil {
+			logrus.Panic(nil, map[string]interface{}{
+				"err":         err,
+				"cluster_url": c.APIURL,
+			}, "failed to read the cluster TLS configuration")
+		}
+		seeds = append(seeds, withTransport(&cluster.Cluster{
+			Name:     c.Name,
+			APIURL:   c.APIURL,
+			Token:    c.Token,
+			Capacity: c.Capacity,
+			Weight:   c.Weight,
+			Labels:   c.Labels,
+		}, tc))
+	}
+	return seeds
+}
+
+func withTransport(c *cluster.Cluster, tc transport.Config) *cluster.Cluster {
+	c.CABundle = tc.CABundle
+	c.ClientCert = tc.ClientCert
+	c.ClientKey = tc.ClientKey
+	c.ServerName = tc.ServerName
+	c.ProxyURL = tc.ProxyURL
+	c.InsecureSkipTLSVerify = tc.InsecureSkipVerify
+	return c
+}
+
+// withoutEventStreams applies the middleware to all requests but the ones for event streams, which
+// must reach the client unbuffered
+func withoutEventStreams(m goa.Middleware) goa.Middleware {
+	return func(h goa.Handler) goa.Handler {
+		wrapped := m(h)
+		return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			if strings.Contains(req.Header.Get("Accept"), "text/event-stream") {
+				return h(ctx, rw, req)
+			}
+			return wrapped(ctx, rw, req)
+		}
+	}
+}
+
 func connect(config *configuration.Data) *gorm.DB {
 	var err error
 	var db *gorm.DB
@@ -102,6 +102,18 @@ func getMigrations() migrations {
 
 	m = append(m, steps{executeSQLFile("000-bootstrap.sql")})
 	m = append(m, steps{executeSQLFile("001-tenant-and-namespaces.sql")})
+	m = append(m, steps{executeSQLFile("002-tenant-os-username.sql")})
+	m = append(m, steps{executeSQLFile("003-jobs.sql")})
+	m = append(m, steps{executeSQLFile("004-namespace-activity.sql")})
+	m = append(m, steps{executeSQLFile("005-tenant-plan.sql")})
+	m = append(m, steps{executeSQLFile("006-tenant-ns-base-name.sql")})
+	m = append(m, steps{executeSQLFile("007-clusters.sql")})
+	m = append(m, steps{executeSQLFile("008-placement.sql")})
+	m = append(m, steps{executeSQLFile("009-job-steps.sql")})
+	m = append(m, steps{executeSQLFile("010-cluster-tls.sql")})
+	m = append(m, steps{executeSQLFile("011-audit.sql")})
+	m = append(m, steps{executeSQLFile("012-webhooks.sql")})
+	m = append(m, steps{executeSQLFile("013-job-details.sql")})
 
 	// Version N
 	//
@@ -213,13 +225,31 @@ func migrateToNextVersion(tx *sql.Tx, nextVersion *int64, m migrations) error {
 	return nil
 }
 
+// queryRower is implemented by both *sql.DB and *sql.Tx
+type queryRower interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// CheckVersion returns an error unless the database is migrated to the version this build expects
+func CheckVersion(db *sql.DB) error {
+	current, err := getCurrentVersion(db)
+	if err != nil {
+		return err
+	}
+	expected := int64(len(getMigrations()) - 1)
+	if current != expected {
+		return errs.Errorf("Database is at version %d, expected version %d", current, expected)
+	}
+	return nil
+}
+
 // getCurrentVersion returns the highest version from the version
 // table or -1 if that table does not exist.
 //
 // Returning -1 simplifies the logic of the migration process because
 // the next version is always the current version + 1 which results
 // in -1 + 1 = 0 which is exactly what we want as the first version.
-func getCurrentVersion(db *sql.Tx) (int64, error) {
+func getCurrentVersion(db queryRower) (int64, error) {
 	row := db.QueryRow("SELECT EXISTS (SELECT 1 FROM information_schema.tables WHERE table_name='version')")
 
 	var exists bool
@@ -0,0 +1 @@
+ALTER TABLE tenants ADD COLUMN os_username text;
@@ -0,0 +1,13 @@
+CREATE TABLE jobs (
+    created_at timestamp with time zone,
+    updated_at timestamp with time zone,
+    completed_at timestamp with time zone,
+    id uuid primary key NOT NULL,
+    tenant_id uuid,
+    type text,
+    target text,
+    state text,
+    error text
+);
+
+CREATE INDEX ix_jobs_tenant ON jobs USING btree (tenant_id);
@@ -0,0 +1,2 @@
+ALTER TABLE namespaces ADD COLUMN last_activity_at timestamp with time zone;
+ALTER TABLE namespaces ADD COLUMN idled_at timestamp with time zone;
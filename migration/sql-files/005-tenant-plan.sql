@@ -0,0 +1 @@
+ALTER TABLE tenants ADD COLUMN plan text;
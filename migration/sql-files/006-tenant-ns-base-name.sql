@@ -0,0 +1,10 @@
+ALTER TABLE tenants ADD COLUMN ns_base_name text;
+
+-- existing tenants keep the name of the user namespace they were provisioned with
+UPDATE tenants SET ns_base_name = (
+    SELECT name FROM namespaces
+    WHERE namespaces.tenant_id = tenants.id AND namespaces.type = 'user'
+    ORDER BY created_at LIMIT 1
+);
+
+CREATE UNIQUE INDEX uix_tenants_ns_base_name ON tenants USING btree (ns_base_name) WHERE ns_base_name IS NOT NULL;
@@ -0,0 +1,22 @@
+CREATE TABLE clusters (
+    created_at timestamp with time zone,
+    updated_at timestamp with time zone,
+    id uuid primary key NOT NULL,
+    name text,
+    api_url text NOT NULL,
+    token text,
+    ca_bundle text,
+    capacity integer NOT NULL DEFAULT 0,
+    labels jsonb
+);
+
+CREATE UNIQUE INDEX uix_clusters_api_url ON clusters USING btree (api_url);
+
+ALTER TABLE tenants ADD COLUMN master_url text;
+
+-- existing tenants stay on the cluster their namespaces were provisioned on
+UPDATE tenants SET master_url = (
+    SELECT master_url FROM namespaces
+    WHERE namespaces.tenant_id = tenants.id
+    ORDER BY created_at LIMIT 1
+);
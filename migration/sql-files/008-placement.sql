@@ -0,0 +1,10 @@
+ALTER TABLE clusters ADD COLUMN weight integer NOT NULL DEFAULT 1;
+
+ALTER TABLE tenants ADD COLUMN placement_reason text;
+
+CREATE TABLE placement_overrides (
+    created_at timestamp with time zone,
+    updated_at timestamp with time zone,
+    tenant_id uuid primary key NOT NULL,
+    master_url text NOT NULL
+);
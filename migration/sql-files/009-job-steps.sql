@@ -0,0 +1,4 @@
+ALTER TABLE jobs ADD COLUMN source text;
+ALTER TABLE jobs ADD COLUMN step text;
+
+CREATE INDEX ix_jobs_type_state ON jobs USING btree (type, state);
@@ -0,0 +1,5 @@
+ALTER TABLE clusters ADD COLUMN client_cert text;
+ALTER TABLE clusters ADD COLUMN client_key text;
+ALTER TABLE clusters ADD COLUMN server_name text;
+ALTER TABLE clusters ADD COLUMN proxy_url text;
+ALTER TABLE clusters ADD COLUMN insecure_skip_tls_verify boolean NOT NULL DEFAULT false;
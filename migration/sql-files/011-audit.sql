@@ -0,0 +1,22 @@
+CREATE TABLE audit_records (
+    created_at timestamp with time zone NOT NULL DEFAULT now(),
+    id uuid primary key NOT NULL,
+    actor_id uuid,
+    actor_email text,
+    acting_as text,
+    operation text NOT NULL,
+    tenant_id uuid,
+    target_version text,
+    outcome text NOT NULL,
+    error text,
+    request_id text,
+    details jsonb
+);
+
+CREATE INDEX ix_audit_records_tenant ON audit_records USING btree (tenant_id, created_at);
+CREATE INDEX ix_audit_records_actor ON audit_records USING btree (actor_id, created_at);
+CREATE INDEX ix_audit_records_created_at ON audit_records USING btree (created_at);
+
+-- the audit trail is append-only
+CREATE RULE audit_records_no_update AS ON UPDATE TO audit_records DO INSTEAD NOTHING;
+CREATE RULE audit_records_no_delete AS ON DELETE TO audit_records DO INSTEAD NOTHING;
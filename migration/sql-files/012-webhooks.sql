@@ -0,0 +1,30 @@
+CREATE TABLE webhook_subscriptions (
+    created_at timestamp with time zone,
+    updated_at timestamp with time zone,
+    id uuid primary key NOT NULL,
+    url text NOT NULL,
+    secret text NOT NULL,
+    event_types jsonb
+);
+
+-- the outbox of the deliveries, written for all matching subscriptions in the transaction of the
+-- tenant, namespace, job or audit change the event reports
+CREATE TABLE webhook_deliveries (
+    created_at timestamp with time zone,
+    updated_at timestamp with time zone,
+    id uuid primary key NOT NULL,
+    subscription_id uuid NOT NULL REFERENCES webhook_subscriptions (id) ON DELETE CASCADE,
+    event_id uuid NOT NULL,
+    event_type text NOT NULL,
+    tenant_id uuid,
+    payload text NOT NULL,
+    state text NOT NULL,
+    attempts integer NOT NULL DEFAULT 0,
+    next_attempt_at timestamp with time zone,
+    last_attempt_at timestamp with time zone,
+    response_status integer NOT NULL DEFAULT 0,
+    error text
+);
+
+CREATE INDEX ix_webhook_deliveries_due ON webhook_deliveries USING btree (state, next_attempt_at);
+CREATE INDEX ix_webhook_deliveries_subscription ON webhook_deliveries USING btree (subscription_id, created_at);
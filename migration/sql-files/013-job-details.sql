@@ -0,0 +1,2 @@
+-- the state specific to the type of the job, e.g. the progress of an upgrade rollout
+ALTER TABLE jobs ADD COLUMN details jsonb;
@@ -26,6 +26,7 @@ const (
 	FieldName                     = "name"
 	FieldResourceVersion          = "resourceVersion"
 	ValKindTemplate               = "Template"
+	ValKindProject                = "Project"
 	ValKindProjectRequest         = "ProjectRequest"
 	ValKindPersistenceVolumeClaim = "PersistentVolumeClaim"
 	ValKindServiceAccount         = "ServiceAccount"
@@ -201,7 +202,10 @@ func apply(object map[interface{}]interface{}, action string, opts ApplyOptions)
 	if action == "PATCH" {
 		req.Header.Set("Content-Type", "application/merge-patch+json")
 	}
-	req.Header.Set("Authorization", "Bearer "+opts.Token)
+	err = opts.authorize(req)
+	if err != nil {
+		return nil, err
+	}
 
 	// for debug only
 	if false {
@@ -13,6 +13,27 @@ type Config struct {
 	TemplateDir   string
 	TeamVersion   string
 	LogCallback   LogCallback
+	// TokenSource provides the token when set, e.g. to refresh an expiring user token during a long apply
+	TokenSource TokenSource
+	// Impersonate makes the user scoped operations use the master token impersonating the tenant user
+	// instead of the token of the user
+	Impersonate bool
+	// ImpersonateUser and ImpersonateGroups are sent as Impersonate-User and Impersonate-Group headers
+	ImpersonateUser   string
+	ImpersonateGroups []string
+}
+
+// ImpersonatedGroups are the groups of a user logged in through OAuth, which are allowed to request projects
+var ImpersonatedGroups = []string{"system:authenticated", "system:authenticated:oauth"}
+
+// TokenSource returns a currently valid token
+type TokenSource func() (string, error)
+
+// StaticToken returns a TokenSource that always returns the given token
+func StaticToken(token string) TokenSource {
+	return func() (string, error) {
+		return token, nil
+	}
 }
 
 type LogCallback func(message string)
@@ -21,6 +42,45 @@ func (c Config) WithToken(token string) Config {
 	return Config{MasterURL: c.MasterURL, MasterUser: c.MasterUser, Token: token, HttpTransport: c.HttpTransport}
 }
 
+// WithTokenSource returns a copy of the config authenticating with the tokens of the source
+func (c Config) WithTokenSource(source TokenSource) Config {
+	config := c.WithToken("")
+	config.TokenSource = source
+	return config
+}
+
+// AsUser returns a copy of the config authenticating with the master token while impersonating the user
+func (c Config) AsUser(username string) Config {
+	config := c.WithToken(c.Token)
+	config.ImpersonateUser = username
+	config.ImpersonateGroups = ImpersonatedGroups
+	return config
+}
+
+// bearerToken returns the token to authenticate the next request with
+func (c Config) bearerToken() (string, error) {
+	if c.TokenSource != nil {
+		return c.TokenSource()
+	}
+	return c.Token, nil
+}
+
+// authorize sets the authentication headers of the request
+func (c Config) authorize(req *http.Request) error {
+	token, err := c.bearerToken()
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	if c.ImpersonateUser != "" {
+		req.Header.Set("Impersonate-User", c.ImpersonateUser)
+		for _, group := range c.ImpersonateGroups {
+			req.Header.Add("Impersonate-Group", group)
+		}
+	}
+	return nil
+}
+
 func (c Config) GetLogCallback() LogCallback {
 	if c.LogCallback == nil {
 		return nilLogCallback
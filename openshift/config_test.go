@@ -0,0 +1,35 @@
+package openshift
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/almighty/almighty-core/resource"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthorizeWithToken(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+
+	req, err := http.NewRequest("GET", "https://openshift.example.com/oapi/v1/users/~", nil)
+	require.NoError(t, err)
+	config := Config{Token: "master"}.WithTokenSource(StaticToken("user"))
+
+	require.NoError(t, config.authorize(req))
+	assert.Equal(t, "Bearer user", req.Header.Get("Authorization"))
+	assert.Empty(t, req.Header.Get("Impersonate-User"))
+}
+
+func TestAuthorizeAsUser(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+
+	req, err := http.NewRequest("GET", "https://openshift.example.com/oapi/v1/users/~", nil)
+	require.NoError(t, err)
+	config := Config{Token: "master", Impersonate: true}.AsUser("aslak")
+
+	require.NoError(t, config.authorize(req))
+	assert.Equal(t, "Bearer master", req.Header.Get("Authorization"))
+	assert.Equal(t, "aslak", req.Header.Get("Impersonate-User"))
+	assert.Equal(t, ImpersonatedGroups, req.Header["Impersonate-Group"])
+}
@@ -0,0 +1,42 @@
+package openshift
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// TemplateFiles are the templates a tenant is provisioned from
+var TemplateFiles = []string{
+	"fabric8-online-team-openshift.yml",
+	"fabric8-online-jenkins-openshift.yml",
+	"fabric8-online-che-openshift.yml",
+}
+
+// Ping checks that the API server of the cluster answers on its health endpoint
+func Ping(config Config) error {
+	req, err := http.NewRequest("GET", config.MasterURL+"/healthz", nil)
+	if err != nil {
+		return err
+	}
+	opts := ApplyOptions{Config: config}
+	resp, err := opts.CreateHttpClient().Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	// the health endpoint may require authentication, any answer but a server error means the API is up
+	if resp.StatusCode >= 500 {
+		return fmt.Errorf("Unexpected response %v from GET %v/healthz", resp.StatusCode, config.MasterURL)
+	}
+	return nil
+}
+
+// CheckTemplates checks that all tenant templates of the configured version can be loaded
+func CheckTemplates(config Config) error {
+	for _, file := range TemplateFiles {
+		if _, err := loadTemplate(config, file); err != nil {
+			return err
+		}
+	}
+	return nil
+}
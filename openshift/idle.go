@@ -0,0 +1,153 @@
+package openshift
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+const (
+	// AnnotationIdledReplicas holds the number of replicas a DeploymentConfig had before it was idled
+	AnnotationIdledReplicas = "fabric8.io/idled-replicas"
+)
+
+type deploymentConfigList struct {
+	Items []deploymentConfig `json:"items"`
+}
+
+type deploymentConfig struct {
+	Metadata struct {
+		Name        string            `json:"name"`
+		Annotations map[string]string `json:"annotations"`
+	} `json:"metadata"`
+	Spec struct {
+		Replicas int `json:"replicas"`
+	} `json:"spec"`
+}
+
+// IdleNamespace scales all DeploymentConfigs in the namespace to zero, remembering
+// the current number of replicas so UnidleNamespace can restore them
+func IdleNamespace(config Config, namespace string) error {
+	dcs, err := listDeploymentConfigs(config, namespace)
+	if err != nil {
+		return err
+	}
+	for _, dc := range dcs {
+		if dc.Spec.Replicas == 0 {
+			continue
+		}
+		patch := map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"annotations": map[string]string{
+					AnnotationIdledReplicas: strconv.Itoa(dc.Spec.Replicas),
+				},
+			},
+			"spec": map[string]interface{}{
+				"replicas": 0,
+			},
+		}
+		err = patchDeploymentConfig(config, namespace, dc.Metadata.Name, patch)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UnidleNamespace scales all idled DeploymentConfigs in the namespace back to the
+// number of replicas they had before being idled
+func UnidleNamespace(config Config, namespace string) error {
+	dcs, err := listDeploymentConfigs(config, namespace)
+	if err != nil {
+		return err
+	}
+	for _, dc := range dcs {
+		value, found := dc.Metadata.Annotations[AnnotationIdledReplicas]
+		if !found || dc.Spec.Replicas > 0 {
+			continue
+		}
+		replicas, err := strconv.Atoi(value)
+		if err != nil || replicas < 1 {
+			replicas = 1
+		}
+		patch := map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"annotations": map[string]interface{}{
+					AnnotationIdledReplicas: nil,
+				},
+			},
+			"spec": map[string]interface{}{
+				"replicas": replicas,
+			},
+		}
+		err = patchDeploymentConfig(config, namespace, dc.Metadata.Name, patch)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func listDeploymentConfigs(config Config, namespace string) ([]deploymentConfig, error) {
+	url := fmt.Sprintf("%v/oapi/v1/namespaces/%v/deploymentconfigs", config.MasterURL, namespace)
+	b, err := doJSON(config, "GET", url, "", nil)
+	if err != nil {
+		return nil, err
+	}
+	var list deploymentConfigList
+	err = json.Unmarshal(b, &list)
+	if err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+
+func patchDeploymentConfig(config Config, namespace, name string, patch interface{}) error {
+	url := fmt.Sprintf("%v/oapi/v1/namespaces/%v/deploymentconfigs/%v", config.MasterURL, namespace, name)
+	body, err := json.Marshal(patch)
+	if err != nil {
+		return err
+	}
+	_, err = doJSON(config, "PATCH", url, "application/merge-patch+json", body)
+	return err
+}
+
+func doJSON(config Config, method, url, contentType string, body []byte) ([]byte, error) {
+	status, b, err := doRequest(config, method, url, contentType, body)
+	if err != nil {
+		return nil, err
+	}
+	if status < 200 || status >= 300 {
+		return nil, fmt.Errorf("Unexpected response %v from %v %v:\n%v", status, method, url, string(b))
+	}
+	return b, nil
+}
+
+// doRequest performs a JSON request authorized by the config token and returns the response status and body
+func doRequest(config Config, method, url, contentType string, body []byte) (int, []byte, error) {
+	req, err := http.NewRequest(method, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	err = config.authorize(req)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	opts := ApplyOptions{Config: config}
+	resp, err := opts.CreateHttpClient().Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+
+	buf := new(bytes.Buffer)
+	buf.ReadFrom(resp.Body)
+	return resp.StatusCode, buf.Bytes(), nil
+}
@@ -26,35 +26,24 @@ const (
 // Creates the new x-test|stage|run and x-jenkins|che namespaces
 // and install the required services/routes/deployment configurations to run
 // e.g. Jenkins and Che
-func InitTenant(config Config, callback Callback, username, usertoken string, templateVars map[string]string) error {
-	err := do(config, callback, username, usertoken, templateVars)
+func InitTenant(config Config, callback Callback, username, nsBaseName string, usertoken TokenSource, templateVars map[string]string) error {
+	err := do(config, callback, username, nsBaseName, usertoken, templateVars)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-func do(config Config, callback Callback, username, usertoken string, templateVars map[string]string) error {
-	name := createName(username)
-
-	vars := map[string]string{
-		varProjectName:           name,
-		varProjectTemplateName:   name,
-		varProjectDisplayName:    name,
-		varProjectDescription:    name,
-		varProjectUser:           username,
-		varProjectRequestingUser: username,
-		varProjectAdminUser:      config.MasterUser,
-	}
-
-	for k, v := range templateVars {
-		if _, exist := vars[k]; !exist {
-			vars[k] = v
-		}
-	}
+func do(config Config, callback Callback, username, nsBaseName string, usertoken TokenSource, templateVars map[string]string) error {
+	name := resolveName(username, nsBaseName)
+	vars := createVariables(config, name, username, templateVars)
 
 	masterOpts := ApplyOptions{Config: config, Callback: callback}
-	userOpts := ApplyOptions{Config: config.WithToken(usertoken), Namespace: name, Callback: callback}
+	userConfig := config.WithTokenSource(usertoken)
+	if config.Impersonate {
+		userConfig = config.AsUser(username)
+	}
+	userOpts := ApplyOptions{Config: userConfig, Namespace: name, Callback: callback}
 
 	userProjectT, err := loadTemplate(config, "fabric8-online-user-project.yml")
 	if err != nil {
@@ -71,18 +60,14 @@ func do(config Config, callback Callback, username, usertoken string, templateVa
 		return err
 	}
 
-	projectT, err := loadTemplate(config, "fabric8-online-team-openshift.yml")
-	if err != nil {
-		return err
-	}
-
-	jenkinsT, err := loadTemplate(config, "fabric8-online-jenkins-openshift.yml")
-	if err != nil {
-		return err
-	}
-	cheT, err := loadTemplate(config, "fabric8-online-che-openshift.yml")
-	if err != nil {
-		return err
+	var teamTs [][]byte
+	teams := teamTemplates(name, vars)
+	for _, team := range teams {
+		t, err := loadTemplate(config, team.file)
+		if err != nil {
+			return err
+		}
+		teamTs = append(teamTs, t)
 	}
 
 	var channels []chan error
@@ -102,28 +87,14 @@ func do(config Config, callback Callback, username, usertoken string, templateVa
 		return err
 	}
 
-	{
-		lvars := clone(vars)
-		lvars[varProjectDisplayName] = lvars[varProjectName]
-
-		err = executeNamespaceSync(string(projectT), lvars, masterOpts.WithNamespace(name))
-		if err != nil {
-			return err
-		}
+	// the team template creates the environment namespaces and has to be in place before Jenkins and Che
+	err = executeNamespaceSync(string(teamTs[0]), teams[0].vars, masterOpts.WithNamespace(teams[0].namespace))
+	if err != nil {
+		return err
 	}
 
-	{
-		lvars := clone(vars)
-		nsname := fmt.Sprintf("%v-jenkins", name)
-		lvars[varProjectNamespace] = vars[varProjectName]
-		ns := executeNamespaceAsync(string(jenkinsT), lvars, masterOpts.WithNamespace(nsname))
-		channels = append(channels, ns)
-	}
-	{
-		lvars := clone(vars)
-		nsname := fmt.Sprintf("%v-che", name)
-		lvars[varProjectNamespace] = vars[varProjectName]
-		ns := executeNamespaceAsync(string(cheT), lvars, masterOpts.WithNamespace(nsname))
+	for i := 1; i < len(teams); i++ {
+		ns := executeNamespaceAsync(string(teamTs[i]), teams[i].vars, masterOpts.WithNamespace(teams[i].namespace))
 		channels = append(channels, ns)
 	}
 
@@ -140,6 +111,32 @@ func do(config Config, callback Callback, username, usertoken string, templateVa
 	return nil
 }
 
+// teamTemplate describes a template applied with the master token, the variables
+// to process it with and the namespace its objects default to
+type teamTemplate struct {
+	file      string
+	vars      map[string]string
+	namespace string
+}
+
+// teamTemplates returns the team, Jenkins and Che templates in the order they are applied
+func teamTemplates(name string, vars map[string]string) []teamTemplate {
+	team := clone(vars)
+	team[varProjectDisplayName] = team[varProjectName]
+
+	jenkins := clone(vars)
+	jenkins[varProjectNamespace] = vars[varProjectName]
+
+	che := clone(vars)
+	che[varProjectNamespace] = vars[varProjectName]
+
+	return []teamTemplate{
+		{file: "fabric8-online-team-openshift.yml", vars: team, namespace: name},
+		{file: "fabric8-online-jenkins-openshift.yml", vars: jenkins, namespace: fmt.Sprintf("%v-jenkins", name)},
+		{file: "fabric8-online-che-openshift.yml", vars: che, namespace: fmt.Sprintf("%v-che", name)},
+	}
+}
+
 // loadTemplate will load the template for a specific version from maven central or from the template directory
 // or default to the OOTB template included
 func loadTemplate(config Config, name string) ([]byte, error) {
@@ -184,6 +181,35 @@ func loadTemplate(config Config, name string) ([]byte, error) {
 	return template.Asset("template/" + name)
 }
 
+// createVariables returns the variables used to process the templates for the given tenant
+func createVariables(config Config, name, username string, templateVars map[string]string) map[string]string {
+	vars := map[string]string{
+		varProjectName:           name,
+		varProjectTemplateName:   name,
+		varProjectDisplayName:    name,
+		varProjectDescription:    name,
+		varProjectUser:           username,
+		varProjectRequestingUser: username,
+		varProjectAdminUser:      config.MasterUser,
+	}
+
+	for k, v := range templateVars {
+		if _, exist := vars[k]; !exist {
+			vars[k] = v
+		}
+	}
+	return vars
+}
+
+// resolveName returns the allocated namespace base name of the tenant, falling back
+// to the name derived from the username for tenants created before names were allocated
+func resolveName(username, nsBaseName string) string {
+	if nsBaseName != "" {
+		return nsBaseName
+	}
+	return createName(username)
+}
+
 func createName(username string) string {
 	return strings.Replace(strings.Split(username, "@")[0], ".", "-", -1)
 }
@@ -0,0 +1,138 @@
+package openshift
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const (
+	migrateDeleteTimeout = 5 * time.Minute
+)
+
+// copiedKinds are the kinds holding user data that is not re-created by provisioning the tenant templates
+var copiedKinds = map[string]string{
+	"Secret":    "secrets",
+	"ConfigMap": "configmaps",
+}
+
+// generatedAnnotations mark objects maintained by OpenShift itself, e.g. service account tokens
+var generatedAnnotations = []string{
+	"kubernetes.io/service-account.name",
+	"openshift.io/token-secret.name",
+}
+
+type objectList struct {
+	Items []map[string]interface{} `json:"items"`
+}
+
+// CopyUserObjects copies the Secrets and ConfigMaps created by the user from the namespace on the source
+// cluster to the namespace of the same name on the target cluster. Objects provisioned from the tenant
+// templates or generated by OpenShift are skipped. Objects already on the target are replaced.
+// Returns the number of copied objects.
+func CopyUserObjects(source, target Config, namespace string) (int, error) {
+	copied := 0
+	for kind, resource := range copiedKinds {
+		url := fmt.Sprintf("%v/api/v1/namespaces/%v/%v", source.MasterURL, namespace, resource)
+		b, err := doJSON(source, "GET", url, "", nil)
+		if err != nil {
+			return copied, err
+		}
+		var list objectList
+		err = json.Unmarshal(b, &list)
+		if err != nil {
+			return copied, err
+		}
+		for _, obj := range list.Items {
+			if isGenerated(obj) {
+				continue
+			}
+			obj["kind"] = kind
+			obj["apiVersion"] = "v1"
+			obj["metadata"] = copyableMetadata(obj, namespace)
+			err = createOrReplace(target, namespace, resource, obj)
+			if err != nil {
+				return copied, err
+			}
+			copied++
+		}
+	}
+	return copied, nil
+}
+
+// DeleteNamespaces deletes the given namespaces and waits for them to be gone. Namespaces that
+// do not exist are ignored.
+func DeleteNamespaces(config Config, namespaces []string) error {
+	opts := ApplyOptions{Config: config}
+	for _, name := range namespaces {
+		project := map[interface{}]interface{}{
+			FieldKind:     ValKindProject,
+			FieldMetadata: map[interface{}]interface{}{FieldName: name},
+		}
+		_, err := apply(project, "DELETE", opts)
+		if err != nil {
+			return err
+		}
+		err = waitForDeletion(project, opts, migrateDeleteTimeout)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func isGenerated(obj map[string]interface{}) bool {
+	metadata, _ := obj["metadata"].(map[string]interface{})
+	if refs, found := metadata["ownerReferences"].([]interface{}); found && len(refs) > 0 {
+		return true
+	}
+	if labels, found := metadata["labels"].(map[string]interface{}); found && labels["provider"] == "fabric8" {
+		return true
+	}
+	if annotations, found := metadata["annotations"].(map[string]interface{}); found {
+		for _, a := range generatedAnnotations {
+			if _, generated := annotations[a]; generated {
+				return true
+			}
+		}
+	}
+	secretType, _ := obj["type"].(string)
+	return secretType == "kubernetes.io/service-account-token"
+}
+
+// copyableMetadata strips the cluster specific fields, e.g. uid and resourceVersion, from the object metadata
+func copyableMetadata(obj map[string]interface{}, namespace string) map[string]interface{} {
+	metadata, _ := obj["metadata"].(map[string]interface{})
+	result := map[string]interface{}{
+		"name":      metadata["name"],
+		"namespace": namespace,
+	}
+	for _, field := range []string{"labels", "annotations"} {
+		if value, found := metadata[field]; found {
+			result[field] = value
+		}
+	}
+	return result
+}
+
+func createOrReplace(config Config, namespace, resource string, obj map[string]interface{}) error {
+	body, err := json.Marshal(obj)
+	if err != nil {
+		return err
+	}
+	url := fmt.Sprintf("%v/api/v1/namespaces/%v/%v", config.MasterURL, namespace, resource)
+	status, b, err := doRequest(config, "POST", url, "application/json", body)
+	if err != nil {
+		return err
+	}
+	if status == http.StatusConflict {
+		url = fmt.Sprintf("%v/%v", url, obj["metadata"].(map[string]interface{})["name"])
+		_, err = doJSON(config, "PUT", url, "application/json", body)
+		return err
+	}
+	if status < 200 || status >= 300 {
+		return fmt.Errorf("Unexpected response %v from POST %v:\n%v", status, url, string(b))
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package openshift
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// NamespaceSuffixes are appended to the base name to create the tenant namespaces
+var NamespaceSuffixes = []string{"", "-jenkins", "-che", "-test", "-stage", "-run"}
+
+const (
+	// maxNamespaceLength is the DNS-1123 label limit for namespace names
+	maxNamespaceLength = 63
+	// maxBaseNameLength leaves room for the longest namespace suffix
+	maxBaseNameLength = maxNamespaceLength - len("-jenkins")
+	// maxAllocationAttempts limits the number of suffixes tried for a base name
+	maxAllocationAttempts = 100
+)
+
+var (
+	invalidNameChars = regexp.MustCompile(`[^a-z0-9-]+`)
+	repeatedDashes   = regexp.MustCompile(`-{2,}`)
+)
+
+// BaseName creates a DNS-1123 compatible namespace base name from the username,
+// e.g. john.doe@example.com becomes john-doe
+func BaseName(username string) string {
+	name := strings.ToLower(strings.Split(username, "@")[0])
+	name = invalidNameChars.ReplaceAllString(name, "-")
+	name = repeatedDashes.ReplaceAllString(name, "-")
+	name = truncate(name, maxBaseNameLength)
+	if name == "" {
+		return "user"
+	}
+	return name
+}
+
+// AllocateBaseName returns the first base name derived from the username that is not taken.
+// On collision a numeric suffix is added, e.g. john-doe2.
+func AllocateBaseName(username string, taken func(name string) (bool, error)) (string, error) {
+	base := BaseName(username)
+	for i := 1; i <= maxAllocationAttempts; i++ {
+		name := base
+		if i > 1 {
+			suffix := fmt.Sprintf("%d", i)
+			name = truncate(base, maxBaseNameLength-len(suffix)) + suffix
+		}
+		used, err := taken(name)
+		if err != nil {
+			return "", err
+		}
+		if !used {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("unable to allocate a namespace name for %v", username)
+}
+
+// NamespacesExist checks if any of the tenant namespaces for the given base name exist on the cluster
+func NamespacesExist(config Config, baseName string) (bool, error) {
+	for _, suffix := range NamespaceSuffixes {
+		url := fmt.Sprintf("%v/oapi/v1/projects/%v%v", config.MasterURL, baseName, suffix)
+		req, err := http.NewRequest("GET", url, nil)
+		if err != nil {
+			return false, err
+		}
+		req.Header.Set("Accept", "application/json")
+		if err := config.authorize(req); err != nil {
+			return false, err
+		}
+
+		opts := ApplyOptions{Config: config}
+		resp, err := opts.CreateHttpClient().Do(req)
+		if err != nil {
+			return false, err
+		}
+		resp.Body.Close()
+		switch resp.StatusCode {
+		case http.StatusOK:
+			return true, nil
+		case http.StatusNotFound:
+			continue
+		default:
+			return false, fmt.Errorf("Unexpected response %v from GET %v", resp.StatusCode, url)
+		}
+	}
+	return false, nil
+}
+
+// truncate shortens the name to max characters without leaving a leading or trailing dash
+func truncate(name string, max int) string {
+	if len(name) > max {
+		name = name[:max]
+	}
+	return strings.Trim(name, "-")
+}
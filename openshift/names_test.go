@@ -0,0 +1,72 @@
+package openshift_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/almighty/almighty-core/resource"
+	"github.com/fabric8io/fabric8-init-tenant/openshift"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBaseName(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+	assert.Equal(t, "john-doe", openshift.BaseName("john.doe@example.com"))
+	assert.Equal(t, "john-doe", openshift.BaseName("John_Doe"))
+	assert.Equal(t, "john-doe", openshift.BaseName("-john..doe-"))
+	assert.Equal(t, "user", openshift.BaseName("___@example.com"))
+
+	long := openshift.BaseName(strings.Repeat("a", 100))
+	assert.Len(t, long, 55)
+	assert.True(t, len(long+"-jenkins") <= 63)
+}
+
+func TestAllocateBaseName(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+	taken := map[string]bool{"john-doe": true, "john-doe2": true}
+	name, err := openshift.AllocateBaseName("john.doe@c.org", func(name string) (bool, error) {
+		return taken[name], nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "john-doe3", name)
+
+	long := strings.Repeat("a", 100)
+	name, err = openshift.AllocateBaseName(long, func(name string) (bool, error) {
+		return !strings.HasSuffix(name, "2"), nil
+	})
+	require.NoError(t, err)
+	assert.Len(t, name, 55)
+	assert.True(t, strings.HasSuffix(name, "a2"))
+
+	_, err = openshift.AllocateBaseName("john", func(name string) (bool, error) {
+		return false, fmt.Errorf("db down")
+	})
+	assert.Error(t, err)
+}
+
+func TestNamespacesExistAuthorization(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+
+	var authorization, impersonated string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		impersonated = r.Header.Get("Impersonate-User")
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+	base := openshift.Config{MasterURL: server.URL, Token: "master"}
+
+	exists, err := openshift.NamespacesExist(base.WithTokenSource(openshift.StaticToken("refreshed")), "aslak")
+	require.NoError(t, err)
+	assert.False(t, exists)
+	assert.Equal(t, "Bearer refreshed", authorization)
+
+	_, err = openshift.NamespacesExist(base.AsUser("aslak"), "aslak")
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer master", authorization)
+	assert.Equal(t, "aslak", impersonated)
+}
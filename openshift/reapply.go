@@ -0,0 +1,74 @@
+package openshift
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// ApplyChanged re-applies the objects of the team, Jenkins and Che templates whose definition
+// differs when processed with newVars instead of oldVars, e.g. ResourceQuotas and LimitRanges
+// after a plan change. Existing PersistentVolumeClaims can not be resized and are left untouched.
+func ApplyChanged(config Config, callback Callback, username, nsBaseName string, oldVars, newVars map[string]string) error {
+	name := resolveName(username, nsBaseName)
+	oldTeams := teamTemplates(name, createVariables(config, name, username, oldVars))
+	newTeams := teamTemplates(name, createVariables(config, name, username, newVars))
+	logCallback := config.GetLogCallback()
+
+	for i, team := range newTeams {
+		t, err := loadTemplate(config, team.file)
+		if err != nil {
+			return err
+		}
+		oldObjects, err := processObjects(string(t), oldTeams[i].vars, team.namespace)
+		if err != nil {
+			return err
+		}
+		newObjects, err := processObjects(string(t), team.vars, team.namespace)
+		if err != nil {
+			return err
+		}
+		opts := ApplyOptions{Config: config, Namespace: team.namespace, Callback: callback}
+		for _, obj := range changedObjects(oldObjects, newObjects) {
+			if GetKind(obj) == ValKindPersistenceVolumeClaim {
+				logCallback(fmt.Sprintf("Skipping change of existing %v %v/%v", GetKind(obj), GetNamespace(obj), GetName(obj)))
+				continue
+			}
+			_, err := apply(obj, "POST", opts)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func processObjects(template string, vars map[string]string, namespace string) ([]map[interface{}]interface{}, error) {
+	p, err := Process(template, vars)
+	if err != nil {
+		return nil, err
+	}
+	objects, err := ParseObjects(p, namespace)
+	if err != nil {
+		return nil, err
+	}
+	return objects, allKnownTypes(objects)
+}
+
+// changedObjects returns the objects in target that are missing or different in source
+func changedObjects(source, target []map[interface{}]interface{}) []map[interface{}]interface{} {
+	index := map[string]map[interface{}]interface{}{}
+	for _, obj := range source {
+		index[objectKey(obj)] = obj
+	}
+	var changed []map[interface{}]interface{}
+	for _, obj := range target {
+		if existing, found := index[objectKey(obj)]; !found || !reflect.DeepEqual(existing, obj) {
+			changed = append(changed, obj)
+		}
+	}
+	return changed
+}
+
+func objectKey(obj map[interface{}]interface{}) string {
+	return fmt.Sprintf("%v/%v/%v", GetKind(obj), GetNamespace(obj), GetName(obj))
+}
@@ -0,0 +1,40 @@
+package openshift
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var quotaTemplate = `
+---
+apiVersion: v1
+kind: Template
+objects:
+- apiVersion: v1
+  kind: ResourceQuota
+  metadata:
+    name: compute-resources
+  spec:
+    hard:
+      limits.memory: ${QUOTA_MEMORY}
+- apiVersion: v1
+  kind: Service
+  metadata:
+    name: jenkins
+`
+
+func TestChangedObjects(t *testing.T) {
+	source, err := processObjects(quotaTemplate, map[string]string{"QUOTA_MEMORY": "1Gi"}, "aslak-jenkins")
+	require.NoError(t, err)
+	target, err := processObjects(quotaTemplate, map[string]string{"QUOTA_MEMORY": "4Gi"}, "aslak-jenkins")
+	require.NoError(t, err)
+
+	changed := changedObjects(source, target)
+	require.Len(t, changed, 1)
+	assert.Equal(t, "ResourceQuota", GetKind(changed[0]))
+
+	assert.Len(t, changedObjects(source, source), 0)
+	assert.Len(t, changedObjects(nil, target), 2)
+}
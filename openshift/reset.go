@@ -0,0 +1,125 @@
+package openshift
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const (
+	resetDeleteTimeout  = 5 * time.Minute
+	resetDeleteInterval = 2 * time.Second
+)
+
+// ResetNamespace deletes and re-provisions a single tenant namespace of the given type
+// (jenkins, che, test, stage or run) using the same template and variables as InitTenant.
+// When preservePVC is set the namespace itself is kept and all objects but the
+// PersistentVolumeClaims are deleted and re-created.
+func ResetNamespace(config Config, callback Callback, username, nsBaseName string, templateVars map[string]string, nsType string, preservePVC bool) error {
+	name := resolveName(username, nsBaseName)
+	vars := createVariables(config, name, username, templateVars)
+
+	nsname := fmt.Sprintf("%v-%v", name, nsType)
+	teams := teamTemplates(name, vars)
+	var team teamTemplate
+	switch nsType {
+	case "test", "stage", "run":
+		team = teams[0]
+	case "jenkins":
+		team = teams[1]
+	case "che":
+		team = teams[2]
+	default:
+		return fmt.Errorf("namespace type %v can not be reset", nsType)
+	}
+
+	t, err := loadTemplate(config, team.file)
+	if err != nil {
+		return err
+	}
+	p, err := Process(string(t), team.vars)
+	if err != nil {
+		return err
+	}
+	all, err := ParseObjects(p, team.namespace)
+	if err != nil {
+		return err
+	}
+	objects := inNamespace(all, nsname)
+	if len(objects) == 0 {
+		return fmt.Errorf("template %v contains no objects for namespace %v", team.file, nsname)
+	}
+	err = allKnownTypes(objects)
+	if err != nil {
+		return err
+	}
+
+	deleteOpts := ApplyOptions{Config: config, Namespace: nsname}
+	if preservePVC {
+		for _, obj := range objects {
+			kind := GetKind(obj)
+			if kind == ValKindProjectRequest || kind == ValKindProject || kind == ValKindPersistenceVolumeClaim {
+				continue
+			}
+			_, err := apply(obj, "DELETE", deleteOpts)
+			if err != nil {
+				return err
+			}
+		}
+	} else {
+		project := map[interface{}]interface{}{
+			FieldKind:     ValKindProject,
+			FieldMetadata: map[interface{}]interface{}{FieldName: nsname},
+		}
+		_, err := apply(project, "DELETE", deleteOpts)
+		if err != nil {
+			return err
+		}
+		err = waitForDeletion(project, deleteOpts, resetDeleteTimeout)
+		if err != nil {
+			return err
+		}
+	}
+
+	return applyAll(objects, ApplyOptions{Config: config, Namespace: nsname, Callback: callback})
+}
+
+// inNamespace returns the objects that live in the given namespace, including the
+// Project/ProjectRequest creating it
+func inNamespace(objects []map[interface{}]interface{}, namespace string) []map[interface{}]interface{} {
+	var result []map[interface{}]interface{}
+	for _, obj := range objects {
+		kind := GetKind(obj)
+		if kind == ValKindProjectRequest || kind == ValKindProject {
+			if GetName(obj) == namespace {
+				result = append(result, obj)
+			}
+			continue
+		}
+		if GetNamespace(obj) == namespace {
+			result = append(result, obj)
+		}
+	}
+	return result
+}
+
+// waitForDeletion polls the given object until the API reports it as gone
+func waitForDeletion(object map[interface{}]interface{}, opts ApplyOptions, timeout time.Duration) error {
+	var status int
+	opts.Callback = func(statusCode int, method string, request, response map[interface{}]interface{}) (string, map[interface{}]interface{}) {
+		status = statusCode
+		return "", nil
+	}
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		_, err := apply(object, "GET", opts)
+		if err != nil {
+			return err
+		}
+		if status == http.StatusNotFound || status == http.StatusForbidden {
+			return nil
+		}
+		time.Sleep(resetDeleteInterval)
+	}
+	return fmt.Errorf("timed out waiting for %v %v to be deleted", GetKind(object), GetName(object))
+}
@@ -0,0 +1,52 @@
+package openshift
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var resetTemplate = `
+---
+apiVersion: v1
+kind: Template
+objects:
+- apiVersion: v1
+  kind: ProjectRequest
+  metadata:
+    name: aslak-test
+- apiVersion: v1
+  kind: ProjectRequest
+  metadata:
+    name: aslak-stage
+- apiVersion: v1
+  kind: RoleBinding
+  metadata:
+    name: edit
+    namespace: aslak-test
+- apiVersion: v1
+  kind: RoleBinding
+  metadata:
+    name: edit
+    namespace: aslak-stage
+- apiVersion: v1
+  kind: ConfigMap
+  metadata:
+    name: defaults
+`
+
+func TestInNamespace(t *testing.T) {
+	all, err := ParseObjects(resetTemplate, "aslak")
+	require.NoError(t, err)
+
+	objects := inNamespace(all, "aslak-test")
+	require.Len(t, objects, 2)
+	assert.Equal(t, ValKindProjectRequest, GetKind(objects[0]))
+	assert.Equal(t, "aslak-test", GetName(objects[0]))
+	assert.Equal(t, "RoleBinding", GetKind(objects[1]))
+	assert.Equal(t, "aslak-test", GetNamespace(objects[1]))
+
+	assert.Len(t, inNamespace(all, "aslak"), 1)
+	assert.Len(t, inNamespace(all, "aslak-run"), 0)
+}
@@ -0,0 +1,111 @@
+package plan
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Template variables contributed by a plan
+const (
+	VarQuotaCPU       = "QUOTA_CPU"
+	VarQuotaMemory    = "QUOTA_MEMORY"
+	VarQuotaPods      = "QUOTA_PODS"
+	VarLimitCPU       = "LIMIT_CPU"
+	VarLimitMemory    = "LIMIT_MEMORY"
+	VarJenkinsPVCSize = "JENKINS_PVC_SIZE"
+	VarChePVCSize     = "CHE_PVC_SIZE"
+	VarDefaultPVCSize = "DEFAULT_PVC_SIZE"
+)
+
+// Represents the available plans
+const (
+	Free       = "free"
+	Team       = "team"
+	Enterprise = "enterprise"
+
+	// Default is the plan of tenants that did not choose one
+	Default = Free
+)
+
+// Plan describes the resources a tenant is entitled to
+type Plan struct {
+	Name string
+	Vars map[string]string
+}
+
+var plans = map[string]Plan{
+	Free: {
+		Name: Free,
+		Vars: map[string]string{
+			VarQuotaCPU:       "2",
+			VarQuotaMemory:    "3Gi",
+			VarQuotaPods:      "10",
+			VarLimitCPU:       "1",
+			VarLimitMemory:    "1Gi",
+			VarJenkinsPVCSize: "1Gi",
+			VarChePVCSize:     "1Gi",
+			VarDefaultPVCSize: "1Gi",
+		},
+	},
+	Team: {
+		Name: Team,
+		Vars: map[string]string{
+			VarQuotaCPU:       "4",
+			VarQuotaMemory:    "8Gi",
+			VarQuotaPods:      "30",
+			VarLimitCPU:       "2",
+			VarLimitMemory:    "2Gi",
+			VarJenkinsPVCSize: "5Gi",
+			VarChePVCSize:     "5Gi",
+			VarDefaultPVCSize: "2Gi",
+		},
+	},
+	Enterprise: {
+		Name: Enterprise,
+		Vars: map[string]string{
+			VarQuotaCPU:       "16",
+			VarQuotaMemory:    "32Gi",
+			VarQuotaPods:      "100",
+			VarLimitCPU:       "4",
+			VarLimitMemory:    "8Gi",
+			VarJenkinsPVCSize: "20Gi",
+			VarChePVCSize:     "20Gi",
+			VarDefaultPVCSize: "10Gi",
+		},
+	},
+}
+
+// Get returns the plan with the given name, an empty name returns the Default plan
+func Get(name string) (Plan, error) {
+	if name == "" {
+		name = Default
+	}
+	p, found := plans[name]
+	if !found {
+		return Plan{}, fmt.Errorf("unknown plan %v", name)
+	}
+	return p, nil
+}
+
+// Names returns the names of all available plans
+func Names() []string {
+	var names []string
+	for name := range plans {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// TemplateVars returns the given variables extended with the plan variables. Variables
+// already present in base, e.g. set through configuration, are not overridden.
+func (p Plan) TemplateVars(base map[string]string) map[string]string {
+	vars := map[string]string{}
+	for k, v := range p.Vars {
+		vars[k] = v
+	}
+	for k, v := range base {
+		vars[k] = v
+	}
+	return vars
+}
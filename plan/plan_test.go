@@ -0,0 +1,39 @@
+package plan_test
+
+import (
+	"testing"
+
+	"github.com/almighty/almighty-core/resource"
+	"github.com/fabric8io/fabric8-init-tenant/plan"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGet(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+
+	p, err := plan.Get("")
+	require.NoError(t, err)
+	assert.Equal(t, plan.Default, p.Name)
+
+	p, err = plan.Get(plan.Enterprise)
+	require.NoError(t, err)
+	assert.Equal(t, plan.Enterprise, p.Name)
+
+	_, err = plan.Get("gold")
+	assert.Error(t, err)
+}
+
+func TestTemplateVars(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+
+	p, err := plan.Get(plan.Team)
+	require.NoError(t, err)
+
+	vars := p.TemplateVars(map[string]string{"DOMAIN": "example.com", plan.VarQuotaPods: "5"})
+	assert.Equal(t, "example.com", vars["DOMAIN"])
+	assert.Equal(t, "5", vars[plan.VarQuotaPods])
+	assert.Equal(t, "8Gi", vars[plan.VarQuotaMemory])
+}
@@ -0,0 +1,153 @@
+// Package progress fans out the provisioning progress of tenants to the clients following it.
+// Events are kept in memory for a while, so a client reconnecting to the same instance of the
+// service resumes where it stopped.
+package progress
+
+import (
+	"sync"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// Represents the event types
+const (
+	EventObjectCreated         = "object-created"
+	EventObjectFailed          = "object-failed"
+	EventNamespaceReady        = "namespace-ready"
+	EventProvisioningCompleted = "provisioning-completed"
+	EventProvisioningFailed    = "provisioning-failed"
+	EventJobCompleted          = "job-completed"
+	EventJobFailed             = "job-failed"
+)
+
+// DefaultRetention is how long events are kept for clients resuming a stream
+const DefaultRetention = time.Hour
+
+// subscriberBuffer is the number of events queued for a subscriber before it is considered too slow
+// and disconnected
+const subscriberBuffer = 64
+
+// Event reports a step of the provisioning of a tenant
+type Event struct {
+	// ID increases with every event published, also across restarts of the service
+	ID        int64     `json:"-"`
+	Type      string    `json:"-"`
+	Time      time.Time `json:"time"`
+	Namespace string    `json:"namespace,omitempty"`
+	Kind      string    `json:"kind,omitempty"`
+	Name      string    `json:"name,omitempty"`
+	JobID     string    `json:"job_id,omitempty"`
+	Error     string    `json:"error,omitempty"`
+}
+
+type stream struct {
+	events      []Event
+	subscribers map[chan Event]struct{}
+}
+
+// Broker publishes the events of each tenant to its subscribers
+type Broker struct {
+	retention time.Duration
+	mu        sync.Mutex
+	lastID    int64
+	lastSweep time.Time
+	streams   map[uuid.UUID]*stream
+}
+
+// NewBroker creates a new Broker keeping the events for the given duration
+func NewBroker(retention time.Duration) *Broker {
+	return &Broker{
+		retention: retention,
+		lastID:    time.Now().UnixNano(),
+		lastSweep: time.Now(),
+		streams:   map[uuid.UUID]*stream{},
+	}
+}
+
+// expire drops the events older than the retention from the stream
+func (s *stream) expire(retained time.Time) {
+	for len(s.events) > 0 && s.events[0].Time.Before(retained) {
+		s.events = s.events[1:]
+	}
+}
+
+// sweep expires the events of all tenants once per retention period and forgets the tenants
+// without events and subscribers
+func (b *Broker) sweep(retained time.Time) {
+	if b.lastSweep.After(retained) {
+		return
+	}
+	b.lastSweep = time.Now()
+	for tenantID, s := range b.streams {
+		s.expire(retained)
+		if len(s.events) == 0 && len(s.subscribers) == 0 {
+			delete(b.streams, tenantID)
+		}
+	}
+}
+
+func (b *Broker) stream(tenantID uuid.UUID) *stream {
+	s, found := b.streams[tenantID]
+	if !found {
+		s = &stream{subscribers: map[chan Event]struct{}{}}
+		b.streams[tenantID] = s
+	}
+	return s
+}
+
+// Publish assigns the next ID to the event of the tenant and sends it to all subscribers. Subscribers
+// not keeping up are disconnected, they resume from the last event they received.
+func (b *Broker) Publish(tenantID uuid.UUID, event Event) Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.lastID++
+	event.ID = b.lastID
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	retained := time.Now().Add(-b.retention)
+	b.sweep(retained)
+	s := b.stream(tenantID)
+	s.expire(retained)
+	s.events = append(s.events, event)
+	for ch := range s.subscribers {
+		select {
+		case ch <- event:
+		default:
+			delete(s.subscribers, ch)
+			close(ch)
+		}
+	}
+	return event
+}
+
+// Subscribe returns the retained events of the tenant published after the given event ID and a channel
+// receiving the events published from now on. The channel is closed when the subscriber falls behind
+// or is cancelled.
+func (b *Broker) Subscribe(tenantID uuid.UUID, lastEventID int64) ([]Event, <-chan Event, func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	s := b.stream(tenantID)
+	var missed []Event
+	for _, e := range s.events {
+		if e.ID > lastEventID {
+			missed = append(missed, e)
+		}
+	}
+	ch := make(chan Event, subscriberBuffer)
+	s.subscribers[ch] = struct{}{}
+	cancel := func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if _, found := s.subscribers[ch]; found {
+			delete(s.subscribers, ch)
+			close(ch)
+		}
+		if len(s.subscribers) == 0 && len(s.events) == 0 {
+			delete(b.streams, tenantID)
+		}
+	}
+	return missed, ch, cancel
+}
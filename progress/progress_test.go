@@ -0,0 +1,81 @@
+package progress_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/almighty/almighty-core/resource"
+	"github.com/fabric8io/fabric8-init-tenant/progress"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubscribe(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+
+	b := progress.NewBroker(progress.DefaultRetention)
+	tenantID := uuid.NewV4()
+	first := b.Publish(tenantID, progress.Event{Type: progress.EventNamespaceReady, Name: "aslak-jenkins"})
+	b.Publish(uuid.NewV4(), progress.Event{Type: progress.EventNamespaceReady, Name: "other-jenkins"})
+
+	missed, events, cancel := b.Subscribe(tenantID, 0)
+	defer cancel()
+	require.Len(t, missed, 1)
+	assert.Equal(t, first, missed[0])
+
+	second := b.Publish(tenantID, progress.Event{Type: progress.EventObjectCreated, Kind: "Route", Name: "jenkins"})
+	assert.True(t, second.ID > first.ID)
+	select {
+	case e := <-events:
+		assert.Equal(t, second, e)
+	case <-time.After(time.Second):
+		t.Fatal("event not received")
+	}
+}
+
+func TestSubscribeResumes(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+
+	b := progress.NewBroker(progress.DefaultRetention)
+	tenantID := uuid.NewV4()
+	first := b.Publish(tenantID, progress.Event{Type: progress.EventNamespaceReady, Name: "aslak-jenkins"})
+	second := b.Publish(tenantID, progress.Event{Type: progress.EventNamespaceReady, Name: "aslak-che"})
+
+	missed, _, cancel := b.Subscribe(tenantID, first.ID)
+	defer cancel()
+	require.Len(t, missed, 1)
+	assert.Equal(t, second, missed[0])
+}
+
+func TestSubscribeExpires(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+
+	b := progress.NewBroker(time.Minute)
+	tenantID := uuid.NewV4()
+	b.Publish(tenantID, progress.Event{Type: progress.EventNamespaceReady, Time: time.Now().Add(-time.Hour)})
+	current := b.Publish(tenantID, progress.Event{Type: progress.EventProvisioningCompleted})
+
+	missed, _, cancel := b.Subscribe(tenantID, 0)
+	defer cancel()
+	require.Len(t, missed, 1)
+	assert.Equal(t, current, missed[0])
+}
+
+func TestSlowSubscriberIsDisconnected(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+
+	b := progress.NewBroker(progress.DefaultRetention)
+	tenantID := uuid.NewV4()
+	_, events, cancel := b.Subscribe(tenantID, 0)
+	defer cancel()
+	for i := 0; i < 100; i++ {
+		b.Publish(tenantID, progress.Event{Type: progress.EventObjectCreated})
+	}
+
+	received := 0
+	for range events {
+		received++
+	}
+	assert.True(t, received < 100)
+}
@@ -0,0 +1,265 @@
+// Package relocate migrates tenants between clusters.
+package relocate
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/almighty/almighty-core/errors"
+	"github.com/almighty/almighty-core/log"
+	"github.com/fabric8io/fabric8-init-tenant/audit"
+	"github.com/fabric8io/fabric8-init-tenant/cluster"
+	"github.com/fabric8io/fabric8-init-tenant/openshift"
+	"github.com/fabric8io/fabric8-init-tenant/tenant"
+	uuid "github.com/satori/go.uuid"
+)
+
+// Represents the steps of a migration, recorded on the job as they complete
+const (
+	StepCheck     = "check"
+	StepProvision = "provision"
+	StepCopy      = "copy"
+	StepSwitch    = "switch"
+	StepTeardown  = "teardown"
+)
+
+// Steps are the migration steps in the order they are performed
+var Steps = []string{StepCheck, StepProvision, StepCopy, StepSwitch, StepTeardown}
+
+// ErrMigrationInProgress is returned when a migration of the tenant is already running
+var ErrMigrationInProgress = errors.NewVersionConflictError("a migration of the tenant is already in progress")
+
+// Provisioner provisions the tenant namespaces in the given template version on the cluster of the
+// config without recording them, the tenant keeps using its current cluster until it is switched
+type Provisioner func(ctx context.Context, config openshift.Config, t *tenant.Tenant, version string) error
+
+// Migrator moves tenants to another cluster. Each migration is tracked as a job recording the last
+// completed step, so a failed or interrupted migration can be resumed where it stopped.
+type Migrator struct {
+	service   tenant.Service
+	clusters  *cluster.Registry
+	provision Provisioner
+	auditor   audit.Service
+
+	mu      sync.Mutex
+	running map[uuid.UUID]bool
+}
+
+// New creates a new Migrator
+func New(service tenant.Service, clusters *cluster.Registry, provision Provisioner, auditor audit.Service) *Migrator {
+	return &Migrator{
+		service:   service,
+		clusters:  clusters,
+		provision: provision,
+		auditor:   auditor,
+		running:   map[uuid.UUID]bool{},
+	}
+}
+
+// Start migrates the tenant to the cluster with the given API URL in the background. An unfinished
+// migration of the tenant to the same cluster is resumed instead of starting over. Returns the job
+// as it was when the migration started.
+func (m *Migrator) Start(ctx context.Context, t *tenant.Tenant, targetURL string) (tenant.Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.running[t.ID] {
+		return tenant.Job{}, ErrMigrationInProgress
+	}
+	job, err := m.prepare(t, targetURL)
+	if err != nil {
+		return tenant.Job{}, err
+	}
+	m.running[t.ID] = true
+	snapshot := *job
+	go m.run(ctx, t, job)
+	return snapshot, nil
+}
+
+// Resume restarts the migrations interrupted while running, e.g. by a restart of the service
+func (m *Migrator) Resume(ctx context.Context) error {
+	jobs, err := m.service.FindJobs(tenant.JobTypeMigrate, tenant.JobStateRunning)
+	if err != nil {
+		return err
+	}
+	for _, job := range jobs {
+		t, err := m.service.GetTenant(job.TenantID)
+		if err != nil {
+			return err
+		}
+		_, err = m.Start(ctx, t, job.Target)
+		if err != nil && err != ErrMigrationInProgress {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *Migrator) prepare(t *tenant.Tenant, targetURL string) (*tenant.Job, error) {
+	target, err := m.clusters.Config(targetURL)
+	if err != nil {
+		return nil, errors.NewBadParameterError("cluster-url", targetURL)
+	}
+	jobs, err := m.service.GetJobs(t.ID, tenant.JobTypeMigrate)
+	if err != nil {
+		return nil, err
+	}
+	for _, job := range jobs {
+		if job.State != tenant.JobStateCompleted && job.Target == target.MasterURL {
+			job.State = tenant.JobStateRunning
+			job.Error = ""
+			job.CompletedAt = nil
+			return job, m.service.UpdateJob(job)
+		}
+	}
+
+	source, err := m.clusters.Config(t.MasterURL)
+	if err != nil {
+		return nil, err
+	}
+	if source.MasterURL == target.MasterURL {
+		return nil, errors.NewBadParameterError("cluster-url", targetURL)
+	}
+	job := &tenant.Job{
+		TenantID: t.ID,
+		Type:     tenant.JobTypeMigrate,
+		Source:   source.MasterURL,
+		Target:   target.MasterURL,
+		State:    tenant.JobStateRunning,
+	}
+	return job, m.service.UpdateJob(job)
+}
+
+func (m *Migrator) run(ctx context.Context, t *tenant.Tenant, job *tenant.Job) {
+	defer func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		delete(m.running, t.ID)
+	}()
+
+	err := m.migrate(ctx, t, job)
+	if err != nil {
+		log.Error(ctx, map[string]interface{}{
+			"err":       err,
+			"tenant_id": t.ID,
+			"job_id":    job.ID,
+		}, "unable to migrate tenant")
+	} else {
+		log.Info(ctx, map[string]interface{}{
+			"tenant_id":   t.ID,
+			"job_id":      job.ID,
+			"cluster_url": job.Target,
+		}, "tenant migrated")
+	}
+	job.Complete(err)
+	if err := m.service.UpdateJob(job); err != nil {
+		log.Error(ctx, map[string]interface{}{
+			"err":    err,
+			"job_id": job.ID,
+		}, "unable to record job state")
+	}
+
+	record := audit.NewRecord(ctx, "migrate", t.ID).Completed(err)
+	record.Details = audit.Details{
+		"cluster_url": job.Target,
+		"job_id":      job.ID.String(),
+	}
+	if err := m.auditor.AddRecord(record); err != nil {
+		log.Error(ctx, map[string]interface{}{
+			"err":    err,
+			"job_id": job.ID,
+		}, "unable to record audit trail")
+	}
+}
+
+func (m *Migrator) migrate(ctx context.Context, t *tenant.Tenant, job *tenant.Job) error {
+	source, err := m.clusters.Config(job.Source)
+	if err != nil {
+		return err
+	}
+	target, err := m.clusters.Config(job.Target)
+	if err != nil {
+		return err
+	}
+	namespaces, err := m.service.GetNamespaces(t.ID)
+	if err != nil {
+		return err
+	}
+	var names []string
+	for _, ns := range namespaces {
+		names = append(names, ns.Name)
+	}
+
+	for _, step := range Pending(job.Step) {
+		switch step {
+		case StepCheck:
+			// provisioning into projects of another user would rewrite the objects in them. Once the
+			// check passed the namespaces found on a resumed migration are the ones it provisioned.
+			var taken bool
+			taken, err = openshift.NamespacesExist(target, t.NsBaseName)
+			if err == nil && taken {
+				err = fmt.Errorf("namespaces named after %v already exist on %v", t.NsBaseName, target.MasterURL)
+			}
+		case StepProvision:
+			err = m.provision(ctx, target, t, templateVersion(namespaces))
+		case StepCopy:
+			for _, name := range names {
+				var copied int
+				copied, err = openshift.CopyUserObjects(source, target, name)
+				if err != nil {
+					break
+				}
+				log.Info(ctx, map[string]interface{}{
+					"job_id":    job.ID,
+					"namespace": name,
+					"copied":    copied,
+				}, "copied user objects")
+			}
+		case StepSwitch:
+			err = m.service.UpdateMasterURL(t.ID, target.MasterURL)
+			if err == nil {
+				t.MasterURL = target.MasterURL
+			}
+		case StepTeardown:
+			err = openshift.DeleteNamespaces(source, names)
+		}
+		if err != nil {
+			return fmt.Errorf("migration step %v failed: %v", step, err)
+		}
+		job.Step = step
+		if err := m.service.UpdateJob(job); err != nil {
+			return err
+		}
+		log.Info(ctx, map[string]interface{}{
+			"job_id":    job.ID,
+			"tenant_id": t.ID,
+			"step":      step,
+		}, "migration step completed")
+	}
+	return nil
+}
+
+// Pending returns the steps following the last completed step
+func Pending(completed string) []string {
+	for i, step := range Steps {
+		if step == completed {
+			return Steps[i+1:]
+		}
+	}
+	return Steps
+}
+
+// templateVersion returns the template version the tenant namespaces were provisioned with,
+// preferring the version of the user namespace
+func templateVersion(namespaces []*tenant.Namespace) string {
+	version := ""
+	for _, ns := range namespaces {
+		if ns.Type == tenant.TypeUser {
+			return ns.Version
+		}
+		if version == "" {
+			version = ns.Version
+		}
+	}
+	return version
+}
@@ -0,0 +1,249 @@
+package relocate_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/almighty/almighty-core/resource"
+	"github.com/fabric8io/fabric8-init-tenant/audit"
+	"github.com/fabric8io/fabric8-init-tenant/cluster"
+	"github.com/fabric8io/fabric8-init-tenant/openshift"
+	"github.com/fabric8io/fabric8-init-tenant/relocate"
+	"github.com/fabric8io/fabric8-init-tenant/tenant"
+	"github.com/fabric8io/fabric8-init-tenant/webhook"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeCluster serves the endpoints used by a migration and records the requests
+type fakeCluster struct {
+	mu       sync.Mutex
+	secrets  []map[string]interface{}
+	created  []string
+	deleted  map[string]bool
+	projects map[string]bool
+	requests []string
+}
+
+func (c *fakeCluster) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.requests = append(c.requests, r.Method+" "+r.URL.Path)
+	switch {
+	case r.URL.Path == "/oapi/v1/users/~":
+		fmt.Fprint(w, "metadata:\n  name: master\n")
+	case r.Method == "GET" && strings.HasSuffix(r.URL.Path, "/secrets"):
+		json.NewEncoder(w).Encode(map[string]interface{}{"items": c.secrets})
+	case r.Method == "GET" && strings.HasSuffix(r.URL.Path, "/configmaps"):
+		json.NewEncoder(w).Encode(map[string]interface{}{"items": []interface{}{}})
+	case r.Method == "POST":
+		var obj struct {
+			Metadata struct {
+				Name string
+			}
+		}
+		json.NewDecoder(r.Body).Decode(&obj)
+		c.created = append(c.created, obj.Metadata.Name)
+		w.WriteHeader(http.StatusCreated)
+	case r.Method == "DELETE":
+		c.deleted[r.URL.Path] = true
+	case r.Method == "GET" && c.deleted[r.URL.Path]:
+		w.WriteHeader(http.StatusNotFound)
+	case r.Method == "GET" && strings.HasPrefix(r.URL.Path, "/oapi/v1/projects/"):
+		if !c.projects[strings.TrimPrefix(r.URL.Path, "/oapi/v1/projects/")] {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}
+}
+
+type clusters struct {
+	cluster.NilService
+	clusters []*cluster.Cluster
+}
+
+func (s *clusters) GetCluster(apiURL string) (*cluster.Cluster, error) {
+	for _, c := range s.clusters {
+		if c.APIURL == apiURL {
+			return c, nil
+		}
+	}
+	return nil, nil
+}
+
+type tenants struct {
+	tenant.NilService
+	mu         sync.Mutex
+	namespaces []*tenant.Namespace
+	jobs       []*tenant.Job
+	masterURL  string
+}
+
+func (s *tenants) GetNamespaces(tenantID uuid.UUID) ([]*tenant.Namespace, error) {
+	return s.namespaces, nil
+}
+
+func (s *tenants) UpdateMasterURL(tenantID uuid.UUID, masterURL string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.masterURL = masterURL
+	return nil
+}
+
+// jobs are stored as copies, like a database would
+func (s *tenants) GetJobs(tenantID uuid.UUID, jobType tenant.JobType) ([]*tenant.Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var jobs []*tenant.Job
+	for _, j := range s.jobs {
+		job := *j
+		jobs = append(jobs, &job)
+	}
+	return jobs, nil
+}
+
+func (s *tenants) UpdateJob(job *tenant.Job, events ...webhook.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if job.ID == uuid.Nil {
+		job.ID = uuid.NewV4()
+	}
+	stored := *job
+	for i, j := range s.jobs {
+		if j.ID == job.ID {
+			s.jobs[i] = &stored
+			return nil
+		}
+	}
+	s.jobs = append(s.jobs, &stored)
+	return nil
+}
+
+func (s *tenants) job() tenant.Job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return *s.jobs[0]
+}
+
+func TestMigrate(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+
+	source := &fakeCluster{deleted: map[string]bool{}, secrets: []map[string]interface{}{
+		{"metadata": map[string]interface{}{"name": "github", "uid": "1"}},
+		{"metadata": map[string]interface{}{"name": "builder-token", "annotations": map[string]interface{}{"kubernetes.io/service-account.name": "builder"}}},
+		{"metadata": map[string]interface{}{"name": "templated", "labels": map[string]interface{}{"provider": "fabric8"}}},
+	}}
+	sourceServer := httptest.NewServer(source)
+	defer sourceServer.Close()
+	target := &fakeCluster{deleted: map[string]bool{}}
+	targetServer := httptest.NewServer(target)
+	defer targetServer.Close()
+
+	registry := cluster.NewRegistry(&clusters{clusters: []*cluster.Cluster{
+		{APIURL: sourceServer.URL, Token: "a"},
+		{APIURL: targetServer.URL, Token: "b"},
+	}}, openshift.Config{}, nil)
+
+	service := &tenants{namespaces: []*tenant.Namespace{
+		{Name: "aslak", Type: tenant.TypeUser, Version: "1.0.91"},
+	}}
+	current := &tenant.Tenant{ID: uuid.NewV4(), MasterURL: sourceServer.URL}
+
+	var mu sync.Mutex
+	var provisioned []string
+	fail := true
+	provision := func(ctx context.Context, config openshift.Config, t *tenant.Tenant, version string) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if fail {
+			fail = false
+			return fmt.Errorf("cluster unavailable")
+		}
+		provisioned = append(provisioned, config.MasterURL+"@"+version)
+		return nil
+	}
+	m := relocate.New(service, registry, provision, audit.NilService{})
+
+	job, err := m.Start(context.Background(), current, targetServer.URL)
+	require.NoError(t, err)
+	assert.Equal(t, sourceServer.URL, job.Source)
+	assert.Equal(t, targetServer.URL, job.Target)
+	waitFor(t, service, tenant.JobStateFailed)
+	assert.Equal(t, relocate.StepCheck, service.job().Step)
+
+	// the failed migration is released right after its state is recorded
+	resumed, err := m.Start(context.Background(), current, targetServer.URL)
+	for err == relocate.ErrMigrationInProgress {
+		time.Sleep(10 * time.Millisecond)
+		resumed, err = m.Start(context.Background(), current, targetServer.URL)
+	}
+	require.NoError(t, err)
+	assert.Equal(t, job.ID, resumed.ID)
+	waitFor(t, service, tenant.JobStateCompleted)
+
+	assert.Equal(t, relocate.StepTeardown, service.job().Step)
+	assert.Equal(t, []string{targetServer.URL + "@1.0.91"}, provisioned)
+	assert.Equal(t, []string{"github"}, target.created)
+	assert.Equal(t, targetServer.URL, service.masterURL)
+	assert.True(t, source.deleted["/oapi/v1/projects/aslak"])
+}
+
+func TestMigrateRefusesTakenNamespaces(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+
+	source := &fakeCluster{deleted: map[string]bool{}}
+	sourceServer := httptest.NewServer(source)
+	defer sourceServer.Close()
+	target := &fakeCluster{deleted: map[string]bool{}, projects: map[string]bool{"aslak-che": true}}
+	targetServer := httptest.NewServer(target)
+	defer targetServer.Close()
+
+	registry := cluster.NewRegistry(&clusters{clusters: []*cluster.Cluster{
+		{APIURL: sourceServer.URL, Token: "a"},
+		{APIURL: targetServer.URL, Token: "b"},
+	}}, openshift.Config{}, nil)
+
+	service := &tenants{namespaces: []*tenant.Namespace{
+		{Name: "aslak", Type: tenant.TypeUser, Version: "1.0.91"},
+	}}
+	current := &tenant.Tenant{ID: uuid.NewV4(), MasterURL: sourceServer.URL, NsBaseName: "aslak"}
+	provisioned := false
+	provision := func(ctx context.Context, config openshift.Config, t *tenant.Tenant, version string) error {
+		provisioned = true
+		return nil
+	}
+	m := relocate.New(service, registry, provision, audit.NilService{})
+
+	_, err := m.Start(context.Background(), current, targetServer.URL)
+	require.NoError(t, err)
+	waitFor(t, service, tenant.JobStateFailed)
+	assert.Equal(t, "", service.job().Step)
+	assert.Contains(t, service.job().Error, "already exist")
+	assert.False(t, provisioned)
+}
+
+func TestPending(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+
+	assert.Equal(t, relocate.Steps, relocate.Pending(""))
+	assert.Equal(t, []string{relocate.StepSwitch, relocate.StepTeardown}, relocate.Pending(relocate.StepCopy))
+	assert.Equal(t, relocate.Steps[1:], relocate.Pending(relocate.StepCheck))
+	assert.Empty(t, relocate.Pending(relocate.StepTeardown))
+}
+
+func waitFor(t *testing.T, service *tenants, state string) {
+	for i := 0; i < 100; i++ {
+		if service.job().State == state {
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	t.Fatalf("job did not reach state %v", state)
+}
@@ -0,0 +1,36 @@
+package tenant
+
+import (
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// Represents the columns tenants can be sorted by
+const (
+	SortByCreatedAt = "created_at"
+	SortByEmail     = "email"
+)
+
+// TenantFilter selects tenants, unset fields match all tenants
+type TenantFilter struct {
+	// Email matches the tenants whose email contains the value, ignoring case
+	Email string
+	// NamespaceState and NamespaceVersion match the tenants owning at least one namespace
+	// in the given state and version
+	NamespaceState   string
+	NamespaceVersion string
+	// MasterURL matches the tenants placed on the cluster with the given API URL
+	MasterURL     string
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+}
+
+// Page selects a page of tenants in the given order. The page starts after the tenant with
+// the ID of the cursor, the last tenant of the previous page.
+type Page struct {
+	SortBy     string
+	Descending bool
+	After      *uuid.UUID
+	Limit      int
+}
@@ -1,16 +1,40 @@
 package tenant
 
 import (
+	"time"
+
+	"github.com/almighty/almighty-core/errors"
+	"github.com/fabric8io/fabric8-init-tenant/webhook"
 	"github.com/jinzhu/gorm"
+	"github.com/lib/pq"
 	uuid "github.com/satori/go.uuid"
 )
 
+// ErrBaseNameTaken is returned when storing a tenant whose namespace base name was stored by
+// another tenant first
+var ErrBaseNameTaken = errors.NewVersionConflictError("the namespace base name is already taken by another tenant")
+
 type Service interface {
 	Exists(tenantID uuid.UUID) bool
 	GetTenant(tenantID uuid.UUID) (*Tenant, error)
 	GetNamespaces(tenantID uuid.UUID) ([]*Namespace, error)
+	FindTenants(version, masterURL string) ([]*Tenant, error)
+	ListTenants(filter TenantFilter, page Page) ([]*Tenant, error)
+	CountTenants(filter TenantFilter) (int, error)
+	FindNamespaces(tenantIDs []uuid.UUID) ([]*Namespace, error)
+	IsBaseNameUsed(name string) (bool, error)
 	UpdateTenant(tenant *Tenant) error
-	UpdateNamespace(namespace *Namespace) error
+	UpdateNamespace(namespace *Namespace, events ...webhook.Event) error
+	RecordActivity(tenantID uuid.UUID) error
+	GetInactiveNamespaces(inactiveSince time.Time, types ...NamespaceType) ([]*Namespace, error)
+	MarkIdled(namespaceID uuid.UUID, inactiveSince time.Time) (bool, error)
+	MarkUnidled(namespaceID uuid.UUID) error
+	UpdateMasterURL(tenantID uuid.UUID, masterURL string) error
+	DeleteTenant(tenantID uuid.UUID, events ...webhook.Event) error
+	GetJob(jobID uuid.UUID) (*Job, error)
+	GetJobs(tenantID uuid.UUID, jobType JobType) ([]*Job, error)
+	FindJobs(jobType JobType, state string) ([]*Job, error)
+	UpdateJob(job *Job, events ...webhook.Event) error
 }
 
 func NewDBService(db *gorm.DB) Service {
@@ -39,15 +63,21 @@ func (s DBService) GetTenant(tenantID uuid.UUID) (*Tenant, error) {
 	return &t, nil
 }
 
+// UpdateTenant stores the tenant, or returns ErrBaseNameTaken if another tenant holds its namespace base name
 func (s DBService) UpdateTenant(tenant *Tenant) error {
-	return s.db.Save(tenant).Error
+	err := s.db.Save(tenant).Error
+	if err, ok := err.(*pq.Error); ok && err.Code == "23505" && err.Constraint == "uix_tenants_ns_base_name" {
+		return ErrBaseNameTaken
+	}
+	return err
 }
 
-func (s DBService) UpdateNamespace(namespace *Namespace) error {
+// UpdateNamespace stores the namespace and publishes the events in the same transaction
+func (s DBService) UpdateNamespace(namespace *Namespace, events ...webhook.Event) error {
 	if namespace.ID == uuid.Nil {
 		namespace.ID = uuid.NewV4()
 	}
-	return s.db.Save(namespace).Error
+	return s.save(namespace, events)
 }
 
 func (s DBService) GetNamespaces(tenantID uuid.UUID) ([]*Namespace, error) {
@@ -59,6 +89,255 @@ func (s DBService) GetNamespaces(tenantID uuid.UUID) ([]*Namespace, error) {
 	return t, nil
 }
 
+// FindTenants returns the tenants owning at least one namespace on the given version and cluster.
+// Empty arguments match any version or cluster.
+func (s DBService) FindTenants(version, masterURL string) ([]*Tenant, error) {
+	var t []*Tenant
+	query := "id IN (SELECT tenant_id FROM namespaces WHERE deleted_at IS NULL"
+	var args []interface{}
+	if version != "" {
+		query += " AND version = ?"
+		args = append(args, version)
+	}
+	if masterURL != "" {
+		query += " AND master_url = ?"
+		args = append(args, masterURL)
+	}
+	query += ")"
+	err := s.db.Table(Tenant{}.TableName()).Where(query, args...).Order("created_at").Find(&t).Error
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// ListTenants returns a page of the tenants matching the filter
+func (s DBService) ListTenants(filter TenantFilter, page Page) ([]*Tenant, error) {
+	column := SortByCreatedAt
+	if page.SortBy == SortByEmail {
+		column = SortByEmail
+	}
+	direction, after := "asc", ">"
+	if page.Descending {
+		direction, after = "desc", "<"
+	}
+	query := s.filterTenants(filter)
+	if page.After != nil {
+		// keyset pagination, the ID breaks ties between tenants with the same sort value
+		query = query.Where(
+			"("+column+", id) "+after+" (SELECT "+column+", id FROM tenants WHERE id = ?)",
+			*page.After)
+	}
+	var t []*Tenant
+	err := query.Order(column + " " + direction).Order("id " + direction).Limit(page.Limit).Find(&t).Error
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// CountTenants returns the number of tenants matching the filter
+func (s DBService) CountTenants(filter TenantFilter) (int, error) {
+	var count int
+	err := s.filterTenants(filter).Count(&count).Error
+	return count, err
+}
+
+func (s DBService) filterTenants(filter TenantFilter) *gorm.DB {
+	query := s.db.Table(Tenant{}.TableName()).Where("deleted_at IS NULL")
+	if filter.Email != "" {
+		query = query.Where("email ILIKE ?", "%"+filter.Email+"%")
+	}
+	if filter.NamespaceState != "" || filter.NamespaceVersion != "" {
+		namespaces := "id IN (SELECT tenant_id FROM namespaces WHERE deleted_at IS NULL"
+		var args []interface{}
+		if filter.NamespaceState != "" {
+			namespaces += " AND state = ?"
+			args = append(args, filter.NamespaceState)
+		}
+		if filter.NamespaceVersion != "" {
+			namespaces += " AND version = ?"
+			args = append(args, filter.NamespaceVersion)
+		}
+		query = query.Where(namespaces+")", args...)
+	}
+	if filter.MasterURL != "" {
+		query = query.Where("master_url = ?", filter.MasterURL)
+	}
+	if filter.CreatedAfter != nil {
+		query = query.Where("created_at >= ?", *filter.CreatedAfter)
+	}
+	if filter.CreatedBefore != nil {
+		query = query.Where("created_at < ?", *filter.CreatedBefore)
+	}
+	return query
+}
+
+// FindNamespaces returns the namespaces of all given tenants
+func (s DBService) FindNamespaces(tenantIDs []uuid.UUID) ([]*Namespace, error) {
+	var t []*Namespace
+	if len(tenantIDs) == 0 {
+		return t, nil
+	}
+	err := s.db.Table(Namespace{}.TableName()).Where("tenant_id IN (?)", tenantIDs).Find(&t).Error
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// RecordActivity marks all namespaces of the tenant as active now
+func (s DBService) RecordActivity(tenantID uuid.UUID) error {
+	return s.db.Table(Namespace{}.TableName()).Where("tenant_id = ?", tenantID).UpdateColumn("last_activity_at", time.Now()).Error
+}
+
+// GetInactiveNamespaces returns the namespaces of the given types that are not idled and
+// have not seen any activity since the given time
+func (s DBService) GetInactiveNamespaces(inactiveSince time.Time, types ...NamespaceType) ([]*Namespace, error) {
+	var t []*Namespace
+	err := s.db.Table(Namespace{}.TableName()).
+		Where("idled_at IS NULL AND type IN (?)", types).
+		Where("COALESCE(last_activity_at, created_at) < ?", inactiveSince).
+		Find(&t).Error
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// MarkIdled records the namespace as idled unless it has seen activity since the given time.
+// It returns false if the namespace was active and is not marked. Like all changes of the
+// namespace representation it bumps the update time.
+func (s DBService) MarkIdled(namespaceID uuid.UUID, inactiveSince time.Time) (bool, error) {
+	result := s.db.Table(Namespace{}.TableName()).
+		Where("id = ? AND COALESCE(last_activity_at, created_at) < ?", namespaceID, inactiveSince).
+		UpdateColumns(map[string]interface{}{"idled_at": time.Now(), "updated_at": time.Now()})
+	return result.RowsAffected > 0, result.Error
+}
+
+// MarkUnidled records that the namespace deployments are scaled back up
+func (s DBService) MarkUnidled(namespaceID uuid.UUID) error {
+	return s.db.Table(Namespace{}.TableName()).Where("id = ?", namespaceID).
+		UpdateColumns(map[string]interface{}{"idled_at": gorm.Expr("NULL"), "updated_at": time.Now()}).Error
+}
+
+func (s DBService) GetJob(jobID uuid.UUID) (*Job, error) {
+	var j Job
+	err := s.db.Table(j.TableName()).Where("id = ?", jobID).Find(&j).Error
+	if err != nil {
+		return nil, err
+	}
+	return &j, nil
+}
+
+// GetJobs returns the jobs of the given type performed on the tenant, the most recent first
+func (s DBService) GetJobs(tenantID uuid.UUID, jobType JobType) ([]*Job, error) {
+	var j []*Job
+	err := s.db.Table(Job{}.TableName()).Where("tenant_id = ? AND type = ?", tenantID, jobType).Order("created_at desc").Find(&j).Error
+	if err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+// FindJobs returns all jobs of the given type in the given state
+func (s DBService) FindJobs(jobType JobType, state string) ([]*Job, error) {
+	var j []*Job
+	err := s.db.Table(Job{}.TableName()).Where("type = ? AND state = ?", jobType, state).Order("created_at").Find(&j).Error
+	if err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+// UpdateJob stores the job and publishes the events in the same transaction
+func (s DBService) UpdateJob(job *Job, events ...webhook.Event) error {
+	if job.ID == uuid.Nil {
+		job.ID = uuid.NewV4()
+	}
+	return s.save(job, events)
+}
+
+// save stores the value and publishes the events reporting the change in a single transaction
+func (s DBService) save(value interface{}, events []webhook.Event) error {
+	if len(events) == 0 {
+		return s.db.Save(value).Error
+	}
+	tx := s.db.Begin()
+	if err := tx.Save(value).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := publish(tx, events); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit().Error
+}
+
+func publish(tx *gorm.DB, events []webhook.Event) error {
+	for _, e := range events {
+		if err := webhook.Publish(tx, e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UpdateMasterURL moves the tenant and all its namespaces to the cluster with the given API URL in a single transaction
+func (s DBService) UpdateMasterURL(tenantID uuid.UUID, masterURL string) error {
+	tx := s.db.Begin()
+	columns := map[string]interface{}{"master_url": masterURL, "updated_at": time.Now()}
+	err := tx.Table(Namespace{}.TableName()).Where("tenant_id = ?", tenantID).UpdateColumns(columns).Error
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	err = tx.Table(Tenant{}.TableName()).Where("id = ?", tenantID).UpdateColumns(columns).Error
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit().Error
+}
+
+// DeleteTenant deletes the tenant and its namespaces and publishes the events in a single transaction.
+// The rows are removed rather than soft deleted, so the tenant can be set up again with the same ID.
+func (s DBService) DeleteTenant(tenantID uuid.UUID, events ...webhook.Event) error {
+	tx := s.db.Begin()
+	err := tx.Unscoped().Where("tenant_id = ?", tenantID).Delete(Namespace{}).Error
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	err = tx.Unscoped().Where("id = ?", tenantID).Delete(Tenant{}).Error
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := publish(tx, events); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit().Error
+}
+
+// IsBaseNameUsed checks if a tenant has allocated the namespace base name or if any known
+// namespace is named after it
+func (s DBService) IsBaseNameUsed(name string) (bool, error) {
+	var count int
+	err := s.db.Table(Tenant{}.TableName()).Where("ns_base_name = ?", name).Count(&count).Error
+	if err != nil || count > 0 {
+		return count > 0, err
+	}
+	names := []string{name}
+	for _, t := range []NamespaceType{TypeJenkins, TypeChe, TypeTest, TypeStage, TypeRun} {
+		names = append(names, name+"-"+string(t))
+	}
+	err = s.db.Table(Namespace{}.TableName()).Where("name IN (?)", names).Count(&count).Error
+	return count > 0, err
+}
+
 type NilService struct {
 }
 
@@ -74,10 +353,70 @@ func (s NilService) GetNamespaces(tenantID uuid.UUID) ([]*Namespace, error) {
 	return nil, nil
 }
 
+func (s NilService) FindTenants(version, masterURL string) ([]*Tenant, error) {
+	return nil, nil
+}
+
+func (s NilService) ListTenants(filter TenantFilter, page Page) ([]*Tenant, error) {
+	return nil, nil
+}
+
+func (s NilService) CountTenants(filter TenantFilter) (int, error) {
+	return 0, nil
+}
+
+func (s NilService) FindNamespaces(tenantIDs []uuid.UUID) ([]*Namespace, error) {
+	return nil, nil
+}
+
+func (s NilService) IsBaseNameUsed(name string) (bool, error) {
+	return false, nil
+}
+
 func (s NilService) UpdateTenant(tenant *Tenant) error {
 	return nil
 }
 
-func (s NilService) UpdateNamespace(namespace *Namespace) error {
+func (s NilService) UpdateNamespace(namespace *Namespace, events ...webhook.Event) error {
+	return nil
+}
+
+func (s NilService) RecordActivity(tenantID uuid.UUID) error {
+	return nil
+}
+
+func (s NilService) GetInactiveNamespaces(inactiveSince time.Time, types ...NamespaceType) ([]*Namespace, error) {
+	return nil, nil
+}
+
+func (s NilService) MarkIdled(namespaceID uuid.UUID, inactiveSince time.Time) (bool, error) {
+	return true, nil
+}
+
+func (s NilService) MarkUnidled(namespaceID uuid.UUID) error {
+	return nil
+}
+
+func (s NilService) GetJob(jobID uuid.UUID) (*Job, error) {
+	return nil, nil
+}
+
+func (s NilService) UpdateMasterURL(tenantID uuid.UUID, masterURL string) error {
+	return nil
+}
+
+func (s NilService) DeleteTenant(tenantID uuid.UUID, events ...webhook.Event) error {
+	return nil
+}
+
+func (s NilService) GetJobs(tenantID uuid.UUID, jobType JobType) ([]*Job, error) {
+	return nil, nil
+}
+
+func (s NilService) FindJobs(jobType JobType, state string) ([]*Job, error) {
+	return nil, nil
+}
+
+func (s NilService) UpdateJob(job *Job, events ...webhook.Event) error {
 	return nil
 }
@@ -0,0 +1,105 @@
+package tenant_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/almighty/almighty-core/resource"
+	"github.com/fabric8io/fabric8-init-tenant/configuration"
+	"github.com/fabric8io/fabric8-init-tenant/migration"
+	"github.com/fabric8io/fabric8-init-tenant/tenant"
+	"github.com/jinzhu/gorm"
+	_ "github.com/lib/pq"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func connect(t *testing.T) *gorm.DB {
+	config, err := configuration.NewData()
+	require.NoError(t, err)
+	db, err := gorm.Open("postgres", config.GetPostgresConfigString())
+	require.NoError(t, err)
+	require.NoError(t, migration.Migrate(db.DB()))
+	return db
+}
+
+func TestSetupAfterDelete(t *testing.T) {
+	resource.Require(t, resource.Database)
+
+	db := connect(t)
+	defer db.Close()
+	service := tenant.NewDBService(db)
+
+	id := uuid.NewV4()
+	baseName := "deleted-" + id.String()[:8]
+	require.NoError(t, service.UpdateTenant(&tenant.Tenant{ID: id, Email: "aslak@redhat.com", NsBaseName: baseName}))
+	require.NoError(t, service.UpdateNamespace(&tenant.Namespace{TenantID: id, Name: baseName + "-jenkins", Type: tenant.TypeJenkins}))
+
+	require.NoError(t, service.DeleteTenant(id))
+	assert.False(t, service.Exists(id))
+	used, err := service.IsBaseNameUsed(baseName)
+	require.NoError(t, err)
+	assert.False(t, used)
+
+	// setting up the deleted tenant again stores it with the same ID
+	require.NoError(t, service.UpdateTenant(&tenant.Tenant{ID: id, Email: "aslak@redhat.com", NsBaseName: baseName}))
+	assert.True(t, service.Exists(id))
+	namespaces, err := service.GetNamespaces(id)
+	require.NoError(t, err)
+	assert.Empty(t, namespaces)
+}
+
+func TestUpdateTenantBaseNameTaken(t *testing.T) {
+	resource.Require(t, resource.Database)
+
+	db := connect(t)
+	defer db.Close()
+	service := tenant.NewDBService(db)
+
+	baseName := "taken-" + uuid.NewV4().String()[:8]
+	require.NoError(t, service.UpdateTenant(&tenant.Tenant{ID: uuid.NewV4(), Email: "aslak@redhat.com", NsBaseName: baseName}))
+
+	err := service.UpdateTenant(&tenant.Tenant{ID: uuid.NewV4(), Email: "aslak@example.com", NsBaseName: baseName})
+	assert.Equal(t, tenant.ErrBaseNameTaken, err)
+}
+
+func TestMarkIdled(t *testing.T) {
+	resource.Require(t, resource.Database)
+
+	db := connect(t)
+	defer db.Close()
+	service := tenant.NewDBService(db)
+
+	id := uuid.NewV4()
+	baseName := "idled-" + id.String()[:8]
+	require.NoError(t, service.UpdateTenant(&tenant.Tenant{ID: id, Email: "aslak@redhat.com", NsBaseName: baseName}))
+	ns := &tenant.Namespace{TenantID: id, Name: baseName + "-jenkins", Type: tenant.TypeJenkins}
+	require.NoError(t, service.UpdateNamespace(ns))
+	require.NoError(t, service.RecordActivity(id))
+	activity := time.Now()
+
+	// activity recorded after the namespace was found inactive keeps it from being marked
+	idled, err := service.MarkIdled(ns.ID, activity.Add(-time.Hour))
+	require.NoError(t, err)
+	assert.False(t, idled)
+
+	idled, err = service.MarkIdled(ns.ID, activity.Add(time.Hour))
+	require.NoError(t, err)
+	assert.True(t, idled)
+	namespaces, err := service.GetNamespaces(id)
+	require.NoError(t, err)
+	require.Len(t, namespaces, 1)
+	assert.NotNil(t, namespaces[0].IdledAt)
+	assert.NotNil(t, namespaces[0].LastActivityAt)
+	// conditional requests see the idled namespace as modified
+	assert.True(t, namespaces[0].UpdatedAt.After(ns.UpdatedAt))
+
+	require.NoError(t, service.MarkUnidled(ns.ID))
+	namespaces, err = service.GetNamespaces(id)
+	require.NoError(t, err)
+	assert.Nil(t, namespaces[0].IdledAt)
+	assert.NotNil(t, namespaces[0].LastActivityAt)
+
+	require.NoError(t, service.DeleteTenant(id))
+}
@@ -46,11 +46,19 @@ const (
 
 // Tenant is the owning OpenShift account
 type Tenant struct {
-	ID        uuid.UUID `sql:"type:uuid" gorm:"primary_key"` // This is the ID PK field
-	CreatedAt time.Time
-	UpdatedAt time.Time
-	DeletedAt *time.Time
-	Email     string
+	ID         uuid.UUID `sql:"type:uuid" gorm:"primary_key"` // This is the ID PK field
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	DeletedAt  *time.Time
+	Email      string
+	OSUsername string
+	// NsBaseName is the allocated name all tenant namespaces are derived from
+	NsBaseName string
+	Plan       string
+	// MasterURL is the API URL of the cluster hosting the tenant namespaces
+	MasterURL string
+	// PlacementReason explains why the tenant was placed on its cluster
+	PlacementReason string
 }
 
 // TableName overrides the table name settings in Gorm to force a specific table name
@@ -71,6 +79,10 @@ type Namespace struct {
 	Type      NamespaceType
 	Version   string
 	State     string
+	// LastActivityAt is the last time the owning tenant used the tenant service
+	LastActivityAt *time.Time
+	// IdledAt is set while the namespace deployments are scaled down by the idler
+	IdledAt *time.Time
 }
 
 // TableName overrides the table name settings in Gorm to force a specific table name
@@ -78,3 +90,85 @@ type Namespace struct {
 func (m Namespace) TableName() string {
 	return "namespaces"
 }
+
+// JobType describes which operation a job performs
+type JobType string
+
+// Represents the job types
+const (
+	JobTypeReset      JobType = "reset"
+	JobTypePlanChange JobType = "plan-change"
+	JobTypeMigrate    JobType = "migrate"
+	JobTypeDelete     JobType = "delete"
+	// JobTypeUpgrade is a fleet upgrade rollout, it is not tied to a single tenant
+	JobTypeUpgrade JobType = "upgrade"
+)
+
+// Represents the job states
+const (
+	JobStateRunning   = "running"
+	JobStateCompleted = "completed"
+	JobStateFailed    = "failed"
+)
+
+// JobDetails holds the state specific to the type of a job as JSON
+type JobDetails []byte
+
+// Value - Implementation of valuer for database/sql
+func (d JobDetails) Value() (driver.Value, error) {
+	if len(d) == 0 {
+		return nil, nil
+	}
+	return string(d), nil
+}
+
+// Scan - Implement the database/sql scanner interface
+func (d *JobDetails) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*d = nil
+	case []byte:
+		*d = append(JobDetails{}, v...)
+	case string:
+		*d = JobDetails(v)
+	default:
+		return errors.New("failed to scan JobDetails")
+	}
+	return nil
+}
+
+// Job records a long running operation performed on a tenant
+type Job struct {
+	ID          uuid.UUID `sql:"type:uuid" gorm:"primary_key"`
+	TenantID    uuid.UUID `sql:"type:uuid"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	CompletedAt *time.Time
+	Type        JobType
+	Target      string
+	// Source is where the job moves the target from, e.g. the source cluster of a migration
+	Source string
+	// Step is the last completed step of a multi-step job, a resumed job continues after it
+	Step    string
+	State   string
+	Error   string
+	Details JobDetails `sql:"type:jsonb"`
+}
+
+// TableName overrides the table name settings in Gorm to force a specific table name
+// in the database.
+func (m Job) TableName() string {
+	return "jobs"
+}
+
+// Complete marks the job as completed or failed depending on the given error
+func (m *Job) Complete(err error) {
+	now := time.Now()
+	m.CompletedAt = &now
+	if err != nil {
+		m.State = JobStateFailed
+		m.Error = err.Error()
+		return
+	}
+	m.State = JobStateCompleted
+}
@@ -0,0 +1,134 @@
+// Package tenantclient builds on the client generated from the design by `make generate`. It adds what
+// other services need to provision a tenant: signing the requests with the token of the user, mapping
+// the responses to errors and waiting until the tenant namespaces are ready.
+package tenantclient
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/fabric8io/fabric8-init-tenant/client"
+	goaclient "github.com/goadesign/goa/client"
+)
+
+// ErrNotFound is returned by Show when the user has no tenant yet
+var ErrNotFound = fmt.Errorf("tenant not found")
+
+// New creates a client of the tenant service at the given URL, e.g. https://f8tenant.openshift.io,
+// signing every request with the signer
+func New(serviceURL string, signer goaclient.Signer) (*client.Client, error) {
+	u, err := url.Parse(serviceURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("the service URL %v must be absolute", serviceURL)
+	}
+	c := client.New(goaclient.HTTPClientDoer(http.DefaultClient))
+	c.Scheme = u.Scheme
+	c.Host = u.Host
+	c.SetJWTSigner(signer)
+	return c, nil
+}
+
+// StaticToken returns a Signer sending the given JWT as bearer token
+func StaticToken(token string) goaclient.Signer {
+	return &goaclient.JWTSigner{
+		TokenSource: &goaclient.StaticTokenSource{
+			StaticToken: &goaclient.StaticToken{Value: token, Type: "Bearer"},
+		},
+	}
+}
+
+// Setup requests the tenant of the user to be provisioned in the background. A tenant that is already
+// provisioned is not an error.
+func Setup(ctx context.Context, c *client.Client) error {
+	_, err := setup(ctx, c)
+	return err
+}
+
+// setup requests the tenant to be provisioned and returns the time the service accepted the request
+// according to its clock, zero if the response has no valid Date header
+func setup(ctx context.Context, c *client.Client) (time.Time, error) {
+	resp, err := c.SetupTenant(ctx, client.SetupTenantPath())
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusAccepted || resp.StatusCode == http.StatusConflict {
+		accepted, _ := http.ParseTime(resp.Header.Get("Date"))
+		return accepted, nil
+	}
+	return time.Time{}, decodeError(c, resp)
+}
+
+// Update requests the tenant of the user to be re-applied in the background with the current templates
+func Update(ctx context.Context, c *client.Client) error {
+	resp, err := c.UpdateTenant(ctx, client.UpdateTenantPath())
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusAccepted {
+		return nil
+	}
+	return decodeError(c, resp)
+}
+
+// Show returns the tenant of the user, or ErrNotFound if the user has no tenant yet
+func Show(ctx context.Context, c *client.Client) (*client.Tenant, error) {
+	resp, err := c.ShowTenant(ctx, client.ShowTenantPath(), nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+		single, err := c.DecodeTenantSingle(resp)
+		if err != nil {
+			return nil, err
+		}
+		return single.Data, nil
+	case http.StatusNotFound:
+		return nil, ErrNotFound
+	}
+	return nil, decodeError(c, resp)
+}
+
+// Status returns the status of the service, deep checks its dependencies as well. The status of an
+// unavailable service is returned along with an error.
+func Status(ctx context.Context, c *client.Client, deep bool) (*client.Status, error) {
+	resp, err := c.ShowStatus(ctx, client.ShowStatusPath(), &deep)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusServiceUnavailable {
+		return nil, fmt.Errorf("unexpected response: %v", resp.Status)
+	}
+	status, err := c.DecodeStatus(resp)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusServiceUnavailable {
+		return status, fmt.Errorf("service unavailable")
+	}
+	return status, nil
+}
+
+// decodeError returns the JSON API errors of the response as a single error
+func decodeError(c *client.Client, resp *http.Response) error {
+	jsonErrors, err := c.DecodeJSONAPIErrors(resp)
+	if err != nil || len(jsonErrors.Errors) == 0 {
+		return fmt.Errorf("unexpected response: %v", resp.Status)
+	}
+	var details []string
+	for _, e := range jsonErrors.Errors {
+		details = append(details, e.Detail)
+	}
+	return fmt.Errorf("%v: %v", resp.Status, strings.Join(details, ", "))
+}
@@ -0,0 +1,156 @@
+package tenantclient_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/almighty/almighty-core/resource"
+	"github.com/fabric8io/fabric8-init-tenant/client"
+	"github.com/fabric8io/fabric8-init-tenant/tenantclient"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// tenantJSON renders a tenant with namespaces of the given types
+func tenantJSON(types ...string) string {
+	namespaces := ""
+	for i, t := range types {
+		if i > 0 {
+			namespaces += ","
+		}
+		namespaces += fmt.Sprintf(`{"name":"aslak-%v","type":"%v","state":"created"}`, t, t)
+	}
+	return fmt.Sprintf(`{"data":{"type":"tenants","attributes":{"email":"aslak@redhat.com","namespaces":[%v]}}}`, namespaces)
+}
+
+func TestSetupAndWait(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+
+	var authorization string
+	polls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/api/tenant":
+			authorization = r.Header.Get("Authorization")
+			w.WriteHeader(http.StatusConflict)
+		case r.Method == "GET" && r.URL.Path == "/api/tenant":
+			polls++
+			w.Header().Set("Content-Type", "application/vnd.api+json")
+			switch polls {
+			case 1:
+				w.WriteHeader(http.StatusNotFound)
+				fmt.Fprint(w, `{"errors":[{"detail":"not found"}]}`)
+			case 2:
+				fmt.Fprint(w, tenantJSON("jenkins"))
+			default:
+				fmt.Fprint(w, tenantJSON(tenantclient.DefaultNamespaceTypes...))
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	c, err := tenantclient.New(server.URL, tenantclient.StaticToken("t0k3n"))
+	require.NoError(t, err)
+	ready, err := tenantclient.SetupAndWait(context.Background(), c, time.Millisecond)
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer t0k3n", authorization)
+	assert.Equal(t, 3, polls)
+	assert.Len(t, ready.Attributes.Namespaces, len(tenantclient.DefaultNamespaceTypes))
+}
+
+func TestSetupAndWaitFailed(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+
+	// the clock of the service is behind the local clock
+	serverNow := time.Now().Add(-2 * time.Hour).UTC()
+	event := func(id int, eventType string, at time.Time, message string) string {
+		return fmt.Sprintf("id: %d\nevent: %v\ndata: {\"time\":%q,\"error\":%q}\n\n", id, eventType, at.Format(time.RFC3339Nano), message)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/api/tenant":
+			w.Header().Set("Date", serverNow.Format(http.TimeFormat))
+			w.WriteHeader(http.StatusAccepted)
+		case r.Method == "GET" && r.URL.Path == "/api/tenant/progress":
+			w.Header().Set("Content-Type", "text/event-stream")
+			// the failure of an earlier attempt is kept by the service and replayed first
+			fmt.Fprint(w, event(1, "provisioning-failed", serverNow.Add(-time.Hour), "earlier"))
+			fmt.Fprint(w, event(2, "namespace-ready", serverNow.Add(time.Second), ""))
+			fmt.Fprint(w, event(3, "provisioning-failed", serverNow.Add(2*time.Second), "quota exceeded"))
+		case r.Method == "GET" && r.URL.Path == "/api/tenant":
+			w.Header().Set("Content-Type", "application/vnd.api+json")
+			fmt.Fprint(w, tenantJSON("user"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	c, err := tenantclient.New(server.URL, tenantclient.StaticToken("t0k3n"))
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_, err = tenantclient.SetupAndWait(ctx, c, time.Millisecond)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "quota exceeded")
+	assert.NotContains(t, err.Error(), "earlier")
+}
+
+func TestWaitUntilGivesUp(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/vnd.api+json")
+		fmt.Fprint(w, tenantJSON("jenkins"))
+	}))
+	defer server.Close()
+
+	c, err := tenantclient.New(server.URL, tenantclient.StaticToken("t0k3n"))
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = tenantclient.WaitUntil(ctx, c, time.Millisecond, tenantclient.NamespacesCreated("jenkins", "che"))
+	assert.Equal(t, context.DeadlineExceeded, err)
+}
+
+func TestUpdateError(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/vnd.api+json")
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprint(w, `{"errors":[{"status":"401","detail":"unknown/unauthorized openshift user"}]}`)
+	}))
+	defer server.Close()
+
+	c, err := tenantclient.New(server.URL, tenantclient.StaticToken("t0k3n"))
+	require.NoError(t, err)
+	err = tenantclient.Update(context.Background(), c)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown/unauthorized openshift user")
+}
+
+func TestNamespacesCreated(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+
+	jenkins := "jenkins"
+	tenant := &client.Tenant{Attributes: &client.TenantAttributes{
+		Namespaces: []*client.NamespaceAttributes{{Type: &jenkins}},
+	}}
+	assert.True(t, tenantclient.NamespacesCreated("jenkins")(tenant))
+	assert.False(t, tenantclient.NamespacesCreated("jenkins", "che")(tenant))
+	assert.False(t, tenantclient.NamespacesCreated("jenkins")(nil))
+}
+
+func TestNewRequiresAbsoluteURL(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+
+	_, err := tenantclient.New("f8tenant.openshift.io", tenantclient.StaticToken("t0k3n"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,140 @@
+package tenantclient
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/fabric8io/fabric8-init-tenant/client"
+	"github.com/fabric8io/fabric8-init-tenant/progress"
+)
+
+// DefaultNamespaceTypes are the namespaces provisioned for every tenant
+var DefaultNamespaceTypes = []string{"che", "jenkins", "run", "stage", "test"}
+
+// DefaultInterval is how often the tenant is polled while waiting for it
+const DefaultInterval = 2 * time.Second
+
+// Condition decides if a tenant is ready
+type Condition func(t *client.Tenant) bool
+
+// NamespacesCreated is met once the tenant has a namespace of each of the given types
+func NamespacesCreated(types ...string) Condition {
+	return func(t *client.Tenant) bool {
+		return len(namespacesOf(t, types)) == len(types)
+	}
+}
+
+// namespacesOf returns the first namespace of each of the given types the tenant has
+func namespacesOf(t *client.Tenant, types []string) []*client.NamespaceAttributes {
+	if t == nil || t.Attributes == nil {
+		return nil
+	}
+	var found []*client.NamespaceAttributes
+	for _, nsType := range types {
+		for _, ns := range t.Attributes.Namespaces {
+			if ns.Type == nil || *ns.Type != nsType {
+				continue
+			}
+			found = append(found, ns)
+			break
+		}
+	}
+	return found
+}
+
+// WaitUntil polls the tenant of the user every interval until the condition is met. The tenant not being
+// found yet is not an error, a tenant is only stored once its setup was accepted. It gives up when the
+// context is done.
+func WaitUntil(ctx context.Context, c *client.Client, interval time.Duration, ready Condition) (*client.Tenant, error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		t, err := Show(ctx, c)
+		if err != nil && err != ErrNotFound {
+			return nil, err
+		}
+		if err == nil && ready(t) {
+			return t, nil
+		}
+		select {
+		case <-ctx.Done():
+			return t, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// SetupAndWait requests the tenant of the user to be provisioned and waits until all its namespaces exist.
+// Meanwhile it follows the provisioning progress of the tenant and gives up as soon as the provisioning
+// is reported to have failed, as the namespaces of a failed tenant are never created.
+func SetupAndWait(ctx context.Context, c *client.Client, interval time.Duration) (*client.Tenant, error) {
+	accepted, err := setup(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	failed := make(chan error, 1)
+	// without the time of the service the failures of earlier attempts can not be told apart
+	if !accepted.IsZero() {
+		go func() {
+			if err := watchProvisioning(ctx, c, accepted); err != nil {
+				failed <- err
+				cancel()
+			}
+		}()
+	}
+	t, err := WaitUntil(ctx, c, interval, NamespacesCreated(DefaultNamespaceTypes...))
+	if err != nil {
+		select {
+		case failure := <-failed:
+			return t, failure
+		default:
+		}
+	}
+	return t, err
+}
+
+// watchProvisioning follows the progress stream of the tenant and returns an error once it reports the
+// failure of a provisioning that ended after the service accepted the setup. Events kept from earlier
+// attempts are skipped, both times are taken by the service so they compare regardless of the local
+// clock. It returns nil once the provisioning completed, or if the stream is unavailable or ends,
+// leaving it to polling to tell when the tenant is ready.
+func watchProvisioning(ctx context.Context, c *client.Client, accepted time.Time) error {
+	resp, err := c.ProgressTenant(ctx, client.ProgressTenantPath(), nil)
+	if err != nil {
+		return nil
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil
+	}
+	var eventType string
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "event: ") {
+			eventType = strings.TrimPrefix(line, "event: ")
+			continue
+		}
+		if !strings.HasPrefix(line, "data: ") {
+			continue
+		}
+		var event progress.Event
+		if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &event); err != nil || event.Time.Before(accepted) {
+			continue
+		}
+		switch eventType {
+		case progress.EventProvisioningCompleted:
+			return nil
+		case progress.EventProvisioningFailed:
+			return fmt.Errorf("provisioning of the tenant failed: %v", event.Error)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+// Command tenant-cli provisions and inspects the tenant of a user through the tenant service API.
+//
+//	tenant-cli -url https://f8tenant.openshift.io -token $TOKEN setup -wait
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/fabric8io/fabric8-init-tenant/tenantclient"
+)
+
+const usage = `Usage: tenant-cli [flags] <command> [command flags]
+
+Commands:
+  setup   Provision the tenant of the user, -wait until its namespaces exist
+  update  Re-apply the templates to the tenant of the user
+  show    Print the tenant of the user, -wait until its namespaces exist
+  status  Print the status of the service, -deep checks its dependencies
+
+Flags:
+`
+
+func main() {
+	serviceURL := flag.String("url", envOr("F8_TENANT_URL", "http://localhost:8080"), "URL of the tenant service, defaults to $F8_TENANT_URL")
+	token := flag.String("token", os.Getenv("F8_TOKEN"), "JWT of the user, defaults to $F8_TOKEN")
+	timeout := flag.Duration("timeout", 10*time.Minute, "How long to wait for the command to complete")
+	flag.Usage = func() {
+		fmt.Fprint(os.Stderr, usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	c, err := tenantclient.New(*serviceURL, tenantclient.StaticToken(*token))
+	if err != nil {
+		fail(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	cmd := flag.NewFlagSet(flag.Arg(0), flag.ExitOnError)
+	wait := cmd.Bool("wait", false, "Wait until the tenant namespaces exist")
+	interval := cmd.Duration("interval", tenantclient.DefaultInterval, "How often to poll the tenant while waiting")
+	deep := cmd.Bool("deep", false, "Check the dependencies of the service as well")
+	cmd.Parse(flag.Args()[1:])
+
+	switch cmd.Name() {
+	case "setup":
+		if !*wait {
+			check(tenantclient.Setup(ctx, c))
+			return
+		}
+		t, err := tenantclient.SetupAndWait(ctx, c, *interval)
+		check(err)
+		printJSON(t)
+	case "update":
+		check(tenantclient.Update(ctx, c))
+	case "show":
+		if !*wait {
+			t, err := tenantclient.Show(ctx, c)
+			check(err)
+			printJSON(t)
+			return
+		}
+		t, err := tenantclient.WaitUntil(ctx, c, *interval, tenantclient.NamespacesCreated(tenantclient.DefaultNamespaceTypes...))
+		check(err)
+		printJSON(t)
+	case "status":
+		status, err := tenantclient.Status(ctx, c, *deep)
+		if status != nil {
+			printJSON(status)
+		}
+		check(err)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n", cmd.Name())
+		flag.Usage()
+		os.Exit(2)
+	}
+}
+
+func envOr(name, fallback string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return fallback
+}
+
+func printJSON(v interface{}) {
+	out := json.NewEncoder(os.Stdout)
+	out.SetIndent("", "  ")
+	check(out.Encode(v))
+}
+
+func check(err error) {
+	if err != nil {
+		fail(err)
+	}
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
@@ -0,0 +1,83 @@
+// Package transport creates the HTTP transports used to connect to OpenShift and Keycloak.
+package transport
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+)
+
+// Config holds the TLS and proxy settings for connecting to a server
+type Config struct {
+	// CABundle holds the PEM encoded certificates of the CAs trusted in addition to the system CAs
+	CABundle string
+	// ClientCert and ClientKey hold the PEM encoded client certificate and key used for mutual TLS
+	ClientCert string
+	ClientKey  string
+	// ServerName overrides the server name used for SNI and certificate verification
+	ServerName string
+	// ProxyURL is the proxy used for all requests, the environment proxy settings are used if empty
+	ProxyURL           string
+	InsecureSkipVerify bool
+}
+
+// IsDefault returns if the config has no settings, i.e. the default transport can be used
+func (c Config) IsDefault() bool {
+	return c == Config{}
+}
+
+// New creates a transport for the config, or nil if the config has no settings
+func New(c Config) (*http.Transport, error) {
+	if c.IsDefault() {
+		return nil, nil
+	}
+	tlsConfig := &tls.Config{
+		ServerName:         c.ServerName,
+		InsecureSkipVerify: c.InsecureSkipVerify,
+	}
+	if c.CABundle != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM([]byte(c.CABundle)) {
+			return nil, fmt.Errorf("invalid CA bundle, no PEM encoded certificates found")
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if c.ClientCert != "" || c.ClientKey != "" {
+		cert, err := tls.X509KeyPair([]byte(c.ClientCert), []byte(c.ClientKey))
+		if err != nil {
+			return nil, fmt.Errorf("invalid client certificate: %v", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	proxy := http.ProxyFromEnvironment
+	if c.ProxyURL != "" {
+		proxyURL, err := url.Parse(c.ProxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy URL %v: %v", c.ProxyURL, err)
+		}
+		proxy = http.ProxyURL(proxyURL)
+	}
+	return &http.Transport{
+		Proxy:           proxy,
+		TLSClientConfig: tlsConfig,
+	}, nil
+}
+
+// ReadFile returns the content of the PEM file, or an empty string if no file is given
+func ReadFile(file string) (string, error) {
+	if file == "" {
+		return "", nil
+	}
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
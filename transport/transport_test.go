@@ -0,0 +1,63 @@
+package transport_test
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/almighty/almighty-core/resource"
+	"github.com/fabric8io/fabric8-init-tenant/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefault(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+
+	tr, err := transport.New(transport.Config{})
+	require.NoError(t, err)
+	assert.Nil(t, tr)
+}
+
+func TestCABundle(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	_, err := http.Get(server.URL)
+	require.Error(t, err, "the test server certificate is not trusted by default")
+
+	caBundle := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	tr, err := transport.New(transport.Config{CABundle: string(caBundle), ServerName: "example.com"})
+	require.NoError(t, err)
+	resp, err := (&http.Client{Transport: tr}).Get(server.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestInvalid(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+
+	_, err := transport.New(transport.Config{CABundle: "not a certificate"})
+	assert.Error(t, err)
+
+	_, err = transport.New(transport.Config{ClientCert: "not a certificate"})
+	assert.Error(t, err)
+
+	_, err = transport.New(transport.Config{ProxyURL: "://proxy"})
+	assert.Error(t, err)
+}
+
+func TestProxy(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+
+	tr, err := transport.New(transport.Config{ProxyURL: "http://proxy:3128"})
+	require.NoError(t, err)
+	req, _ := http.NewRequest("GET", "https://api.example.com", nil)
+	proxy, err := tr.Proxy(req)
+	require.NoError(t, err)
+	assert.Equal(t, "proxy:3128", proxy.Host)
+}
@@ -0,0 +1,480 @@
+package upgrade
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/almighty/almighty-core/errors"
+	"github.com/almighty/almighty-core/log"
+	"github.com/fabric8io/fabric8-init-tenant/tenant"
+	uuid "github.com/satori/go.uuid"
+)
+
+// Defaults used when the corresponding Options value is not set
+const (
+	DefaultBatchSize        = 10
+	DefaultMaxConcurrency   = 2
+	DefaultCanaryPercentage = 5
+	DefaultFailureThreshold = 20
+)
+
+// State describes where a Rollout is in its lifecycle
+type State string
+
+// Represents the rollout states
+const (
+	StateRunning   State = "running"
+	StatePaused    State = "paused"
+	StateAborted   State = "aborted"
+	StateCompleted State = "completed"
+)
+
+// Upgrader upgrades a single tenant to the target version
+type Upgrader func(ctx context.Context, t *tenant.Tenant, targetVersion string) error
+
+// Options describes which tenants to upgrade and how fast
+type Options struct {
+	// TargetVersion is the team version all selected tenants are upgraded to
+	TargetVersion string
+	// FromVersion limits the rollout to tenants with namespaces on this version
+	FromVersion string
+	// MasterURL limits the rollout to tenants with namespaces on this cluster
+	MasterURL string
+	// BatchSize is the number of tenants upgraded before the failure rate is evaluated
+	BatchSize int
+	// MaxConcurrency is the number of tenants upgraded in parallel within a batch
+	MaxConcurrency int
+	// CanaryPercentage is the share of tenants upgraded in the first batch
+	CanaryPercentage int
+	// FailureThreshold is the failure rate in percent that pauses the rollout
+	FailureThreshold int
+}
+
+func (o Options) withDefaults() Options {
+	if o.BatchSize <= 0 {
+		o.BatchSize = DefaultBatchSize
+	}
+	if o.MaxConcurrency <= 0 {
+		o.MaxConcurrency = DefaultMaxConcurrency
+	}
+	if o.CanaryPercentage <= 0 || o.CanaryPercentage > 100 {
+		o.CanaryPercentage = DefaultCanaryPercentage
+	}
+	if o.FailureThreshold <= 0 || o.FailureThreshold > 100 {
+		o.FailureThreshold = DefaultFailureThreshold
+	}
+	return o
+}
+
+// Progress is a point in time snapshot of a Rollout
+type Progress struct {
+	ID          uuid.UUID
+	Options     Options
+	State       State
+	Reason      string
+	Total       int
+	Succeeded   int
+	Failed      int
+	FailedIDs   []uuid.UUID
+	StartedAt   time.Time
+	CompletedAt *time.Time
+}
+
+// Rollout upgrades a set of tenants in batches. Its progress is stored as a job after every batch and
+// whenever its state changes, a rollout interrupted by a restart continues with the batch in progress.
+type Rollout struct {
+	id       uuid.UUID
+	opts     Options
+	tenants  []uuid.UUID
+	service  tenant.Service
+	upgrader Upgrader
+	// saveMu orders the writes of the job
+	saveMu      sync.Mutex
+	mu          sync.Mutex
+	cond        *sync.Cond
+	state       State
+	reason      string
+	next        int
+	succeeded   int
+	failed      []uuid.UUID
+	startedAt   time.Time
+	completedAt *time.Time
+	// checkpoint is the progress as of the last completed batch
+	checkpoint checkpoint
+}
+
+type checkpoint struct {
+	next      int
+	succeeded int
+	failed    []uuid.UUID
+}
+
+// rolloutDetails is the state of a rollout stored in the details of its job
+type rolloutDetails struct {
+	Options   Options
+	Reason    string `json:",omitempty"`
+	Tenants   []uuid.UUID
+	Next      int
+	Succeeded int
+	Failed    []uuid.UUID `json:",omitempty"`
+}
+
+// ID returns the identifier of the rollout
+func (r *Rollout) ID() uuid.UUID {
+	return r.id
+}
+
+// Progress returns the current progress of the rollout
+func (r *Rollout) Progress() Progress {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	failed := make([]uuid.UUID, len(r.failed))
+	copy(failed, r.failed)
+	return Progress{
+		ID:          r.id,
+		Options:     r.opts,
+		State:       r.state,
+		Reason:      r.reason,
+		Total:       len(r.tenants),
+		Succeeded:   r.succeeded,
+		Failed:      len(r.failed),
+		FailedIDs:   failed,
+		StartedAt:   r.startedAt,
+		CompletedAt: r.completedAt,
+	}
+}
+
+// Pause stops the rollout after the batch in progress
+func (r *Rollout) Pause() error {
+	r.mu.Lock()
+	state := r.state
+	if state == StateRunning {
+		r.state = StatePaused
+		r.reason = "paused by request"
+	}
+	r.mu.Unlock()
+	if state != StateRunning {
+		return errors.NewBadParameterError("state", fmt.Sprintf("rollout is %v, can not pause", state))
+	}
+	return r.save()
+}
+
+// Resume continues a paused rollout
+func (r *Rollout) Resume() error {
+	r.mu.Lock()
+	state := r.state
+	if state == StatePaused {
+		r.state = StateRunning
+		r.reason = ""
+		r.cond.Broadcast()
+	}
+	r.mu.Unlock()
+	if state != StatePaused {
+		return errors.NewBadParameterError("state", fmt.Sprintf("rollout is %v, can not resume", state))
+	}
+	return r.save()
+}
+
+// Abort stops the rollout for good after the batch in progress
+func (r *Rollout) Abort() error {
+	r.mu.Lock()
+	state := r.state
+	if state == StateRunning || state == StatePaused {
+		r.finish(StateAborted, "aborted by request")
+		r.cond.Broadcast()
+	}
+	r.mu.Unlock()
+	if state != StateRunning && state != StatePaused {
+		return errors.NewBadParameterError("state", fmt.Sprintf("rollout is %v, can not abort", state))
+	}
+	return r.save()
+}
+
+// finish must be called with the lock held
+func (r *Rollout) finish(state State, reason string) {
+	now := time.Now()
+	r.state = state
+	r.reason = reason
+	r.completedAt = &now
+}
+
+// save stores the state of the rollout and its progress as of the last completed batch as job
+func (r *Rollout) save() error {
+	r.saveMu.Lock()
+	defer r.saveMu.Unlock()
+	r.mu.Lock()
+	details := rolloutDetails{
+		Options:   r.opts,
+		Reason:    r.reason,
+		Tenants:   r.tenants,
+		Next:      r.checkpoint.next,
+		Succeeded: r.checkpoint.succeeded,
+		Failed:    r.checkpoint.failed,
+	}
+	job := &tenant.Job{
+		ID:          r.id,
+		CreatedAt:   r.startedAt,
+		CompletedAt: r.completedAt,
+		Type:        tenant.JobTypeUpgrade,
+		Target:      r.opts.TargetVersion,
+		Source:      r.opts.FromVersion,
+		State:       string(r.state),
+	}
+	r.mu.Unlock()
+	var err error
+	job.Details, err = json.Marshal(details)
+	if err != nil {
+		return err
+	}
+	return r.service.UpdateJob(job)
+}
+
+// nextBatch blocks while the rollout is paused and returns the next tenants to upgrade.
+// An empty batch means the rollout is done.
+func (r *Rollout) nextBatch() []uuid.UUID {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for r.state == StatePaused {
+		r.cond.Wait()
+	}
+	if r.state != StateRunning {
+		return nil
+	}
+	if r.next >= len(r.tenants) {
+		r.finish(StateCompleted, "")
+		return nil
+	}
+	size := r.opts.BatchSize
+	if r.next == 0 {
+		size = (len(r.tenants)*r.opts.CanaryPercentage + 99) / 100
+	}
+	end := r.next + size
+	if end > len(r.tenants) {
+		end = len(r.tenants)
+	}
+	batch := r.tenants[r.next:end]
+	r.next = end
+	return batch
+}
+
+func (r *Rollout) record(tenantID uuid.UUID, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err != nil {
+		r.failed = append(r.failed, tenantID)
+		return
+	}
+	r.succeeded++
+}
+
+// completeBatch checkpoints the progress and pauses the rollout if too many of the processed tenants failed
+func (r *Rollout) completeBatch() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.checkpoint = checkpoint{
+		next:      r.next,
+		succeeded: r.succeeded,
+		failed:    append([]uuid.UUID{}, r.failed...),
+	}
+	processed := r.succeeded + len(r.failed)
+	if processed == 0 || r.state != StateRunning {
+		return
+	}
+	rate := len(r.failed) * 100 / processed
+	if rate >= r.opts.FailureThreshold {
+		r.state = StatePaused
+		r.reason = fmt.Sprintf("failure rate %v%% reached threshold of %v%%", rate, r.opts.FailureThreshold)
+	}
+}
+
+func (r *Rollout) run(ctx context.Context) {
+	for {
+		batch := r.nextBatch()
+		if len(batch) == 0 {
+			break
+		}
+		r.runBatch(ctx, batch)
+		r.completeBatch()
+		r.saveOrLog(ctx)
+	}
+	r.saveOrLog(ctx)
+	p := r.Progress()
+	log.Info(ctx, map[string]interface{}{
+		"rollout_id": p.ID,
+		"state":      p.State,
+		"succeeded":  p.Succeeded,
+		"failed":     p.Failed,
+	}, "rollout finished")
+}
+
+func (r *Rollout) saveOrLog(ctx context.Context) {
+	if err := r.save(); err != nil {
+		log.Error(ctx, map[string]interface{}{
+			"err":        err,
+			"rollout_id": r.id,
+		}, "unable to record rollout progress")
+	}
+}
+
+func (r *Rollout) runBatch(ctx context.Context, batch []uuid.UUID) {
+	sem := make(chan struct{}, r.opts.MaxConcurrency)
+	var wg sync.WaitGroup
+	for _, id := range batch {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(id uuid.UUID) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			// the tenant is loaded when it is upgraded, it may have moved since the rollout started
+			t, err := r.service.GetTenant(id)
+			if err == nil {
+				err = r.upgrader(ctx, t, r.opts.TargetVersion)
+			}
+			if err != nil {
+				log.Error(ctx, map[string]interface{}{
+					"err":            err,
+					"rollout_id":     r.id,
+					"tenant_id":      id,
+					"target_version": r.opts.TargetVersion,
+				}, "unable to upgrade tenant")
+			}
+			r.record(id, err)
+		}(id)
+	}
+	wg.Wait()
+}
+
+// Orchestrator starts and keeps track of rollouts. Only one rollout can be active at a time.
+type Orchestrator struct {
+	service  tenant.Service
+	upgrader Upgrader
+	mu       sync.Mutex
+	rollouts map[uuid.UUID]*Rollout
+	active   *Rollout
+}
+
+// NewOrchestrator creates a new Orchestrator using the given Upgrader for each tenant
+func NewOrchestrator(service tenant.Service, upgrader Upgrader) *Orchestrator {
+	return &Orchestrator{
+		service:  service,
+		upgrader: upgrader,
+		rollouts: map[uuid.UUID]*Rollout{},
+	}
+}
+
+// ErrRolloutInProgress is returned by Start when another rollout is running or paused
+var ErrRolloutInProgress = fmt.Errorf("another rollout is in progress")
+
+// Start selects the tenants matching the options and starts upgrading them in the background
+func (o *Orchestrator) Start(ctx context.Context, opts Options) (*Rollout, error) {
+	if opts.TargetVersion == "" {
+		return nil, fmt.Errorf("missing target version")
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.active != nil {
+		state := o.active.Progress().State
+		if state == StateRunning || state == StatePaused {
+			return nil, ErrRolloutInProgress
+		}
+	}
+	tenants, err := o.service.FindTenants(opts.FromVersion, opts.MasterURL)
+	if err != nil {
+		return nil, err
+	}
+	r := o.newRollout(uuid.NewV4(), opts.withDefaults(), StateRunning, time.Now())
+	for _, t := range tenants {
+		r.tenants = append(r.tenants, t.ID)
+	}
+	if err := r.save(); err != nil {
+		return nil, err
+	}
+	o.rollouts[r.id] = r
+	o.active = r
+
+	go r.run(ctx)
+	return r, nil
+}
+
+// Resume restarts the rollouts interrupted while running or paused, e.g. by a restart of the service.
+// The batch in progress when the rollout was interrupted is upgraded again.
+func (o *Orchestrator) Resume(ctx context.Context) error {
+	var jobs []*tenant.Job
+	for _, state := range []State{StateRunning, StatePaused} {
+		found, err := o.service.FindJobs(tenant.JobTypeUpgrade, string(state))
+		if err != nil {
+			return err
+		}
+		jobs = append(jobs, found...)
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for _, job := range jobs {
+		r, err := o.restore(job)
+		if err != nil {
+			return err
+		}
+		o.rollouts[r.id] = r
+		o.active = r
+		log.Info(ctx, map[string]interface{}{
+			"rollout_id": r.id,
+			"state":      r.state,
+		}, "rollout resumed")
+		go r.run(ctx)
+	}
+	return nil
+}
+
+// Get returns the rollout with the given ID. Rollouts finished before the service started are read
+// from their job.
+func (o *Orchestrator) Get(id uuid.UUID) (*Rollout, bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if r, found := o.rollouts[id]; found {
+		return r, true
+	}
+	job, err := o.service.GetJob(id)
+	if err != nil || job == nil || job.Type != tenant.JobTypeUpgrade {
+		return nil, false
+	}
+	r, err := o.restore(job)
+	if err != nil {
+		return nil, false
+	}
+	return r, true
+}
+
+func (o *Orchestrator) newRollout(id uuid.UUID, opts Options, state State, startedAt time.Time) *Rollout {
+	r := &Rollout{
+		id:        id,
+		opts:      opts,
+		service:   o.service,
+		upgrader:  o.upgrader,
+		state:     state,
+		startedAt: startedAt,
+	}
+	r.cond = sync.NewCond(&r.mu)
+	return r
+}
+
+// restore recreates the rollout stored as the job, continuing after its last completed batch
+func (o *Orchestrator) restore(job *tenant.Job) (*Rollout, error) {
+	var details rolloutDetails
+	if err := json.Unmarshal(job.Details, &details); err != nil {
+		return nil, fmt.Errorf("invalid details of rollout %v: %v", job.ID, err)
+	}
+	r := o.newRollout(job.ID, details.Options, State(job.State), job.CreatedAt)
+	r.tenants = details.Tenants
+	r.reason = details.Reason
+	r.next = details.Next
+	r.succeeded = details.Succeeded
+	r.failed = details.Failed
+	r.completedAt = job.CompletedAt
+	r.checkpoint = checkpoint{next: details.Next, succeeded: details.Succeeded, failed: details.Failed}
+	return r, nil
+}
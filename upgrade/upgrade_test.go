@@ -0,0 +1,208 @@
+package upgrade_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/almighty/almighty-core/resource"
+	"github.com/fabric8io/fabric8-init-tenant/tenant"
+	"github.com/fabric8io/fabric8-init-tenant/upgrade"
+	"github.com/fabric8io/fabric8-init-tenant/webhook"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fixedTenants selects the same tenants for every rollout and stores the jobs in memory
+type fixedTenants struct {
+	tenant.NilService
+	tenants []*tenant.Tenant
+	mu      sync.Mutex
+	jobs    map[uuid.UUID]tenant.Job
+}
+
+func newFixedTenants(tenants []*tenant.Tenant) *fixedTenants {
+	return &fixedTenants{tenants: tenants, jobs: map[uuid.UUID]tenant.Job{}}
+}
+
+func (s *fixedTenants) FindTenants(version, masterURL string) ([]*tenant.Tenant, error) {
+	return s.tenants, nil
+}
+
+func (s *fixedTenants) GetTenant(tenantID uuid.UUID) (*tenant.Tenant, error) {
+	for _, t := range s.tenants {
+		if t.ID == tenantID {
+			return t, nil
+		}
+	}
+	return nil, fmt.Errorf("tenant %v not found", tenantID)
+}
+
+func (s *fixedTenants) GetJob(jobID uuid.UUID) (*tenant.Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, found := s.jobs[jobID]
+	if !found {
+		return nil, fmt.Errorf("job %v not found", jobID)
+	}
+	return &job, nil
+}
+
+func (s *fixedTenants) FindJobs(jobType tenant.JobType, state string) ([]*tenant.Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var found []*tenant.Job
+	for _, job := range s.jobs {
+		if job.Type == jobType && job.State == state {
+			job := job
+			found = append(found, &job)
+		}
+	}
+	return found, nil
+}
+
+func (s *fixedTenants) UpdateJob(job *tenant.Job, events ...webhook.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobs[job.ID] = *job
+	return nil
+}
+
+func newTenants(n int) []*tenant.Tenant {
+	var tenants []*tenant.Tenant
+	for i := 0; i < n; i++ {
+		tenants = append(tenants, &tenant.Tenant{ID: uuid.NewV4(), Email: fmt.Sprintf("user%v@example.com", i)})
+	}
+	return tenants
+}
+
+func waitFor(t *testing.T, r *upgrade.Rollout, state upgrade.State) upgrade.Progress {
+	for i := 0; i < 200; i++ {
+		p := r.Progress()
+		if p.State == state {
+			return p
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	require.Fail(t, "rollout did not reach state", "%v", state)
+	return upgrade.Progress{}
+}
+
+// waitForJob waits until the rollout is stored in the given state
+func waitForJob(t *testing.T, s *fixedTenants, id uuid.UUID, state upgrade.State) {
+	for i := 0; i < 200; i++ {
+		if job, err := s.GetJob(id); err == nil && job.State == string(state) {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	require.Fail(t, "rollout was not stored in state", "%v", state)
+}
+
+func TestRolloutCompletes(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+
+	var mu sync.Mutex
+	upgraded := map[uuid.UUID]string{}
+	o := upgrade.NewOrchestrator(newFixedTenants(newTenants(25)), func(ctx context.Context, t *tenant.Tenant, version string) error {
+		mu.Lock()
+		defer mu.Unlock()
+		upgraded[t.ID] = version
+		return nil
+	})
+
+	r, err := o.Start(context.Background(), upgrade.Options{TargetVersion: "1.0.92", BatchSize: 7})
+	require.NoError(t, err)
+
+	p := waitFor(t, r, upgrade.StateCompleted)
+	assert.Equal(t, 25, p.Total)
+	assert.Equal(t, 25, p.Succeeded)
+	assert.Equal(t, 0, p.Failed)
+	assert.Len(t, upgraded, 25)
+}
+
+func TestRolloutPausesOnFailedCanary(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+
+	o := upgrade.NewOrchestrator(newFixedTenants(newTenants(20)), func(ctx context.Context, t *tenant.Tenant, version string) error {
+		return fmt.Errorf("failed")
+	})
+
+	r, err := o.Start(context.Background(), upgrade.Options{TargetVersion: "1.0.92", CanaryPercentage: 10})
+	require.NoError(t, err)
+
+	p := waitFor(t, r, upgrade.StatePaused)
+	assert.Equal(t, 2, p.Failed)
+	assert.Equal(t, 0, p.Succeeded)
+	assert.NotEmpty(t, p.Reason)
+
+	_, err = o.Start(context.Background(), upgrade.Options{TargetVersion: "1.0.93"})
+	assert.Equal(t, upgrade.ErrRolloutInProgress, err)
+
+	require.NoError(t, r.Abort())
+	waitFor(t, r, upgrade.StateAborted)
+	assert.Error(t, r.Resume())
+}
+
+func TestRolloutResumedAfterRestart(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+
+	service := newFixedTenants(newTenants(20))
+	failing := upgrade.NewOrchestrator(service, func(ctx context.Context, t *tenant.Tenant, version string) error {
+		return fmt.Errorf("failed")
+	})
+	r, err := failing.Start(context.Background(), upgrade.Options{TargetVersion: "1.0.92", CanaryPercentage: 10})
+	require.NoError(t, err)
+	paused := waitFor(t, r, upgrade.StatePaused)
+	waitForJob(t, service, r.ID(), upgrade.StatePaused)
+
+	// a new instance of the service picks up the paused rollout
+	var mu sync.Mutex
+	upgraded := map[uuid.UUID]bool{}
+	restarted := upgrade.NewOrchestrator(service, func(ctx context.Context, t *tenant.Tenant, version string) error {
+		mu.Lock()
+		defer mu.Unlock()
+		upgraded[t.ID] = true
+		return nil
+	})
+	require.NoError(t, restarted.Resume(context.Background()))
+	resumed, found := restarted.Get(r.ID())
+	require.True(t, found)
+	p := resumed.Progress()
+	assert.Equal(t, upgrade.StatePaused, p.State)
+	assert.Equal(t, paused.Reason, p.Reason)
+	assert.Equal(t, paused.FailedIDs, p.FailedIDs)
+	assert.Equal(t, 20, p.Total)
+
+	_, err = restarted.Start(context.Background(), upgrade.Options{TargetVersion: "1.0.93"})
+	assert.Equal(t, upgrade.ErrRolloutInProgress, err)
+
+	require.NoError(t, resumed.Resume())
+	p = waitFor(t, resumed, upgrade.StateCompleted)
+	assert.Equal(t, 18, p.Succeeded)
+	assert.Equal(t, 2, p.Failed)
+	assert.Len(t, upgraded, 18)
+	waitForJob(t, service, r.ID(), upgrade.StateCompleted)
+
+	// finished rollouts are still reported by instances that did not run them
+	finished, found := upgrade.NewOrchestrator(service, nil).Get(r.ID())
+	require.True(t, found)
+	assert.Equal(t, upgrade.StateCompleted, finished.Progress().State)
+	assert.Equal(t, 18, finished.Progress().Succeeded)
+	assert.NotNil(t, finished.Progress().CompletedAt)
+}
+
+func TestRolloutMissingTargetVersion(t *testing.T) {
+	t.Parallel()
+	resource.Require(t, resource.UnitTest)
+
+	o := upgrade.NewOrchestrator(newFixedTenants(nil), nil)
+	_, err := o.Start(context.Background(), upgrade.Options{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,189 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/almighty/almighty-core/log"
+)
+
+// Defaults used when the corresponding Options value is not set
+const (
+	DefaultMaxAttempts = 10
+	DefaultBackoff     = 30 * time.Second
+	DefaultMaxBackoff  = time.Hour
+	DefaultTimeout     = 10 * time.Second
+	DefaultBatchSize   = 50
+)
+
+// Options configure how deliveries are retried
+type Options struct {
+	// MaxAttempts is the number of attempts after which a delivery is given up
+	MaxAttempts int
+	// Backoff is the delay before the first retry, doubled with every further attempt up to MaxBackoff
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	// Timeout limits each attempt
+	Timeout time.Duration
+	// BatchSize is the number of deliveries claimed at once, they are leased for BatchSize times Timeout
+	BatchSize int
+}
+
+func (o Options) withDefaults() Options {
+	if o.MaxAttempts <= 0 {
+		o.MaxAttempts = DefaultMaxAttempts
+	}
+	if o.Backoff <= 0 {
+		o.Backoff = DefaultBackoff
+	}
+	if o.MaxBackoff <= 0 {
+		o.MaxBackoff = DefaultMaxBackoff
+	}
+	if o.Timeout <= 0 {
+		o.Timeout = DefaultTimeout
+	}
+	if o.BatchSize <= 0 {
+		o.BatchSize = DefaultBatchSize
+	}
+	return o
+}
+
+// backoff returns the delay before the next attempt of a delivery that failed the given number of times
+func (o Options) backoff(attempts int) time.Duration {
+	delay := o.Backoff
+	for i := 1; i < attempts && delay < o.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > o.MaxBackoff {
+		delay = o.MaxBackoff
+	}
+	return delay
+}
+
+// Dispatcher sends the pending deliveries of the outbox
+type Dispatcher struct {
+	service Service
+	client  *http.Client
+	opts    Options
+}
+
+// NewDispatcher creates a new Dispatcher
+func NewDispatcher(service Service, opts Options) *Dispatcher {
+	opts = opts.withDefaults()
+	return &Dispatcher{
+		service: service,
+		client:  &http.Client{Timeout: opts.Timeout},
+		opts:    opts,
+	}
+}
+
+// Start runs Dispatch every interval until the context is done
+func (d *Dispatcher) Start(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := d.Dispatch(ctx); err != nil {
+					log.Error(ctx, map[string]interface{}{
+						"err": err,
+					}, "unable to dispatch webhook deliveries")
+				}
+			}
+		}
+	}()
+}
+
+// Dispatch sends the deliveries that are due and records their outcome. The deliveries of a batch are
+// sent one after another, so they are leased for as long as sending all of them may take. A delivery
+// whose lease ran out anyway is left for the next claim rather than risk sending it twice.
+func (d *Dispatcher) Dispatch(ctx context.Context) error {
+	claimed := time.Now()
+	lease := d.opts.Timeout * time.Duration(d.opts.BatchSize)
+	deliveries, err := d.service.ClaimDeliveries(claimed, lease, d.opts.BatchSize)
+	if err != nil {
+		return err
+	}
+	var failed error
+	for _, delivery := range deliveries {
+		if time.Now().Add(d.opts.Timeout).After(claimed.Add(lease)) {
+			break
+		}
+		status, err := d.send(delivery)
+		now := time.Now()
+		delivery.Attempts++
+		delivery.LastAttemptAt = &now
+		delivery.ResponseStatus = status
+		delivery.Error = ""
+		switch {
+		case err == nil:
+			delivery.State = DeliveryDelivered
+			delivery.NextAttemptAt = nil
+		case delivery.Attempts >= d.opts.MaxAttempts:
+			delivery.State = DeliveryFailed
+			delivery.NextAttemptAt = nil
+			delivery.Error = err.Error()
+		default:
+			next := now.Add(d.opts.backoff(delivery.Attempts))
+			delivery.NextAttemptAt = &next
+			delivery.Error = err.Error()
+		}
+		if err != nil {
+			log.Error(ctx, map[string]interface{}{
+				"err":         err,
+				"delivery_id": delivery.ID,
+				"attempts":    delivery.Attempts,
+				"state":       delivery.State,
+			}, "unable to deliver webhook")
+		}
+		// the other deliveries of the batch are still sent, this one is retried once its lease ran out
+		if err := d.service.UpdateDelivery(delivery); err != nil {
+			log.Error(ctx, map[string]interface{}{
+				"err":         err,
+				"delivery_id": delivery.ID,
+			}, "unable to record webhook delivery")
+			if failed == nil {
+				failed = err
+			}
+		}
+	}
+	return failed
+}
+
+// send posts the payload signed with the secret of the subscription, any response but 2xx is an error
+func (d *Dispatcher) send(delivery *Delivery) (int, error) {
+	sub, err := d.service.GetSubscription(delivery.SubscriptionID)
+	if err != nil {
+		return 0, err
+	}
+	if sub == nil {
+		return 0, fmt.Errorf("subscription %v no longer exists", delivery.SubscriptionID)
+	}
+	payload := []byte(delivery.Payload)
+	req, err := http.NewRequest("POST", sub.URL, bytes.NewReader(payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, delivery.EventType)
+	req.Header.Set(HeaderDelivery, delivery.ID.String())
+	req.Header.Set(HeaderSignature, Sign(sub.Secret, payload))
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("subscriber responded with %v", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
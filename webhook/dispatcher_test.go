@@ -0,0 +1,148 @@
+package webhook_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/almighty/almighty-core/resource"
+	"github.com/fabric8io/fabric8-init-tenant/webhook"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type outbox struct {
+	webhook.NilService
+	subscription *webhook.Subscription
+	deliveries   []*webhook.Delivery
+	lease        time.Duration
+	updated      []webhook.Delivery
+	updateErr    error
+}
+
+func (s *outbox) GetSubscription(id uuid.UUID) (*webhook.Subscription, error) {
+	if s.subscription != nil && s.subscription.ID == id {
+		return s.subscription, nil
+	}
+	return nil, nil
+}
+
+func (s *outbox) ClaimDeliveries(now time.Time, lease time.Duration, limit int) ([]*webhook.Delivery, error) {
+	s.lease = lease
+	return s.deliveries, nil
+}
+
+func (s *outbox) UpdateDelivery(delivery *webhook.Delivery) error {
+	s.updated = append(s.updated, *delivery)
+	return s.updateErr
+}
+
+func TestDispatch(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+
+	var received *http.Request
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		body, _ = ioutil.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	sub := &webhook.Subscription{ID: uuid.NewV4(), URL: server.URL, Secret: "s3cr3t"}
+	delivery := &webhook.Delivery{
+		ID:             uuid.NewV4(),
+		SubscriptionID: sub.ID,
+		EventType:      webhook.EventTenantCreated,
+		Payload:        `{"type":"tenant.created"}`,
+		State:          webhook.DeliveryPending,
+	}
+	service := &outbox{subscription: sub, deliveries: []*webhook.Delivery{delivery}}
+
+	require.NoError(t, webhook.NewDispatcher(service, webhook.Options{}).Dispatch(context.Background()))
+	require.NotNil(t, received)
+	assert.Equal(t, `{"type":"tenant.created"}`, string(body))
+	assert.Equal(t, webhook.EventTenantCreated, received.Header.Get(webhook.HeaderEvent))
+	assert.Equal(t, delivery.ID.String(), received.Header.Get(webhook.HeaderDelivery))
+	assert.Equal(t, webhook.Sign("s3cr3t", body), received.Header.Get(webhook.HeaderSignature))
+	require.Len(t, service.updated, 1)
+	assert.Equal(t, webhook.DeliveryDelivered, service.updated[0].State)
+	assert.Equal(t, http.StatusNoContent, service.updated[0].ResponseStatus)
+	assert.Equal(t, 1, service.updated[0].Attempts)
+}
+
+func TestDispatchRetries(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	sub := &webhook.Subscription{ID: uuid.NewV4(), URL: server.URL, Secret: "s3cr3t"}
+	delivery := &webhook.Delivery{ID: uuid.NewV4(), SubscriptionID: sub.ID, State: webhook.DeliveryPending}
+	service := &outbox{subscription: sub, deliveries: []*webhook.Delivery{delivery}}
+	dispatcher := webhook.NewDispatcher(service, webhook.Options{MaxAttempts: 2, Backoff: time.Minute})
+
+	before := time.Now()
+	require.NoError(t, dispatcher.Dispatch(context.Background()))
+	retried := service.updated[0]
+	assert.Equal(t, webhook.DeliveryPending, retried.State)
+	assert.Equal(t, http.StatusServiceUnavailable, retried.ResponseStatus)
+	require.NotNil(t, retried.NextAttemptAt)
+	assert.True(t, retried.NextAttemptAt.After(before.Add(59*time.Second)))
+
+	require.NoError(t, dispatcher.Dispatch(context.Background()))
+	failed := service.updated[1]
+	assert.Equal(t, webhook.DeliveryFailed, failed.State)
+	assert.Equal(t, 2, failed.Attempts)
+	assert.Nil(t, failed.NextAttemptAt)
+	assert.NotEmpty(t, failed.Error)
+}
+
+func TestDispatchWithoutSubscription(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+
+	delivery := &webhook.Delivery{ID: uuid.NewV4(), SubscriptionID: uuid.NewV4(), State: webhook.DeliveryPending}
+	service := &outbox{deliveries: []*webhook.Delivery{delivery}}
+
+	require.NoError(t, webhook.NewDispatcher(service, webhook.Options{MaxAttempts: 1}).Dispatch(context.Background()))
+	assert.Equal(t, webhook.DeliveryFailed, service.updated[0].State)
+}
+
+func TestDispatchLeasesWholeBatch(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+
+	service := &outbox{}
+	dispatcher := webhook.NewDispatcher(service, webhook.Options{Timeout: time.Second, BatchSize: 20})
+	require.NoError(t, dispatcher.Dispatch(context.Background()))
+	assert.Equal(t, 20*time.Second, service.lease)
+}
+
+func TestDispatchContinuesAfterUpdateError(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	sub := &webhook.Subscription{ID: uuid.NewV4(), URL: server.URL, Secret: "s3cr3t"}
+	service := &outbox{
+		subscription: sub,
+		deliveries: []*webhook.Delivery{
+			{ID: uuid.NewV4(), SubscriptionID: sub.ID, State: webhook.DeliveryPending},
+			{ID: uuid.NewV4(), SubscriptionID: sub.ID, State: webhook.DeliveryPending},
+		},
+		updateErr: errors.New("connection reset"),
+	}
+
+	err := webhook.NewDispatcher(service, webhook.Options{}).Dispatch(context.Background())
+	assert.EqualError(t, err, "connection reset")
+	assert.Len(t, service.updated, 2)
+}
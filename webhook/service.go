@@ -0,0 +1,179 @@
+package webhook
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	uuid "github.com/satori/go.uuid"
+)
+
+type Service interface {
+	GetSubscriptions() ([]*Subscription, error)
+	GetSubscription(id uuid.UUID) (*Subscription, error)
+	CreateSubscription(subscription *Subscription) error
+	DeleteSubscription(id uuid.UUID) error
+	ClaimDeliveries(now time.Time, lease time.Duration, limit int) ([]*Delivery, error)
+	UpdateDelivery(delivery *Delivery) error
+	FindDeliveries(subscriptionID uuid.UUID, state string, limit int) ([]*Delivery, error)
+}
+
+func NewDBService(db *gorm.DB) Service {
+	return &DBService{db: db}
+}
+
+type DBService struct {
+	db *gorm.DB
+}
+
+func (s DBService) GetSubscriptions() ([]*Subscription, error) {
+	var sub []*Subscription
+	err := s.db.Table(Subscription{}.TableName()).Order("created_at").Find(&sub).Error
+	if err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+// GetSubscription returns the subscription with the given ID or nil if there is no such subscription
+func (s DBService) GetSubscription(id uuid.UUID) (*Subscription, error) {
+	var sub Subscription
+	err := s.db.Table(sub.TableName()).Where("id = ?", id).Find(&sub).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &sub, nil
+}
+
+func (s DBService) CreateSubscription(subscription *Subscription) error {
+	if subscription.ID == uuid.Nil {
+		subscription.ID = uuid.NewV4()
+	}
+	return s.db.Create(subscription).Error
+}
+
+// DeleteSubscription deletes the subscription and its deliveries
+func (s DBService) DeleteSubscription(id uuid.UUID) error {
+	return s.db.Where("id = ?", id).Delete(Subscription{}).Error
+}
+
+// ClaimDeliveries returns the pending deliveries due at the given time and postpones them by the lease,
+// so that no other instance of the service sends them at the same time
+func (s DBService) ClaimDeliveries(now time.Time, lease time.Duration, limit int) ([]*Delivery, error) {
+	tx := s.db.Begin()
+	var d []*Delivery
+	err := tx.Table(Delivery{}.TableName()).
+		Set("gorm:query_option", "FOR UPDATE SKIP LOCKED").
+		Where("state = ? AND next_attempt_at <= ?", DeliveryPending, now).
+		Order("next_attempt_at").
+		Limit(limit).
+		Find(&d).Error
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	leased := now.Add(lease)
+	for _, delivery := range d {
+		delivery.NextAttemptAt = &leased
+		err := tx.Table(Delivery{}.TableName()).Where("id = ?", delivery.ID).UpdateColumn("next_attempt_at", leased).Error
+		if err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+	return d, tx.Commit().Error
+}
+
+func (s DBService) UpdateDelivery(delivery *Delivery) error {
+	return s.db.Save(delivery).Error
+}
+
+// FindDeliveries returns the deliveries of the subscription in the given state, the most recent first.
+// An empty state matches all deliveries.
+func (s DBService) FindDeliveries(subscriptionID uuid.UUID, state string, limit int) ([]*Delivery, error) {
+	query := s.db.Table(Delivery{}.TableName()).Where("subscription_id = ?", subscriptionID)
+	if state != "" {
+		query = query.Where("state = ?", state)
+	}
+	var d []*Delivery
+	err := query.Order("created_at desc").Limit(limit).Find(&d).Error
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// Publish records a pending delivery of the event for every subscription selecting its type. It is
+// called with the transaction of the state change the event reports, so that the deliveries are only
+// written if the change is committed.
+func Publish(tx *gorm.DB, event Event) error {
+	if event.ID == uuid.Nil {
+		event.ID = uuid.NewV4()
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	var subscriptions []*Subscription
+	err = tx.Table(Subscription{}.TableName()).Find(&subscriptions).Error
+	if err != nil {
+		return err
+	}
+	for _, sub := range subscriptions {
+		if !sub.EventTypes.Contains(event.Type) {
+			continue
+		}
+		delivery := &Delivery{
+			ID:             uuid.NewV4(),
+			SubscriptionID: sub.ID,
+			EventID:        event.ID,
+			EventType:      event.Type,
+			TenantID:       event.TenantID,
+			Payload:        string(payload),
+			State:          DeliveryPending,
+			NextAttemptAt:  &event.Time,
+		}
+		if err := tx.Create(delivery).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type NilService struct {
+}
+
+func (s NilService) GetSubscriptions() ([]*Subscription, error) {
+	return nil, nil
+}
+
+func (s NilService) GetSubscription(id uuid.UUID) (*Subscription, error) {
+	return nil, nil
+}
+
+func (s NilService) CreateSubscription(subscription *Subscription) error {
+	return nil
+}
+
+func (s NilService) DeleteSubscription(id uuid.UUID) error {
+	return nil
+}
+
+func (s NilService) ClaimDeliveries(now time.Time, lease time.Duration, limit int) ([]*Delivery, error) {
+	return nil, nil
+}
+
+func (s NilService) UpdateDelivery(delivery *Delivery) error {
+	return nil
+}
+
+func (s NilService) FindDeliveries(subscriptionID uuid.UUID, state string, limit int) ([]*Delivery, error) {
+	return nil, nil
+}
@@ -0,0 +1,144 @@
+// Package webhook notifies other services about the tenant lifecycle. Events are written to an outbox
+// table in the transaction of the state change they report, one delivery per matching subscription,
+// and sent in the background with retries.
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql/driver"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// Represents the event types subscriptions can select
+const (
+	EventTenantCreated  = "tenant.created"
+	EventNamespaceReady = "namespace.ready"
+	EventTenantUpdated  = "tenant.updated"
+	EventTenantFailed   = "tenant.failed"
+	EventTenantDeleted  = "tenant.deleted"
+)
+
+// EventTypes are all event types in the order they are documented
+var EventTypes = []string{EventTenantCreated, EventNamespaceReady, EventTenantUpdated, EventTenantFailed, EventTenantDeleted}
+
+// Represents the delivery states
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryFailed    = "failed"
+)
+
+// Represents the headers of a delivery
+const (
+	HeaderEvent     = "X-Tenant-Event"
+	HeaderDelivery  = "X-Tenant-Delivery"
+	HeaderSignature = "X-Tenant-Signature"
+)
+
+// Types is a list of event types
+type Types []string
+
+// Value - Implementation of valuer for database/sql
+func (t Types) Value() (driver.Value, error) {
+	if t == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(t)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// Scan - Implement the database/sql scanner interface
+func (t *Types) Scan(value interface{}) error {
+	if value == nil {
+		*t = nil
+		return nil
+	}
+	var b []byte
+	switch v := value.(type) {
+	case []byte:
+		b = v
+	case string:
+		b = []byte(v)
+	default:
+		return errors.New("failed to scan Types")
+	}
+	return json.Unmarshal(b, t)
+}
+
+// Contains returns if the list contains the event type
+func (t Types) Contains(eventType string) bool {
+	for _, e := range t {
+		if e == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// Subscription selects the events sent to a URL
+type Subscription struct {
+	ID        uuid.UUID `sql:"type:uuid" gorm:"primary_key"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	URL       string `gorm:"column:url"`
+	// Secret is the key the deliveries are signed with
+	Secret     string
+	EventTypes Types `sql:"type:jsonb"`
+}
+
+// TableName overrides the table name settings in Gorm to force a specific table name
+// in the database.
+func (m Subscription) TableName() string {
+	return "webhook_subscriptions"
+}
+
+// Event is a change in the lifecycle of a tenant
+type Event struct {
+	ID       uuid.UUID         `json:"id"`
+	Type     string            `json:"type"`
+	TenantID uuid.UUID         `json:"tenant_id"`
+	Time     time.Time         `json:"time"`
+	Data     map[string]string `json:"data,omitempty"`
+}
+
+// Delivery is an event to send to a subscription, the row of the outbox
+type Delivery struct {
+	ID             uuid.UUID `sql:"type:uuid" gorm:"primary_key"`
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	SubscriptionID uuid.UUID `sql:"type:uuid"`
+	EventID        uuid.UUID `sql:"type:uuid"`
+	EventType      string
+	TenantID       uuid.UUID `sql:"type:uuid"`
+	// Payload is the JSON encoded event sent as request body
+	Payload        string
+	State          string
+	Attempts       int
+	NextAttemptAt  *time.Time
+	LastAttemptAt  *time.Time
+	ResponseStatus int
+	Error          string
+}
+
+// TableName overrides the table name settings in Gorm to force a specific table name
+// in the database.
+func (m Delivery) TableName() string {
+	return "webhook_deliveries"
+}
+
+// Sign returns the signature of the payload sent in the X-Tenant-Signature header, the hex encoded
+// HMAC-SHA256 of the payload keyed with the subscription secret
+func Sign(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
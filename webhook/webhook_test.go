@@ -0,0 +1,27 @@
+package webhook_test
+
+import (
+	"testing"
+
+	"github.com/almighty/almighty-core/resource"
+	"github.com/fabric8io/fabric8-init-tenant/webhook"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSign(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+
+	// echo -n '{"type":"tenant.created"}' | openssl dgst -sha256 -hmac s3cr3t
+	assert.Equal(t,
+		"sha256=e106d062e1d433cffddd7418dcc5700b51b9ad47fea8b78de788db2f657e7f2e",
+		webhook.Sign("s3cr3t", []byte(`{"type":"tenant.created"}`)))
+	assert.NotEqual(t, webhook.Sign("s3cr3t", []byte("a")), webhook.Sign("other", []byte("a")))
+}
+
+func TestTypesContains(t *testing.T) {
+	resource.Require(t, resource.UnitTest)
+
+	types := webhook.Types{webhook.EventTenantCreated, webhook.EventTenantFailed}
+	assert.True(t, types.Contains(webhook.EventTenantFailed))
+	assert.False(t, types.Contains(webhook.EventTenantDeleted))
+}